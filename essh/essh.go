@@ -21,8 +21,27 @@ const (
 	ESSH_MSG_NEW_KEYS            = 21 // SSH_MSG_NEWKEYS
 	ESSH_MSG_DHKEXINIT  ESSHType = 30
 	ESSH_MSG_DHKEXREPLY ESSHType = 31
+
+	// Diffie-Hellman Group Exchange, RFC 4419. SSH_MSG_KEX_DH_GEX_GROUP
+	// shares its message code with SSH_MSG_KEXDH_REPLY.
+	ESSH_MSG_KEX_DH_GEX_GROUP   ESSHType = 31
+	ESSH_MSG_KEX_DH_GEX_INIT    ESSHType = 32
+	ESSH_MSG_KEX_DH_GEX_REPLY   ESSHType = 33
+	ESSH_MSG_KEX_DH_GEX_REQUEST ESSHType = 34
+)
+
+// Message numbers of the transport layer protocol, RFC 4250, section 4.1.2.
+// Those not decoded are skipped, while other messages are not expected
+// before the keys are in use.
+const (
+	esshTransportFirst ESSHType = 1
+	esshTransportLast  ESSHType = 49
 )
 
+// maxPacketLength is the largest packet_length of a binary packet skipped,
+// RFC 4253, section 6.1.
+const maxPacketLength = 35000
+
 // String shows the register type nicely formatted
 func (ss ESSHType) String() string {
 	switch ss {
@@ -38,6 +57,12 @@ func (ss ESSHType) String() string {
 		return "Diffie-Hellman Key Exchange Init"
 	case ESSH_MSG_DHKEXREPLY:
 		return "Diffie-Hellman Key Exchange Reploy"
+	case ESSH_MSG_KEX_DH_GEX_INIT:
+		return "Diffie-Hellman Group Exchange Init"
+	case ESSH_MSG_KEX_DH_GEX_REPLY:
+		return "Diffie-Hellman Group Exchange Reply"
+	case ESSH_MSG_KEX_DH_GEX_REQUEST:
+		return "Diffie-Hellman Group Exchange Request"
 
 	}
 }
@@ -60,9 +85,57 @@ type ESSH struct {
 
 	BannersComplete bool
 
-	// ESSH Records
-	Banner  *ESSHBannerRecord
-	Kexinit *ESSHKexinitRecord
+	// BannersOnly stops decoding after the banner, any records following
+	// it are left undecoded.
+	BannersOnly bool
+
+	// SkipPreamble skips the telnet negotiation and stray bytes some
+	// middleboxes and honeypots inject before the banner of a client. It
+	// is meant for the client side only: a server may send lines of its
+	// own before its banner, which are kept as PreBannerLines.
+	SkipPreamble bool
+
+	// NameListPolicy selects how invalid names in KEXINIT name-lists are
+	// handled, NameListAccept by default.
+	NameListPolicy NameListPolicy
+
+	// MaxNameListNames caps the names of each KEXINIT name-list and
+	// MaxNameListBytes the bytes of all of them, DefaultMaxNameListNames
+	// and DefaultMaxNameListBytes if 0. Larger KEXINITs fail the decoding
+	// with ErrKexinitTooLarge, before their data is copied or even waited
+	// for.
+	MaxNameListNames int
+	MaxNameListBytes int
+
+	// Protocol1 decodes the records following the banner as SSH protocol 1
+	// packets. It is set once the banner is of protocol 1 only, a server
+	// sending 1.99 speaks protocol 1 when the client banner does, which
+	// the caller has to tell.
+	Protocol1 bool
+
+	// Records holds the header of every binary packet decoded, in the
+	// order they were sent, including the transport layer messages which
+	// are only skipped. Decoding stops after SSH_MSG_NEWKEYS, the packets
+	// following it are encrypted.
+	Records []ESSHRecordHeader
+
+	// ESSH Records. With the group exchange, KexDHInit and KexDHReply
+	// are its SSH_MSG_KEX_DH_GEX_INIT and SSH_MSG_KEX_DH_GEX_REPLY.
+	Banner        *ESSHBannerRecord
+	Kexinit       *ESSHKexinitRecord
+	KexDHInit     *ESSHKexDHRecord
+	KexDHReply    *ESSHKexDHReplyRecord
+	KexGexRequest *ESSHKexGexRequestRecord
+	KexGexGroup   *ESSHKexGexGroupRecord
+
+	// NewKeys is set once SSH_MSG_NEWKEYS was decoded: the keys are in
+	// use from there on, and what follows is encrypted.
+	NewKeys bool
+
+	// Protocol 1 key exchange, decoding stops after either: what follows
+	// is encrypted.
+	SSH1PublicKey  *ESSH1PublicKeyRecord
+	SSH1SessionKey *ESSH1SessionKeyRecord
 }
 
 // decodeFromBytes decodes the Binary Packet Protocol as specified by RFC 4253, section 6.
@@ -71,14 +144,14 @@ type ESSH struct {
 //   byte       padding_length
 //   byte       message code
 type ESSHRecordHeader struct {
-	PacketLength  uint32
-	PaddingLength uint8
-	MessageCode   ESSHType
+	PacketLength  uint32   `json:"packet_length"`
+	PaddingLength uint8    `json:"padding_length"`
+	MessageCode   ESSHType `json:"message_code"`
 }
 
 func (h *ESSHRecordHeader) decodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
 	if len(data) < 6 {
-		return errors.New("ESSH invalid SSH header")
+		return fmt.Errorf("%w: invalid SSH header", ErrTruncated)
 	}
 	h.PacketLength = binary.BigEndian.Uint32(data[0:4])
 	h.PaddingLength = uint8(data[4:5][0])
@@ -86,6 +159,10 @@ func (h *ESSHRecordHeader) decodeFromBytes(data []byte, df gopacket.DecodeFeedba
 	return nil
 }
 
+// NewESSH returns an ESSH layer. With decb set, the banner is taken as
+// already exchanged and decoding starts at the binary packets, as for
+// streams where the banner was seen in an earlier segment or the capture
+// started after it.
 func NewESSH(decb bool) *ESSH {
 	return &ESSH{
 		BannersComplete: decb,
@@ -104,83 +181,169 @@ func decodeESSH(data []byte, p gopacket.PacketBuilder) error {
 	}
 	p.AddLayer(s)
 	p.SetApplicationLayer(s)
+	if len(s.BaseLayer.Payload) > 0 {
+		return p.NextDecoder(gopacket.LayerTypePayload)
+	}
 	return nil
 }
 
-// DecodeFromBytes decodes a byte slice into the ESSH struct
+// DecodeFromBytes decodes a byte slice into the ESSH struct. The bytes
+// following the decoded records, such as records the decoder does not handle
+// or the encrypted stream, are left as the payload. On error the records
+// decoded before it are kept, and the contents end where the failing record
+// starts: with ErrTruncated, decoding can resume from there once more data is
+// available.
 func (s *ESSH) DecodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
-	s.BaseLayer.Contents = data
-	s.BaseLayer.Payload = nil
-	return s.decodeESSHRecords(data, df)
+	n, err := s.decodeESSHRecords(data, df)
+	s.BaseLayer = layers.BaseLayer{Contents: data[:n], Payload: data[n:]}
+	return err
 }
 
-func (s *ESSH) decodeESSHRecords(data []byte, df gopacket.DecodeFeedback) error {
-	var err error
-
+// decodeESSHRecords decodes the banner, if not yet complete, and the records
+// following it until the data is exhausted or SSH_MSG_NEWKEYS was decoded.
+// It returns the number of bytes decoded.
+func (s *ESSH) decodeESSHRecords(data []byte, df gopacket.DecodeFeedback) (int, error) {
 	if len(data) < 4 {
 		df.SetTruncated()
-		return errors.New("ESSH record too short")
+		return 0, fmt.Errorf("%w: record too short", ErrTruncated)
 	}
 
-	// since there are no further layers, the baselayer's content is
-	// pointing to this layer
-	s.BaseLayer = layers.BaseLayer{Contents: data[:len(data)]}
+	n := 0
 
 	// If banners are not complete, try to parse these first.
 	if !s.BannersComplete {
 		var r ESSHBannerRecord
-		bl, err := r.decodeFromBytes(data, df)
+		bl, err := r.decodeFromBytes(data, s.SkipPreamble, df)
 		if err != nil {
 			// We must parse banners first, and these banners are invalid. Abort!
-			return nil
+			if errors.Is(err, ErrTruncated) {
+				df.SetTruncated()
+			}
+			return 0, err
 		}
 
 		// Banner successful!
 		s.Banner = &r
 		s.BannersComplete = true // important, if we have more data!
-		if bl == len(data) {
-			// All data is decoded!
-			return nil
+		if s.BannersOnly {
+			return bl, nil
+		}
+		n = bl
+		if r.SSH1() && r.ProtoVersion != "1.99" {
+			s.Protocol1 = true
 		}
-
-		// We must decode the rest of the data!
-		return s.decodeESSHRecords(data[bl:len(data)], df)
 	}
 
-	err = s.decodeKexRecords(data, df)
-	if err != nil {
-		return err
+	if s.Protocol1 {
+		if n == len(data) {
+			return n, nil
+		}
+		l, err := s.decodeSSH1Record(data[n:], df)
+		if err != nil {
+			if n > 0 && errors.Is(err, ErrWrongMessageCode) {
+				return n, nil
+			}
+			return n, err
+		}
+		return n + l, nil
 	}
 
-	return nil
+	// We must decode the rest of the data!
+	for n < len(data) {
+		l, err := s.decodeKexRecords(data[n:], df)
+		if err != nil {
+			// Records following the ones decoded which are not handled are
+			// left undecoded.
+			if n > 0 && errors.Is(err, ErrWrongMessageCode) {
+				return n, nil
+			}
+			return n, err
+		}
+		n += l
+		if s.Records[len(s.Records)-1].MessageCode == ESSH_MSG_NEW_KEYS {
+			break
+		}
+	}
+	return n, nil
 }
 
-func (s *ESSH) decodeKexRecords(data []byte, df gopacket.DecodeFeedback) error {
+// decodeKexRecords decodes a single binary packet, and returns its length.
+// Transport layer messages other than the key exchange are only skipped.
+func (s *ESSH) decodeKexRecords(data []byte, df gopacket.DecodeFeedback) (int, error) {
 	var h ESSHRecordHeader
 	err := h.decodeFromBytes(data, df)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	limits := s.nameListLimits()
+	if h.MessageCode == ESSH_MSG_KEXINIT && uint64(h.PacketLength) > uint64(limits.bytes)+kexinitOverhead {
+		return 0, fmt.Errorf("%w: packet of %d bytes", ErrKexinitTooLarge, h.PacketLength)
+	}
+
+	if h.MessageCode < esshTransportFirst || h.MessageCode > esshTransportLast {
+		return 0, fmt.Errorf("%w: %d, should be a transport layer message (%d to %d)",
+			ErrWrongMessageCode, h.MessageCode, esshTransportFirst, esshTransportLast)
+	}
+	skipped := true
+	switch h.MessageCode {
+	case ESSH_MSG_KEXINIT, ESSH_MSG_DHKEXINIT, ESSH_MSG_DHKEXREPLY,
+		ESSH_MSG_KEX_DH_GEX_INIT, ESSH_MSG_KEX_DH_GEX_REPLY, ESSH_MSG_KEX_DH_GEX_REQUEST:
+		skipped = false
+	}
+	if h.PacketLength < 2 || (skipped && h.PacketLength > maxPacketLength) {
+		return 0, fmt.Errorf("%w: %d in a packet of %d bytes", ErrWrongMessageCode, h.MessageCode, h.PacketLength)
 	}
 
 	hl := 6                            // header length
 	tl := hl + int(h.PacketLength) - 2 // minus padding_length and MessageCode field
 	if len(data) < tl {
 		df.SetTruncated()
-		return errors.New("ESSH packet length mismatch")
-	}
-
-	if h.MessageCode != ESSH_MSG_KEXINIT {
-		return fmt.Errorf("Wrong messagecode (%d), should be ESSH_MSG_KEXINIT (%d)", h.MessageCode, ESSH_MSG_KEXINIT)
+		return 0, fmt.Errorf("%w: packet length mismatch", ErrTruncated)
 	}
 
-	var r ESSHKexinitRecord
-	err = r.decodeFromBytes(data[hl:tl], h.PaddingLength, gopacket.NilDecodeFeedback)
-	if err != nil {
-		return err
+	switch h.MessageCode {
+	case ESSH_MSG_KEXINIT:
+		var r ESSHKexinitRecord
+		err = r.decodeFromBytes(data[hl:tl], h.PaddingLength, s.NameListPolicy, limits, gopacket.NilDecodeFeedback)
+		if err != nil {
+			return 0, err
+		}
+		// Key Exchange successful!
+		r.Header = h
+		s.Kexinit = &r
+	case ESSH_MSG_DHKEXINIT, ESSH_MSG_KEX_DH_GEX_INIT:
+		var r ESSHKexDHRecord
+		err = r.decodeFromBytes(data[hl:tl], h.MessageCode, h.PaddingLength, gopacket.NilDecodeFeedback)
+		if err != nil {
+			return 0, err
+		}
+		s.KexDHInit = &r
+	case ESSH_MSG_DHKEXREPLY, ESSH_MSG_KEX_DH_GEX_REPLY:
+		if h.MessageCode == ESSH_MSG_KEX_DH_GEX_GROUP {
+			if g, ok := decodeKexGexGroup(data[hl:tl], h.PaddingLength); ok {
+				s.KexGexGroup = g
+				break
+			}
+		}
+		var r ESSHKexDHReplyRecord
+		err = r.decodeFromBytes(data[hl:tl], h.MessageCode, h.PaddingLength, gopacket.NilDecodeFeedback)
+		if err != nil {
+			return 0, err
+		}
+		s.KexDHReply = &r
+	case ESSH_MSG_KEX_DH_GEX_REQUEST:
+		var r ESSHKexGexRequestRecord
+		err = r.decodeFromBytes(data[hl:tl], h.PaddingLength, gopacket.NilDecodeFeedback)
+		if err != nil {
+			return 0, err
+		}
+		s.KexGexRequest = &r
+	case ESSH_MSG_NEW_KEYS:
+		s.NewKeys = true
 	}
-	// Key Exchange successful!
-	s.Kexinit = &r
-	return nil
+	s.Records = append(s.Records, h)
+	return tl, nil
 }
 
 // CanDecode implements gopacket.DecodingLayer.
@@ -188,13 +351,18 @@ func (s *ESSH) CanDecode() gopacket.LayerClass {
 	return LayerTypeESSH
 }
 
-// NextLayerType implements gopacket.DecodingLayer.
+// NextLayerType implements gopacket.DecodingLayer. Bytes following the
+// decoded records are a payload.
 func (t *ESSH) NextLayerType() gopacket.LayerType {
+	if len(t.BaseLayer.Payload) > 0 {
+		return gopacket.LayerTypePayload
+	}
 	return gopacket.LayerTypeZero
 }
 
+// Payload returns the bytes following the decoded records.
 func (s *ESSH) Payload() []byte {
-	return nil
+	return s.BaseLayer.Payload
 }
 
 func init() {
@@ -2,8 +2,8 @@ package essh
 
 import (
 	"bytes"
-	"errors"
-	"io"
+	"fmt"
+	"strings"
 
 	"github.com/google/gopacket"
 )
@@ -13,9 +13,49 @@ import (
 // chars
 const maxVersionStringBytes = 255
 
+// maxPreBannerBytes is the maximum number of bytes of the lines a server
+// sends before its version string
+const maxPreBannerBytes = 2048
+
+// maxPreambleBytes is the maximum number of telnet negotiation and stray
+// bytes skipped before the version string
+const maxPreambleBytes = 64
+
+// Telnet commands, RFC 854
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetDONT = 254
+	telnetIAC  = 255
+)
+
 type ESSHBannerRecord struct {
 	ProtoVersion    string `json:"proto_version"`
 	SoftwareVersion string `json:"software_version"`
+	Comments        string `json:"comments,omitempty"`
+
+	// Bytes skipped before the version string, as injected by some
+	// middleboxes and honeypots
+	PreambleBytes int `json:"preamble_bytes,omitempty"`
+
+	// Every byte consumed up to the end of the version string line, with
+	// the preamble and the lines before, exactly as seen, and what
+	// terminated the version string: "\r\n" as required by the RFC, or
+	// "\n" as sent by some implementations
+	Raw        []byte `json:"raw,omitempty"`
+	Terminator string `json:"terminator,omitempty"`
+
+	// Lines sent before the version string, without their terminator.
+	// RFC 4253 allows servers to send them, clients ignore them.
+	PreBannerLines []string `json:"pre_banner_lines,omitempty"`
+}
+
+// SSH1 reports if the version string advertises SSH protocol 1: 1.99 for
+// a server also speaking protocol 2, as given by RFC 4253 section 5.1, or
+// any other 1.x for protocol 1 only.
+func (s *ESSHBannerRecord) SSH1() bool {
+	return strings.HasPrefix(s.ProtoVersion, "1.")
 }
 
 // decodeFromBytes decodes the version string as specified by RFC 4253, section 4.2, som a slice of bytes.
@@ -23,86 +63,135 @@ type ESSHBannerRecord struct {
 //
 //   SSH-<protoversion>-<softwareversion> <space> <comments...> CR LF
 //
+// With preamble set, the telnet negotiation and stray bytes in front of it
+// are skipped first, see skipPreamble.
 // It returns how much of the data that was processed.
-func (s *ESSHBannerRecord) decodeFromBytes(data []byte, df gopacket.DecodeFeedback) (int, error) {
-	/*	if len(data) > maxVersionStringBytes {
-		return 0, fmt.Errorf("Invalid version string, it should be less than %d characters including <CR><LF>",
-			maxVersionStringBytes,
-		)
-	}*/
-
-	versionString := make([]byte, 0, 64)
-	var ok bool
-	var buf [1]byte
-	crlf := 0
-
-	r := bytes.NewReader(data)
-
-	for length := 0; length < maxVersionStringBytes; length++ {
-		_, err := io.ReadFull(r, buf[:])
-		if err != nil {
+func (s *ESSHBannerRecord) decodeFromBytes(data []byte, preamble bool, df gopacket.DecodeFeedback) (int, error) {
+	var skip int
+	if preamble {
+		var err error
+		if skip, err = skipPreamble(data); err != nil {
 			return 0, err
 		}
-		// The RFC says that the version should be terminated with \r\n
-		// but several SSH servers actually only send a \n.
-		if buf[0] == '\n' {
-			if !bytes.HasPrefix(versionString, []byte("SSH-")) {
-				// RFC 4253 says we need to ignore all version string lines
-				// except the one containing the SSH version (provided that
-				// all the lines do not exceed 255 bytes in total).
-				versionString = versionString[:0]
-				continue
+	}
+	n, err := s.decodeVersionString(data[skip:], df)
+	if err != nil {
+		return 0, err
+	}
+	s.PreambleBytes = skip
+	s.Raw = append([]byte(nil), data[:skip+n]...)
+	return skip + n, nil
+}
+
+// skipPreamble returns the number of telnet negotiation (IAC sequences),
+// whitespace and non-printable bytes at the start of the data, up to
+// maxPreambleBytes.
+func skipPreamble(data []byte) (int, error) {
+	n := 0
+	for n < len(data) && n < maxPreambleBytes {
+		c := data[n]
+		switch {
+		case c == telnetIAC:
+			if n+1 >= len(data) {
+				return 0, fmt.Errorf("%w: telnet command is not terminated", ErrTruncated)
 			}
-			crlf += 1
-			ok = true
-			break
+			switch cmd := data[n+1]; {
+			case cmd == telnetSB:
+				// Subnegotiation, up to IAC SE
+				end := bytes.Index(data[n+2:], []byte{telnetIAC, telnetSE})
+				if end < 0 {
+					return 0, fmt.Errorf("%w: telnet subnegotiation is not terminated", ErrTruncated)
+				}
+				n += 2 + end + 2
+			case cmd >= telnetWILL && cmd <= telnetDONT:
+				// Option negotiation, followed by the option
+				if n+2 >= len(data) {
+					return 0, fmt.Errorf("%w: telnet negotiation is not terminated", ErrTruncated)
+				}
+				n += 3
+			default:
+				n += 2
+			}
+		case c < 0x21 || c > 0x7e:
+			n++
+		default:
+			return n, nil
 		}
-
-		// non ASCII chars are disallowed, but we are lenient,
-		// since Go doesn't use null-terminated strings.
-
-		// The RFC allows a comment after a space, however,
-		// all of it (version and comments) goes into the
-		// session hash.
-		versionString = append(versionString, buf[0])
 	}
+	return n, nil
+}
 
-	if !ok {
-		return 0, errors.New("invalid version string")
+// decodeVersionString decodes the version string at the start of the data,
+// after the lines a server may send before it, RFC 4253, section 4.2. It
+// returns the length of all of them.
+func (s *ESSHBannerRecord) decodeVersionString(data []byte, df gopacket.DecodeFeedback) (int, error) {
+	var versionString []byte
+	var lines [][]byte
+	n := 0
+	for {
+		end := bytes.IndexByte(data[n:min(len(data), n+maxVersionStringBytes)], '\n')
+		if end < 0 {
+			if len(data)-n < maxVersionStringBytes {
+				return 0, fmt.Errorf("%w: version string is not terminated", ErrTruncated)
+			}
+			return 0, fmt.Errorf("%w: invalid version string", ErrNotSSH)
+		}
+		line := data[n : n+end]
+		n += end + 1
+		if bytes.HasPrefix(line, []byte("SSH-")) {
+			versionString = line
+			break
+		}
+		// Other lines are ignored, as long as they do not add up to
+		// more than a few lines of text
+		if n > maxPreBannerBytes {
+			return 0, fmt.Errorf("%w: more than %d bytes before the version string", ErrNotSSH, maxPreBannerBytes)
+		}
+		lines = append(lines, line)
+	}
+	s.PreBannerLines = nil
+	for _, l := range lines {
+		s.PreBannerLines = append(s.PreBannerLines, string(bytes.TrimSuffix(l, []byte("\r"))))
 	}
 
-	// There might be a '\r' on the end which we should remove.
+	s.Terminator = "\n"
+
+	// The RFC says that the version should be terminated with \r\n
+	// but several SSH servers actually only send a \n.
 	if len(versionString) > 0 && versionString[len(versionString)-1] == '\r' {
 		versionString = versionString[:len(versionString)-1]
-		crlf += 1
+		s.Terminator = "\r\n"
 	}
 
-	lvs := len(versionString)
+	// non ASCII chars are disallowed, but we are lenient,
+	// since Go doesn't use null-terminated strings.
 
 	// First 4 bytes are "SSH-", we skip these.
 	bptr := 4
 
 	// Next is the protocol version before the next `-`
-	p := bytes.Index(data[bptr:], []byte("-"))
+	p := bytes.IndexByte(versionString[bptr:], '-')
 	if p < 1 {
-		return 0, errors.New("invalid version string: length of protocol version is too short")
+		return 0, fmt.Errorf("%w: invalid version string: length of protocol version is too short", ErrNotSSH)
 	}
-	s.ProtoVersion = string(data[bptr:(bptr + p)])
+	s.ProtoVersion = string(versionString[bptr:(bptr + p)])
 	bptr += p
 	bptr += 1 // skip -
 
+	// The RFC allows a comment after a space, however,
+	// all of it (version and comments) goes into the
+	// session hash.
+
 	// Next is the software version before either a space or end of versionstring.
-	sp := bytes.Index(data[bptr:], []byte{0x20})
+	sp := bytes.IndexByte(versionString[bptr:], 0x20)
 	if sp < 0 {
 		// No comment given
-		s.SoftwareVersion = string(data[bptr:lvs])
-		bptr = lvs
+		s.SoftwareVersion = string(versionString[bptr:])
 	} else {
-		// Software version is everything before the space.
-		s.SoftwareVersion = string(data[bptr:(bptr + sp)])
-		bptr += sp
+		// Software version is everything before the space, the comments
+		// everything after it.
+		s.SoftwareVersion = string(versionString[bptr:(bptr + sp)])
+		s.Comments = string(versionString[(bptr + sp + 1):])
 	}
-
-	bptr += crlf // Skip the line feed bytes.
-	return bptr, nil
+	return n, nil
 }
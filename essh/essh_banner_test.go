@@ -1,6 +1,10 @@
 package essh
 
 import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/gopacket"
@@ -10,16 +14,81 @@ var testBanner = map[string]struct {
 	data             []byte
 	proto_version    string
 	software_version string
+	comments         string
+	preamble         int
+	terminator       string
+	lines            []string
+	ssh1             bool
+	server           bool // sent by the server, no preamble is skipped
 }{
 	"new format": {
 		data:             append([]byte("SSH-2.0-OpenSSH_7.4"), []byte{0x0d, 0x0a}...),
 		proto_version:    "2.0",
 		software_version: "OpenSSH_7.4",
+		terminator:       "\r\n",
 	},
 	"comments": {
 		data:             append([]byte("SSH-2.0-OpenSSH_7.4 some comment..."), []byte{0x0d, 0x0a}...),
 		proto_version:    "2.0",
 		software_version: "OpenSSH_7.4",
+		comments:         "some comment...",
+		terminator:       "\r\n",
+	},
+	"distribution comments": {
+		data:             []byte("SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n"),
+		proto_version:    "2.0",
+		software_version: "OpenSSH_9.6p1",
+		comments:         "Ubuntu-3ubuntu13",
+		terminator:       "\r\n",
+	},
+	"compatible with protocol 1": {
+		data:             []byte("SSH-1.99-OpenSSH_3.9p1\r\n"),
+		proto_version:    "1.99",
+		software_version: "OpenSSH_3.9p1",
+		terminator:       "\r\n",
+		ssh1:             true,
+	},
+	"protocol 1": {
+		data:             []byte("SSH-1.5-Cisco-1.25\n"),
+		proto_version:    "1.5",
+		software_version: "Cisco-1.25",
+		terminator:       "\n",
+		ssh1:             true,
+	},
+	"line feed only": {
+		data:             []byte("SSH-2.0-dropbear_2019.78 \n"),
+		proto_version:    "2.0",
+		software_version: "dropbear_2019.78",
+		terminator:       "\n",
+	},
+	"telnet negotiation": {
+		data:             append([]byte{0xff, 0xfb, 0x01, 0xff, 0xfd, 0x03, 0xff, 0xfa, 0x18, 0x01, 0xff, 0xf0, 0x0d, 0x0a}, []byte("SSH-2.0-OpenSSH_7.4\r\n")...),
+		proto_version:    "2.0",
+		software_version: "OpenSSH_7.4",
+		preamble:         14,
+		terminator:       "\r\n",
+	},
+	"telnet negotiation from the server": {
+		data:             append([]byte{0xff, 0xfb, 0x01, 0x0d, 0x0a}, []byte("SSH-2.0-OpenSSH_7.4\r\n")...),
+		server:           true,
+		proto_version:    "2.0",
+		software_version: "OpenSSH_7.4",
+		terminator:       "\r\n",
+		lines:            []string{"\xff\xfb\x01"},
+	},
+	"pre-banner lines": {
+		data:             []byte("Welcome to host-1\r\nAuthorized use only\n\r\nSSH-2.0-OpenSSH_7.4\r\n"),
+		proto_version:    "2.0",
+		software_version: "OpenSSH_7.4",
+		terminator:       "\r\n",
+		lines:            []string{"Welcome to host-1", "Authorized use only", ""},
+	},
+	"dashes in the lines before": {
+		data:             []byte("Hello SSH-1.99-x\r\nSSH-2.0-Cisco-1.25\r\n"),
+		proto_version:    "2.0",
+		software_version: "Cisco-1.25",
+		terminator:       "\r\n",
+		lines:            []string{"Hello SSH-1.99-x"},
 	},
 }
 
@@ -28,7 +97,13 @@ func TestBanner(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			t.Log(k)
 			r := &ESSHBannerRecord{}
-			r.decodeFromBytes(test.data, gopacket.NilDecodeFeedback)
+			l, err := r.decodeFromBytes(test.data, !test.server, gopacket.NilDecodeFeedback)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l != len(test.data) {
+				t.Errorf("failed testcase '%s', mismatch on decoded length\n\nexpected:\n%d\ngot: \n%d\n", k, len(test.data), l)
+			}
 
 			if r.ProtoVersion != test.proto_version {
 				t.Errorf("failed testcase '%s', mismatch on ProtoVersion\n\nexpected:\n%s\ngot: \n%s\n", k, test.proto_version, r.ProtoVersion)
@@ -36,7 +111,36 @@ func TestBanner(t *testing.T) {
 			if r.SoftwareVersion != test.software_version {
 				t.Errorf("failed testcase '%s', mismatch on SoftwareVersion\n\nexpected:\n%s\ngot: \n%s\n", k, test.software_version, r.SoftwareVersion)
 			}
+			if r.Comments != test.comments {
+				t.Errorf("failed testcase '%s', mismatch on Comments\n\nexpected:\n%s\ngot: \n%s\n", k, test.comments, r.Comments)
+			}
+			if r.PreambleBytes != test.preamble {
+				t.Errorf("failed testcase '%s', mismatch on PreambleBytes\n\nexpected:\n%d\ngot: \n%d\n", k, test.preamble, r.PreambleBytes)
+			}
+			if !bytes.Equal(r.Raw, test.data) {
+				t.Errorf("failed testcase '%s', mismatch on Raw\n\nexpected:\n%q\ngot: \n%q\n", k, test.data, r.Raw)
+			}
+			if !reflect.DeepEqual(r.PreBannerLines, test.lines) {
+				t.Errorf("failed testcase '%s', mismatch on PreBannerLines\n\nexpected:\n%q\ngot: \n%q\n", k, test.lines, r.PreBannerLines)
+			}
+			if r.Terminator != test.terminator {
+				t.Errorf("failed testcase '%s', mismatch on Terminator\n\nexpected:\n%q\ngot: \n%q\n", k, test.terminator, r.Terminator)
+			}
+			if r.SSH1() != test.ssh1 {
+				t.Errorf("failed testcase '%s', mismatch on SSH1\n\nexpected:\n%t\ngot: \n%t\n", k, test.ssh1, r.SSH1())
+			}
 
 		})
 	}
 }
+
+func TestBannerTooManyLines(t *testing.T) {
+	data := []byte(strings.Repeat("Welcome\r\n", maxPreBannerBytes/9+1) + "SSH-2.0-OpenSSH_7.4\r\n")
+	r := &ESSHBannerRecord{}
+	if _, err := r.decodeFromBytes(data, false, gopacket.NilDecodeFeedback); !errors.Is(err, ErrNotSSH) {
+		t.Errorf("expected %v, got %v", ErrNotSSH, err)
+	}
+	if _, err := r.decodeFromBytes([]byte("Welcome\r\nSSH-2.0-Open"), false, gopacket.NilDecodeFeedback); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected %v, got %v", ErrTruncated, err)
+	}
+}
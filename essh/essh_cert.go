@@ -0,0 +1,162 @@
+package essh
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// Certificate types, as given by PROTOCOL.certkeys of OpenSSH
+const (
+	ESSHCertUser = 1
+	ESSHCertHost = 2
+)
+
+// ESSHCertForever is the valid before of certificates which do not expire
+const ESSHCertForever = ^uint64(0)
+
+// certSuffix ends the key format identifier of OpenSSH certificates
+const certSuffix = "-cert-v01@openssh.com"
+
+// certKeyFields is the number of strings and mpints holding the public key
+// of each certificate type, between the nonce and the serial
+var certKeyFields = map[string]int{
+	"ssh-rsa-cert-v01@openssh.com":                2, // e, n
+	"ssh-dss-cert-v01@openssh.com":                4, // p, q, g, y
+	"ecdsa-sha2-nistp256-cert-v01@openssh.com":    2, // curve, public_key
+	"ecdsa-sha2-nistp384-cert-v01@openssh.com":    2,
+	"ecdsa-sha2-nistp521-cert-v01@openssh.com":    2,
+	"ssh-ed25519-cert-v01@openssh.com":            1, // pk
+	"sk-ecdsa-sha2-nistp256-cert-v01@openssh.com": 3, // curve, public_key, application
+	"sk-ssh-ed25519-cert-v01@openssh.com":         2, // pk, application
+}
+
+// ESSHCertificate is an OpenSSH certificate, as given by PROTOCOL.certkeys:
+//
+//	string    "*-cert-v01@openssh.com"
+//	string    nonce
+//	...       public key fields, depending on the type
+//	uint64    serial
+//	uint32    type
+//	string    key id
+//	string    valid principals
+//	uint64    valid after
+//	uint64    valid before
+//	string    critical options
+//	string    extensions
+//	string    reserved
+//	string    signature key
+//	string    signature
+//
+// Options, extensions and the signature are not decoded. Validity is in
+// seconds since the epoch, ESSHCertForever for certificates which do not
+// expire.
+type ESSHCertificate struct {
+	Serial      uint64   `json:"serial"`
+	CertType    uint32   `json:"cert_type"`
+	KeyID       string   `json:"key_id"`
+	Principals  []string `json:"principals,omitempty"`
+	ValidAfter  uint64   `json:"valid_after"`
+	ValidBefore uint64   `json:"valid_before"`
+
+	// Key of the certificate authority which signed the certificate
+	CAKey         *ESSHHostKey `json:"-"`
+	CAKeyType     string       `json:"ca_key_type"`
+	CAFingerprint string       `json:"ca_fingerprint"`
+}
+
+// IsCertificate reports if the type is the one of an OpenSSH certificate.
+func IsCertificate(t string) bool {
+	return strings.HasSuffix(t, certSuffix)
+}
+
+// decodeCertificate decodes the certificate in the blob of a host key, nil
+// if its type is unknown or it is malformed.
+func decodeCertificate(t string, blob []byte) *ESSHCertificate {
+	fields, ok := certKeyFields[t]
+	if !ok {
+		return nil
+	}
+	r := certReader{data: blob}
+	r.string() // type
+	r.string() // nonce
+	for i := 0; i < fields; i++ {
+		r.string()
+	}
+	c := &ESSHCertificate{}
+	c.Serial = r.uint64()
+	c.CertType = r.uint32()
+	c.KeyID = string(r.string())
+	principals := certReader{data: r.string()}
+	for len(principals.data) > 0 && principals.ok() {
+		p := principals.string()
+		if principals.ok() {
+			c.Principals = append(c.Principals, string(p))
+		}
+	}
+	c.ValidAfter = r.uint64()
+	c.ValidBefore = r.uint64()
+	r.string() // critical options
+	r.string() // extensions
+	r.string() // reserved
+	c.CAKey = decodeHostKey(r.raw())
+	if !r.ok() || !principals.ok() || c.CAKey == nil {
+		return nil
+	}
+	c.CAKeyType = c.CAKey.Type
+	c.CAFingerprint = c.CAKey.FingerprintSHA256()
+	return c
+}
+
+// certReader reads the fields of a certificate in order, any field out of
+// bounds fails all the following ones.
+type certReader struct {
+	data []byte
+	err  bool
+}
+
+func (r *certReader) ok() bool {
+	return !r.err
+}
+
+func (r *certReader) take(n int) []byte {
+	if r.err || len(r.data) < n {
+		r.err = true
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *certReader) uint32() uint32 {
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *certReader) uint64() uint64 {
+	if b := r.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// string returns the content of the string, without its length.
+func (r *certReader) string() []byte {
+	l := r.uint32()
+	if r.err || uint64(len(r.data)) < uint64(l) {
+		r.err = true
+		return nil
+	}
+	return r.take(int(l))
+}
+
+// raw returns the string including its length, as decodeHostKey reads it.
+func (r *certReader) raw() []byte {
+	data := r.data
+	if b := r.string(); r.ok() {
+		return data[:4+len(b)]
+	}
+	return nil
+}
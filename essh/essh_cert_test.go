@@ -0,0 +1,99 @@
+package essh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testCertificate returns a host certificate signed as ssh-keygen -s would,
+// and the key of its certificate authority.
+func testCertificate(t *testing.T, principals []string, validBefore uint64) ([]byte, ssh.PublicKey) {
+	hostKey, err := ssh.NewPublicKey(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, 32)).Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ssh.NewSignerFromKey(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &ssh.Certificate{
+		Key:             hostKey,
+		Serial:          42,
+		CertType:        ssh.HostCert,
+		KeyId:           "host-1.example.com",
+		ValidPrincipals: principals,
+		ValidAfter:      1700000000,
+		ValidBefore:     validBefore,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return cert.Marshal(), ca.PublicKey()
+}
+
+// sshString returns the data as a string of RFC 4251, section 5.
+func sshString(data []byte) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(data))), data...)
+}
+
+func TestCertificate(t *testing.T) {
+	for k, test := range map[string]struct {
+		principals  []string
+		validBefore uint64
+	}{
+		"principals": {
+			principals:  []string{"host-1.example.com", "10.0.0.1"},
+			validBefore: 1800000000,
+		},
+		"no principals, forever": {
+			validBefore: ESSHCertForever,
+		},
+	} {
+		t.Run(k, func(t *testing.T) {
+			blob, ca := testCertificate(t, test.principals, test.validBefore)
+			k := decodeHostKey(sshString(blob))
+			if k == nil {
+				t.Fatal("host key not decoded")
+			}
+			if k.Type != "ssh-ed25519-cert-v01@openssh.com" {
+				t.Errorf("mismatch on type, got %s", k.Type)
+			}
+			expected := &ESSHCertificate{
+				Serial:        42,
+				CertType:      ESSHCertHost,
+				KeyID:         "host-1.example.com",
+				Principals:    test.principals,
+				ValidAfter:    1700000000,
+				ValidBefore:   test.validBefore,
+				CAKey:         &ESSHHostKey{Type: ca.Type(), Blob: ca.Marshal()},
+				CAKeyType:     "ssh-ed25519",
+				CAFingerprint: ssh.FingerprintSHA256(ca),
+			}
+			if !reflect.DeepEqual(k.Certificate, expected) {
+				t.Errorf("mismatch on certificate\n\nexpected:\n%+v\ngot: \n%+v\n", expected, k.Certificate)
+			}
+		})
+	}
+}
+
+func TestCertificateMalformed(t *testing.T) {
+	blob, _ := testCertificate(t, []string{"host-1.example.com"}, ESSHCertForever)
+
+	// Truncated in the signature key
+	k := decodeHostKey(sshString(blob[:len(blob)-100]))
+	if k == nil || k.Certificate != nil {
+		t.Errorf("expected a host key without certificate, got %+v", k)
+	}
+
+	// Not a certificate, even if the key is named like one
+	k = decodeHostKey(sshString(sshString([]byte("unknown-cert-v01@openssh.com"))))
+	if k == nil || k.Certificate != nil {
+		t.Errorf("expected a host key without certificate, got %+v", k)
+	}
+}
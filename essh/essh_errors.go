@@ -0,0 +1,46 @@
+package essh
+
+import (
+	"errors"
+)
+
+// Errors returned by the decoder, possibly wrapped with details. Use
+// errors.Is to check for them.
+var (
+	// ErrTruncated is returned when the data ends before the record does,
+	// the record might decode once more data is available.
+	ErrTruncated = errors.New("ESSH record truncated")
+
+	// ErrNotSSH is returned when the data does not start with a SSH
+	// version string.
+	ErrNotSSH = errors.New("ESSH not SSH")
+
+	// ErrMalformedKexinit is returned when a SSH_MSG_KEXINIT does not
+	// match its specification in RFC 4253, section 7.1.
+	ErrMalformedKexinit = errors.New("ESSH malformed KEXINIT")
+
+	// ErrWrongMessageCode is returned for records with a message code the
+	// decoder does not handle.
+	ErrWrongMessageCode = errors.New("ESSH wrong message code")
+
+	// ErrInvalidNameList is returned, when rejecting them, for name-lists
+	// with names outside the grammar of RFC 4251, section 6.
+	ErrInvalidNameList = errors.New("ESSH invalid name-list")
+
+	// ErrKexinitTooLarge is returned for KEXINITs whose name-lists exceed
+	// ESSH.MaxNameListNames or ESSH.MaxNameListBytes.
+	ErrKexinitTooLarge = errors.New("ESSH KEXINIT too large")
+
+	// ErrMalformedKexGex is returned when a SSH_MSG_KEX_DH_GEX_REQUEST does
+	// not match its specification in RFC 4419, section 3.
+	ErrMalformedKexGex = errors.New("ESSH malformed KEX_DH_GEX")
+
+	// ErrMalformedKexDH is returned when a Diffie-Hellman message is
+	// shorter than its padding, see RFC 4253, section 6.
+	ErrMalformedKexDH = errors.New("ESSH malformed KEXDH")
+
+	// ErrMalformedSSH1 is returned for protocol 1 packets which do not
+	// match their specification in draft-ylonen-ssh-protocol-00, or fail
+	// their check.
+	ErrMalformedSSH1 = errors.New("ESSH malformed protocol 1 packet")
+)
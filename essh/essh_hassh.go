@@ -0,0 +1,129 @@
+package essh
+
+import (
+	"strings"
+
+	"github.com/kjelle/gohassh"
+)
+
+// HASSH computes the fingerprints from the decoded Kexinit of the layer. A
+// KEXINIT does not tell which side sent it, so both the client (hassh) and
+// server (hasshServer) fingerprint is returned and it is up to the caller
+// to pick the one matching the direction of the packet.
+// ok is false if the layer holds no KEXINIT.
+func (s *ESSH) HASSH() (client string, server string, ok bool) {
+	if s.Kexinit == nil {
+		return "", "", false
+	}
+	return Fingerprint(s.Kexinit), FingerprintServer(s.Kexinit), true
+}
+
+// Fingerprint returns the HASSH of a client KEXINIT. Unlike ESSH.HASSH it
+// needs no layer: tools holding the SSH payload, such as proxies and
+// honeypots, can fingerprint a KEXINIT decoded by other means. It returns
+// the empty string for a nil KEXINIT.
+func Fingerprint(k *ESSHKexinitRecord) string {
+	if k == nil {
+		return ""
+	}
+	cr := &gohassh.ClientRecord{
+		KexAlgos:                k.KexAlgos,
+		CiphersClientServer:     k.CiphersClientServer,
+		MACsClientServer:        k.MACsClientServer,
+		CompressionClientServer: k.CompressionClientServer,
+	}
+	return cr.Compute().Hassh
+}
+
+// FingerprintServer returns the HASSHServer of a server KEXINIT, see
+// Fingerprint.
+func FingerprintServer(k *ESSHKexinitRecord) string {
+	if k == nil {
+		return ""
+	}
+	sr := &gohassh.ServerRecord{
+		KexAlgos:                k.KexAlgos,
+		CiphersServerClient:     k.CiphersServerClient,
+		MACsServerClient:        k.MACsServerClient,
+		CompressionServerClient: k.CompressionServerClient,
+	}
+	return sr.Compute().HasshServer
+}
+
+// HASSHListDiff tells how one name-list of two HASSH algorithm strings
+// differs. The order of the algorithms is part of the fingerprint, so lists
+// holding the same algorithms in another order differ with nothing added or
+// removed.
+type HASSHListDiff struct {
+	Differs bool
+	Added   []string // in the second list only
+	Removed []string // in the first list only
+}
+
+// HASSHDiff tells how two HASSH algorithm strings differ, list by list.
+type HASSHDiff struct {
+	KexAlgos    HASSHListDiff
+	Ciphers     HASSHListDiff
+	MACs        HASSHListDiff
+	Compression HASSHListDiff
+}
+
+// Differs reports if any of the lists differ.
+func (d HASSHDiff) Differs() bool {
+	return d.KexAlgos.Differs || d.Ciphers.Differs || d.MACs.Differs || d.Compression.Differs
+}
+
+// DiffHASSH compares two HASSH algorithm strings, the semicolon-joined
+// name-lists the fingerprints are the MD5 of, to explain why the
+// fingerprints differ. Missing lists compare as empty.
+func DiffHASSH(a, b string) HASSHDiff {
+	la := strings.Split(a, ";")
+	lb := strings.Split(b, ";")
+	list := func(l []string, i int) string {
+		if i < len(l) {
+			return l[i]
+		}
+		return ""
+	}
+
+	var d HASSHDiff
+	for i, ld := range []*HASSHListDiff{&d.KexAlgos, &d.Ciphers, &d.MACs, &d.Compression} {
+		*ld = diffNameList(list(la, i), list(lb, i))
+	}
+	return d
+}
+
+// diffNameList compares two comma-separated name-lists.
+func diffNameList(a, b string) HASSHListDiff {
+	if a == b {
+		return HASSHListDiff{}
+	}
+	split := func(l string) []string {
+		if l == "" {
+			return nil
+		}
+		return strings.Split(l, ",")
+	}
+	na, nb := split(a), split(b)
+	in := func(names []string) map[string]bool {
+		m := make(map[string]bool, len(names))
+		for _, n := range names {
+			m[n] = true
+		}
+		return m
+	}
+	ma, mb := in(na), in(nb)
+
+	d := HASSHListDiff{Differs: true}
+	for _, n := range nb {
+		if !ma[n] {
+			d.Added = append(d.Added, n)
+		}
+	}
+	for _, n := range na {
+		if !mb[n] {
+			d.Removed = append(d.Removed, n)
+		}
+	}
+	return d
+}
@@ -0,0 +1,110 @@
+package essh
+
+import (
+	"crypto/md5"
+	"fmt"
+	"testing"
+
+	"github.com/google/gopacket"
+)
+
+var testESSHHASSH = map[string]struct {
+	kexinit     string // key in testKexinit
+	hassh       string
+	hasshserver string
+}{
+	"OpenSSH_7.4 Client": {
+		kexinit:     "OpenSSH_7.4 Client Key Exchange Init",
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "ec9ea89c70f5fc71cf61061bff5e4740",
+	},
+	"OpenSSH_7.4 Server": {
+		kexinit:     "OpenSSH_7.4 Server Key Exchange Init",
+		hassh:       "6832f1ce43d4397c2c0a3e2f8c94334e",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+	},
+}
+
+func TestESSHHASSH(t *testing.T) {
+	for k, test := range testESSHHASSH {
+		t.Run(k, func(t *testing.T) {
+			t.Log(k)
+			s := &ESSH{}
+			_, err := s.decodeKexRecords(testKexinit[test.kexinit].data, gopacket.NilDecodeFeedback)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hassh, hasshserver, ok := s.HASSH()
+			if !ok {
+				t.Fatalf("failed testcase '%s', no KEXINIT", k)
+			}
+			if hassh != test.hassh {
+				t.Errorf("failed testcase '%s', mismatch on hassh\n\nexpected:\n%s\ngot: \n%s\n", k, test.hassh, hassh)
+			}
+			if hasshserver != test.hasshserver {
+				t.Errorf("failed testcase '%s', mismatch on hasshServer\n\nexpected:\n%s\ngot: \n%s\n", k, test.hasshserver, hasshserver)
+			}
+		})
+	}
+
+	t.Run("No KEXINIT", func(t *testing.T) {
+		s := &ESSH{}
+		if _, _, ok := s.HASSH(); ok {
+			t.Errorf("expected ok to be false without KEXINIT")
+		}
+	})
+}
+
+func TestFingerprintKexinit(t *testing.T) {
+	k := &ESSHKexinitRecord{
+		KexAlgos:                "curve25519-sha256",
+		CiphersClientServer:     "aes128-ctr",
+		CiphersServerClient:     "aes256-ctr",
+		MACsClientServer:        "hmac-sha2-256",
+		MACsServerClient:        "hmac-sha2-512",
+		CompressionClientServer: "none",
+		CompressionServerClient: "zlib",
+	}
+	for _, test := range []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"Fingerprint", Fingerprint(k), fmt.Sprintf("%x", md5.Sum([]byte("curve25519-sha256;aes128-ctr;hmac-sha2-256;none")))},
+		{"FingerprintServer", FingerprintServer(k), fmt.Sprintf("%x", md5.Sum([]byte("curve25519-sha256;aes256-ctr;hmac-sha2-512;zlib")))},
+		{"Fingerprint of nil", Fingerprint(nil), ""},
+		{"FingerprintServer of nil", FingerprintServer(nil), ""},
+	} {
+		if test.got != test.expected {
+			t.Errorf("mismatch on %s\n\nexpected:\n%s\ngot: \n%s\n", test.name, test.expected, test.got)
+		}
+	}
+}
+
+func TestDiffHASSH(t *testing.T) {
+	a := "curve25519-sha256,ecdh-sha2-nistp256;aes128-ctr;hmac-sha2-256;none"
+	b := "curve25519-sha256,diffie-hellman-group14-sha1;aes128-ctr;hmac-sha2-256;none,zlib@openssh.com"
+
+	d := DiffHASSH(a, a)
+	if d.Differs() {
+		t.Errorf("identical strings differ: %+v", d)
+	}
+
+	d = DiffHASSH(a, b)
+	if !d.Differs() || d.Ciphers.Differs || d.MACs.Differs {
+		t.Fatalf("wrong lists differ: %+v", d)
+	}
+	if fmt.Sprint(d.KexAlgos.Added, d.KexAlgos.Removed) != "[diffie-hellman-group14-sha1] [ecdh-sha2-nistp256]" {
+		t.Errorf("wrong kex difference: %+v", d.KexAlgos)
+	}
+	if fmt.Sprint(d.Compression.Added, d.Compression.Removed) != "[zlib@openssh.com] []" {
+		t.Errorf("wrong compression difference: %+v", d.Compression)
+	}
+
+	// Order is part of the fingerprint
+	d = DiffHASSH("a,b;c;d;e", "b,a;c;d;e")
+	if !d.KexAlgos.Differs || len(d.KexAlgos.Added) != 0 || len(d.KexAlgos.Removed) != 0 {
+		t.Errorf("reordered list: %+v", d.KexAlgos)
+	}
+}
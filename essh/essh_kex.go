@@ -3,6 +3,7 @@ package essh
 import (
 	"encoding/binary"
 	"fmt"
+	"strings"
 
 	"github.com/google/gopacket"
 )
@@ -25,7 +26,6 @@ import (
 //      uint32       0 (reserved for future extension)
 //
 type ESSHKexinitRecord struct {
-	//	Cookie                  [16]byte `sshtype:"20"`
 	KexAlgos                string `json:"kex"`
 	ServerHostKeyAlgos      string `json:"shka"`
 	CiphersClientServer     string `json:"eacts"`
@@ -38,97 +38,182 @@ type ESSHKexinitRecord struct {
 	LanguagesServerClient   string `json:"lstc"`
 	FirstKexFollows         bool   `json:"follow"`
 	//	Reserved                uint32
-}
 
-const (
-// Contants used for parsing
+	// Header is the binary packet header the record was decoded from, its
+	// lengths can tell implementations apart.
+	Header ESSHRecordHeader `json:"-"`
 
-)
+	// Sanitized is set when names outside the RFC 4251 grammar were
+	// replaced, see NameListSanitize.
+	Sanitized bool `json:"sanitized,omitempty"`
 
-// decodeFromBytes decodes the Key Exchange (kex) as specified by RFC 4253, section 7.1.
-func (s *ESSHKexinitRecord) decodeFromBytes(data []byte, pad uint8, df gopacket.DecodeFeedback) error {
-	var l uint32
-	//fmt.Printf("cookie: %02x\n", data[0:16])
-	bptr := uint32(16) // Skip cookie
+	// Pseudo-algorithms advertised in kex_algorithms: ext-info-c or
+	// ext-info-s (RFC 8308), kex-strict-c-v00@openssh.com or
+	// kex-strict-s-v00@openssh.com. They are part of the HASSH like any
+	// other name.
+	ExtInfo   bool `json:"ext_info"`
+	StrictKex bool `json:"strict_kex"`
 
-	// kex_algorithms
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.KexAlgos = string((data[bptr:(bptr + l)])[:])
-		bptr += l
-	}
+	// Cookie is the random bytes of the KEXINIT, not part of the HASSH.
+	Cookie [16]byte `json:"-"`
 
-	// server_host_key_algorithms
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.ServerHostKeyAlgos = string((data[bptr:(bptr + l)])[:])
-		bptr += l
-	}
+	// Which of the kex-strict markers was advertised: strict key exchange,
+	// the mitigation of Terrapin (CVE-2023-48795), is only in use with
+	// kex-strict-c-v00@openssh.com from the client and
+	// kex-strict-s-v00@openssh.com from the server.
+	StrictKexClient bool `json:"-"`
+	StrictKexServer bool `json:"-"`
 
-	// encryption_algorithms_client_to_server
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.CiphersClientServer = string((data[bptr:(bptr + l)])[:])
-		bptr += l
-	}
+	// Which of the ext-info markers was advertised: clients signal
+	// extension negotiation with ext-info-c, servers with ext-info-s.
+	ExtInfoClient bool `json:"-"`
+	ExtInfoServer bool `json:"-"`
+}
 
-	// encryption_algorithms_server_to_client
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.CiphersServerClient = string((data[bptr:(bptr + l)])[:])
-		bptr += l
-	}
+// KexinitAlgorithms holds the name-lists of a KEXINIT split into their
+// names, in the order they are offered. An empty name-list has no names.
+type KexinitAlgorithms struct {
+	Kex                     []string
+	ServerHostKey           []string
+	CiphersClientServer     []string
+	CiphersServerClient     []string
+	MACsClientServer        []string
+	MACsServerClient        []string
+	CompressionClientServer []string
+	CompressionServerClient []string
+	LanguagesClientServer   []string
+	LanguagesServerClient   []string
+}
 
-	// mac_algorithms_client_to_server
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.MACsClientServer = string((data[bptr:(bptr + l)])[:])
-		bptr += l
+// Algorithms returns the name-lists of the record split into their names,
+// for looking at individual algorithms rather than the fingerprint.
+func (s *ESSHKexinitRecord) Algorithms() KexinitAlgorithms {
+	split := func(nl string) []string {
+		if nl == "" {
+			return nil
+		}
+		return strings.Split(nl, ",")
 	}
+	return KexinitAlgorithms{
+		Kex:                     split(s.KexAlgos),
+		ServerHostKey:           split(s.ServerHostKeyAlgos),
+		CiphersClientServer:     split(s.CiphersClientServer),
+		CiphersServerClient:     split(s.CiphersServerClient),
+		MACsClientServer:        split(s.MACsClientServer),
+		MACsServerClient:        split(s.MACsServerClient),
+		CompressionClientServer: split(s.CompressionClientServer),
+		CompressionServerClient: split(s.CompressionServerClient),
+		LanguagesClientServer:   split(s.LanguagesClientServer),
+		LanguagesServerClient:   split(s.LanguagesServerClient),
+	}
+}
 
-	// mac_algorithms_server_to_client
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.MACsServerClient = string((data[bptr:(bptr + l)])[:])
-		bptr += l
+// NameListPolicy selects how name-lists holding names outside the grammar of
+// RFC 4251, section 6, are handled: names must be non-empty printable
+// US-ASCII without commas or whitespace.
+type NameListPolicy uint8
+
+const (
+	// NameListAccept keeps name-lists as they are
+	NameListAccept NameListPolicy = iota
+	// NameListReject fails the decoding with ErrInvalidNameList
+	NameListReject
+	// NameListSanitize replaces invalid bytes with '?', drops empty names
+	// and sets Sanitized
+	NameListSanitize
+)
+
+// Defaults of ESSH.MaxNameListNames and ESSH.MaxNameListBytes, far above
+// what implementations send: OpenSSH offers some 15 names per name-list and
+// 1500 bytes in all.
+const (
+	DefaultMaxNameListNames = 512
+	DefaultMaxNameListBytes = 32768
+)
+
+// kexinitOverhead is the most a KEXINIT packet holds besides its
+// name-lists: the padding length, message code, cookie, name-list lengths,
+// first_kex_packet_follows, reserved field and padding.
+const kexinitOverhead = 1 + 1 + 16 + 10*4 + 1 + 4 + 255
+
+// nameListLimits caps the name-lists of a KEXINIT
+type nameListLimits struct {
+	names int // of each name-list
+	bytes int // of all name-lists
+}
+
+func (s *ESSH) nameListLimits() nameListLimits {
+	l := nameListLimits{names: s.MaxNameListNames, bytes: s.MaxNameListBytes}
+	if l.names <= 0 {
+		l.names = DefaultMaxNameListNames
+	}
+	if l.bytes <= 0 {
+		l.bytes = DefaultMaxNameListBytes
 	}
+	return l
+}
 
-	// compression_algorithms_client_to_server
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.CompressionClientServer = string((data[bptr:(bptr + l)])[:])
-		bptr += l
+// decodeFromBytes decodes the Key Exchange (kex) as specified by RFC 4253, section 7.1.
+func (s *ESSHKexinitRecord) decodeFromBytes(data []byte, pad uint8, policy NameListPolicy, limits nameListLimits, df gopacket.DecodeFeedback) error {
+	var err error
+	if len(data) < 16 {
+		return fmt.Errorf("%w: too short for cookie", ErrMalformedKexinit)
 	}
+	copy(s.Cookie[:], data[:16])
+	bptr := uint32(16)
+	budget := uint32(limits.bytes)
 
-	// compression_algorithms_server_to_client
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.CompressionServerClient = string((data[bptr:(bptr + l)])[:])
-		bptr += l
+	// name-lists in the order given by the RFC
+	for _, nl := range []*string{
+		&s.KexAlgos,                // kex_algorithms
+		&s.ServerHostKeyAlgos,      // server_host_key_algorithms
+		&s.CiphersClientServer,     // encryption_algorithms_client_to_server
+		&s.CiphersServerClient,     // encryption_algorithms_server_to_client
+		&s.MACsClientServer,        // mac_algorithms_client_to_server
+		&s.MACsServerClient,        // mac_algorithms_server_to_client
+		&s.CompressionClientServer, // compression_algorithms_client_to_server
+		&s.CompressionServerClient, // compression_algorithms_server_to_client
+		&s.LanguagesClientServer,   // languages_client_to_server
+		&s.LanguagesServerClient,   // languages_server_to_client
+	} {
+		start := bptr
+		*nl, bptr, err = decodeNameList(data, bptr, budget)
+		if err != nil {
+			return err
+		}
+		budget -= bptr - start - 4
+		if n := strings.Count(*nl, ",") + 1; n > limits.names {
+			return fmt.Errorf("%w: name-list of %d names", ErrKexinitTooLarge, n)
+		}
+		if policy == NameListAccept || validNameList(*nl) {
+			continue
+		}
+		if policy == NameListReject {
+			return fmt.Errorf("%w: %q", ErrInvalidNameList, *nl)
+		}
+		*nl = sanitizeNameList(*nl)
+		s.Sanitized = true
 	}
 
-	// languages_client_to_server
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.LanguagesClientServer = string((data[bptr:(bptr + l)])[:])
-		bptr += l
+	for _, name := range strings.Split(s.KexAlgos, ",") {
+		switch pseudoAlgorithms[name] {
+		case pseudoExtInfoClient:
+			s.ExtInfo = true
+			s.ExtInfoClient = true
+		case pseudoExtInfoServer:
+			s.ExtInfo = true
+			s.ExtInfoServer = true
+		case pseudoStrictKexClient:
+			s.StrictKex = true
+			s.StrictKexClient = true
+		case pseudoStrictKexServer:
+			s.StrictKex = true
+			s.StrictKexServer = true
+		}
 	}
 
-	// languages_server_to_client
-	l = binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
-	bptr += 4
-	if l > 0 {
-		s.LanguagesServerClient = string((data[bptr:(bptr + l)])[:])
-		bptr += l
+	if uint32(len(data)) < bptr+5 {
+		return fmt.Errorf("%w: too short for first_kex_packet_follows", ErrMalformedKexinit)
 	}
 
 	// first_kex_packet_follows
@@ -141,8 +226,125 @@ func (s *ESSHKexinitRecord) decodeFromBytes(data []byte, pad uint8, df gopacket.
 	// Padding
 	p := uint8(uint32(len(data)) - bptr)
 	if p != pad {
-		return fmt.Errorf("Misaligned padding, expected %d, got %d", pad, p)
+		return fmt.Errorf("%w: misaligned padding, expected %d, got %d", ErrMalformedKexinit, pad, p)
 	}
 
 	return nil
 }
+
+// decodeNameList decodes the name-list at bptr, as specified by RFC 4251,
+// section 5:
+//
+//   uint32    length
+//   byte[n]   comma-separated list of names
+//
+// It returns the name-list and the position following it. Name-lists longer
+// than max fail with ErrKexinitTooLarge.
+func decodeNameList(data []byte, bptr uint32, max uint32) (string, uint32, error) {
+	if uint64(len(data)) < uint64(bptr)+4 {
+		return "", bptr, fmt.Errorf("%w: name-list length at %d is out of bounds", ErrMalformedKexinit, bptr)
+	}
+	l := binary.BigEndian.Uint32(data[bptr:(bptr + 4)])
+	bptr += 4
+	if uint64(len(data)) < uint64(bptr)+uint64(l) {
+		return "", bptr, fmt.Errorf("%w: name-list of %d bytes at %d is out of bounds", ErrMalformedKexinit, l, bptr)
+	}
+	if l > max {
+		return "", bptr, fmt.Errorf("%w: name-list of %d bytes, at most %d left", ErrKexinitTooLarge, l, max)
+	}
+	return string(data[bptr:(bptr + l)]), bptr + l, nil
+}
+
+// Pseudo-algorithms of kex_algorithms, which signal extensions rather than
+// key exchange methods
+const (
+	pseudoNone = iota
+	pseudoExtInfoClient
+	pseudoExtInfoServer
+	pseudoStrictKexClient
+	pseudoStrictKexServer
+)
+
+var pseudoAlgorithms = map[string]int{
+	"ext-info-c":                   pseudoExtInfoClient,
+	"ext-info-s":                   pseudoExtInfoServer,
+	"kex-strict-c-v00@openssh.com": pseudoStrictKexClient,
+	"kex-strict-s-v00@openssh.com": pseudoStrictKexServer,
+}
+
+// IsPseudoAlgorithm reports if the name of kex_algorithms signals an
+// extension rather than a key exchange method.
+func IsPseudoAlgorithm(name string) bool {
+	return pseudoAlgorithms[name] != pseudoNone
+}
+
+// Validate checks that the record offers at least min key exchange
+// algorithms, not counting the pseudo-algorithms, and min ciphers in both
+// directions. Streams which are not SSH sometimes decode into a KEXINIT with
+// empty or single lists, giving a meaningless HASSH.
+// It returns ErrMalformedKexinit otherwise.
+func (s *ESSHKexinitRecord) Validate(min int) error {
+	count := func(nl string, pseudo bool) int {
+		n := 0
+		for _, name := range strings.Split(nl, ",") {
+			if name != "" && (pseudo || pseudoAlgorithms[name] == pseudoNone) {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(s.KexAlgos, false); n < min {
+		return fmt.Errorf("%w: %d key exchange algorithms, expected at least %d", ErrMalformedKexinit, n, min)
+	}
+	if n := count(s.CiphersClientServer, true); n < min {
+		return fmt.Errorf("%w: %d client to server ciphers, expected at least %d", ErrMalformedKexinit, n, min)
+	}
+	if n := count(s.CiphersServerClient, true); n < min {
+		return fmt.Errorf("%w: %d server to client ciphers, expected at least %d", ErrMalformedKexinit, n, min)
+	}
+	return nil
+}
+
+// validNameList reports if all names of the name-list follow the grammar of
+// RFC 4251, section 6. The empty name-list is valid.
+func validNameList(nl string) bool {
+	if nl == "" {
+		return true
+	}
+	for _, name := range strings.Split(nl, ",") {
+		if name == "" {
+			return false
+		}
+		for i := 0; i < len(name); i++ {
+			if !validNameByte(name[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validNameByte reports if b is printable US-ASCII other than space.
+func validNameByte(b byte) bool {
+	return b > 0x20 && b < 0x7f
+}
+
+// sanitizeNameList replaces the bytes not allowed in names with '?', and
+// drops the empty names.
+func sanitizeNameList(nl string) string {
+	names := strings.Split(nl, ",")
+	kept := names[:0]
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		b := []byte(name)
+		for i := range b {
+			if !validNameByte(b[i]) {
+				b[i] = '?'
+			}
+		}
+		kept = append(kept, string(b))
+	}
+	return strings.Join(kept, ",")
+}
@@ -1,8 +1,12 @@
 package essh
 
 import (
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/google/gopacket"
@@ -26,6 +30,15 @@ var testKexinit = map[string]struct {
 			``,
 			``,
 			false,
+			ESSHRecordHeader{PacketLength: 1492, PaddingLength: 11, MessageCode: ESSH_MSG_KEXINIT},
+			false,
+			true,
+			false,
+			cookie(`92c601dc57d2e6b5398f52ee39fa6791`),
+			false,
+			false,
+			true,
+			false,
 		},
 	},
 	"OpenSSH_7.4 Server Key Exchange Init": {
@@ -42,6 +55,15 @@ var testKexinit = map[string]struct {
 			``,
 			``,
 			false,
+			ESSHRecordHeader{PacketLength: 1276, PaddingLength: 10, MessageCode: ESSH_MSG_KEXINIT},
+			false,
+			false,
+			false,
+			cookie(`57c8119f871366333f5f7d033b9777c0`),
+			false,
+			false,
+			false,
+			false,
 		},
 	},
 }
@@ -51,7 +73,7 @@ func TestKexinit(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			t.Log(k)
 			s := &ESSH{}
-			err := s.decodeKexRecords(test.data, gopacket.NilDecodeFeedback)
+			_, err := s.decodeKexRecords(test.data, gopacket.NilDecodeFeedback)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -63,7 +85,220 @@ func TestKexinit(t *testing.T) {
 	}
 }
 
+var testNameLists = map[string]struct {
+	kex       string
+	policy    NameListPolicy
+	expected  string
+	sanitized bool
+	err       error
+}{
+	"Valid": {
+		kex:      "curve25519-sha256,ext-info-c",
+		policy:   NameListReject,
+		expected: "curve25519-sha256,ext-info-c",
+	},
+	"Embedded NUL accepted": {
+		kex:      "curve25519-sha256\x00,ext-info-c",
+		policy:   NameListAccept,
+		expected: "curve25519-sha256\x00,ext-info-c",
+	},
+	"Embedded NUL rejected": {
+		kex:    "curve25519-sha256\x00,ext-info-c",
+		policy: NameListReject,
+		err:    ErrInvalidNameList,
+	},
+	"Embedded NUL sanitized": {
+		kex:       "curve25519-sha256\x00,ext-info-c",
+		policy:    NameListSanitize,
+		expected:  "curve25519-sha256?,ext-info-c",
+		sanitized: true,
+	},
+	"Control characters and non-ASCII sanitized": {
+		kex:       "curve25519-sha256\r\n\"x\":1,caf\xc3\xa9",
+		policy:    NameListSanitize,
+		expected:  "curve25519-sha256??\"x\":1,caf??",
+		sanitized: true,
+	},
+	"Whitespace rejected": {
+		kex:    "curve25519-sha256, ext-info-c",
+		policy: NameListReject,
+		err:    ErrInvalidNameList,
+	},
+	"Empty name rejected": {
+		kex:    "curve25519-sha256,,ext-info-c",
+		policy: NameListReject,
+		err:    ErrInvalidNameList,
+	},
+	"Empty names sanitized": {
+		kex:       ",curve25519-sha256,,ext-info-c,",
+		policy:    NameListSanitize,
+		expected:  "curve25519-sha256,ext-info-c",
+		sanitized: true,
+	},
+}
+
+func TestNameListPolicy(t *testing.T) {
+	for k, test := range testNameLists {
+		t.Run(k, func(t *testing.T) {
+			t.Log(k)
+			s := &ESSH{NameListPolicy: test.policy}
+			_, err := s.decodeKexRecords(buildKexinit(test.kex), gopacket.NilDecodeFeedback)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("failed testcase '%s', mismatch on error\n\nexpected:\n%v\ngot: \n%v\n", k, test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if s.Kexinit.KexAlgos != test.expected {
+				t.Errorf("failed testcase '%s', mismatch on kex\n\nexpected:\n%q\ngot: \n%q\n", k, test.expected, s.Kexinit.KexAlgos)
+			}
+			if s.Kexinit.Sanitized != test.sanitized {
+				t.Errorf("failed testcase '%s', mismatch on Sanitized, expected %t", k, test.sanitized)
+			}
+		})
+	}
+}
+
+func TestKexPseudoAlgorithms(t *testing.T) {
+	for kex, expected := range map[string][2]bool{
+		"curve25519-sha256":                                         {false, false},
+		"curve25519-sha256,ext-info-c":                              {true, false},
+		"curve25519-sha256,ext-info-s,kex-strict-s-v00@openssh.com": {true, true},
+		"curve25519-sha256,kex-strict-c-v00@openssh.com":            {false, true},
+		"curve25519-sha256,ext-info-cx":                             {false, false},
+	} {
+		s := &ESSH{}
+		if _, err := s.decodeKexRecords(buildKexinit(kex), gopacket.NilDecodeFeedback); err != nil {
+			t.Fatal(err)
+		}
+		if s.Kexinit.ExtInfo != expected[0] || s.Kexinit.StrictKex != expected[1] {
+			t.Errorf("mismatch for %q, expected ext-info:%t strict-kex:%t, got %t %t", kex, expected[0], expected[1], s.Kexinit.ExtInfo, s.Kexinit.StrictKex)
+		}
+		c, sv := strings.Contains(kex, "kex-strict-c-"), strings.Contains(kex, "kex-strict-s-")
+		if s.Kexinit.StrictKexClient != c || s.Kexinit.StrictKexServer != sv {
+			t.Errorf("mismatch for %q, expected strict-kex client:%t server:%t, got %t %t", kex, c, sv, s.Kexinit.StrictKexClient, s.Kexinit.StrictKexServer)
+		}
+		c, sv = strings.Contains(kex, "ext-info-c,") || strings.HasSuffix(kex, "ext-info-c"), strings.Contains(kex, "ext-info-s")
+		if s.Kexinit.ExtInfoClient != c || s.Kexinit.ExtInfoServer != sv {
+			t.Errorf("mismatch for %q, expected ext-info client:%t server:%t, got %t %t", kex, c, sv, s.Kexinit.ExtInfoClient, s.Kexinit.ExtInfoServer)
+		}
+	}
+}
+
+// buildKexinit returns a KEXINIT packet with kex as kex_algorithms and
+// ssh-ed25519 as the other name-lists.
+func buildKexinit(kex string) []byte {
+	payload := []byte{byte(ESSH_MSG_KEXINIT)}
+	payload = append(payload, make([]byte, 16)...) // cookie
+	for i := 0; i < 10; i++ {
+		nl := "ssh-ed25519"
+		if i == 0 {
+			nl = kex
+		}
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(nl)))
+		payload = append(payload, nl...)
+	}
+	payload = append(payload, 0, 0, 0, 0, 0) // first_kex_packet_follows, reserved
+
+	pad := 8 - (5+len(payload))%8
+	if pad < 4 {
+		pad += 8
+	}
+	data := binary.BigEndian.AppendUint32(nil, uint32(1+len(payload)+pad))
+	data = append(data, byte(pad))
+	data = append(data, payload...)
+	return append(data, make([]byte, pad)...)
+}
+
+func TestKexinitTooLarge(t *testing.T) {
+	hugeList := buildKexinit("curve25519-sha256")
+	binary.BigEndian.PutUint32(hugeList[22:], 0xfffffff0) // kex_algorithms length
+
+	hugePacket := buildKexinit("curve25519-sha256")[:6]
+	binary.BigEndian.PutUint32(hugePacket, 0x7ffffff0)
+
+	manyNames := strings.Repeat("a,", DefaultMaxNameListNames) + "a"
+	manyBytes := strings.Repeat("a", 600)
+
+	for k, test := range map[string]struct {
+		data  []byte
+		limit ESSH
+		err   error
+	}{
+		"Declared packet length":     {data: hugePacket, err: ErrKexinitTooLarge},
+		"Declared name-list length":  {data: hugeList, err: ErrMalformedKexinit}, // beyond its packet
+		"Too many names":             {data: buildKexinit(manyNames), err: ErrKexinitTooLarge},
+		"Names below a raised limit": {data: buildKexinit(manyNames), limit: ESSH{MaxNameListNames: 1000}},
+		"Too many bytes":             {data: buildKexinit(manyBytes), limit: ESSH{MaxNameListBytes: 512}, err: ErrKexinitTooLarge},
+		"Bytes below the default":    {data: buildKexinit(manyBytes)},
+	} {
+		t.Run(k, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			s := test.limit
+			_, err := s.decodeKexRecords(test.data, gopacket.NilDecodeFeedback)
+			runtime.ReadMemStats(&after)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("failed testcase '%s', mismatch on error\n\nexpected:\n%v\ngot: \n%v\n", k, test.err, err)
+			}
+			if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+				t.Errorf("failed testcase '%s', %d bytes allocated", k, n)
+			}
+		})
+	}
+}
+
+func cookie(s string) [16]byte {
+	var c [16]byte
+	copy(c[:], decodeString(s))
+	return c
+}
+
+func TestKexinitAlgorithms(t *testing.T) {
+	k := &ESSHKexinitRecord{
+		KexAlgos:                "curve25519-sha256,ext-info-c",
+		CiphersClientServer:     "aes128-ctr",
+		CompressionServerClient: "none,zlib@openssh.com",
+	}
+	expected := KexinitAlgorithms{
+		Kex:                     []string{"curve25519-sha256", "ext-info-c"},
+		CiphersClientServer:     []string{"aes128-ctr"},
+		CompressionServerClient: []string{"none", "zlib@openssh.com"},
+	}
+	if a := k.Algorithms(); !reflect.DeepEqual(a, expected) {
+		t.Errorf("mismatch on Algorithms\n\nexpected:\n%+v\ngot: \n%+v\n", expected, a)
+	}
+}
+
 func decodeString(s string) []byte {
 	b, _ := hex.DecodeString(s)
 	return b
 }
+
+func TestKexinitValidate(t *testing.T) {
+	for kex, valid := range map[string]bool{
+		"curve25519-sha256":                       true,
+		"curve25519-sha256,ext-info-c":            true,
+		"ext-info-c":                              false,
+		"ext-info-c,kex-strict-c-v00@openssh.com": false,
+		"": false,
+	} {
+		s := &ESSH{}
+		if _, err := s.decodeKexRecords(buildKexinit(kex), gopacket.NilDecodeFeedback); err != nil {
+			t.Fatal(err)
+		}
+		err := s.Kexinit.Validate(1)
+		if valid && err != nil {
+			t.Errorf("%q: %s", kex, err)
+		}
+		if !valid && !errors.Is(err, ErrMalformedKexinit) {
+			t.Errorf("%q: expected ErrMalformedKexinit, got %v", kex, err)
+		}
+	}
+
+	// Ciphers are counted too
+	k := ESSHKexinitRecord{KexAlgos: "curve25519-sha256", CiphersClientServer: "aes128-ctr"}
+	if err := k.Validate(1); !errors.Is(err, ErrMalformedKexinit) {
+		t.Errorf("no server to client ciphers: expected ErrMalformedKexinit, got %v", err)
+	}
+}
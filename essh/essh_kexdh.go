@@ -0,0 +1,131 @@
+package essh
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/google/gopacket"
+)
+
+// SSH Diffie-Hellman Key Exchange, as given by RFC 4253 section 8 and the
+// elliptic curve variant in RFC 5656 section 4:
+//
+//      byte      SSH_MSG_KEXDH_INIT
+//      mpint     e
+//
+//      byte      SSH_MSG_KEXDH_REPLY
+//      string    server public host key and certificates (K_S)
+//      mpint     f
+//      string    signature of H
+//
+// The content is not decoded, only the fact that the message was seen and
+// the size of its payload (excluding the message code and padding).
+type ESSHKexDHRecord struct {
+	MessageCode ESSHType `json:"message_code"`
+	Length      int      `json:"length"`
+}
+
+// decodeFromBytes records the Diffie-Hellman message given by code. The data
+// includes the random padding of pad bytes.
+func (s *ESSHKexDHRecord) decodeFromBytes(data []byte, code ESSHType, pad uint8, df gopacket.DecodeFeedback) error {
+	if len(data) < int(pad) {
+		return fmt.Errorf("%w: padding of %d bytes is longer than the %d bytes of data", ErrMalformedKexDH, pad, len(data))
+	}
+	s.MessageCode = code
+	s.Length = len(data) - int(pad)
+	return nil
+}
+
+// ESSHKexDHReplyRecord is a SSH_MSG_KEXDH_REPLY, or the SSH_MSG_KEX_ECDH_REPLY
+// of RFC 5656 sharing its message code, used by the ecdh-sha2-* and
+// curve25519-sha256 methods as well. The host key of the server is decoded
+// from it, f and the signature are not.
+type ESSHKexDHReplyRecord struct {
+	ESSHKexDHRecord
+	HostKey *ESSHHostKey `json:"host_key,omitempty"`
+}
+
+// ESSHHostKey is a public host key, in the format of RFC 4253, section 6.6:
+//
+//      string    certificate or public key format identifier
+//      byte[n]   key/certificate data
+//
+// Certificates, of the *-cert-v01@openssh.com types, are decoded as well.
+type ESSHHostKey struct {
+	Type string `json:"type"`
+	Blob []byte `json:"blob"` // the whole key, including its type
+
+	Certificate *ESSHCertificate `json:"certificate,omitempty"`
+}
+
+// FingerprintSHA256 returns the fingerprint of the key as shown by OpenSSH,
+// "SHA256:" followed by the unpadded base64 of the SHA-256 of the blob.
+func (k *ESSHHostKey) FingerprintSHA256() string {
+	sum := sha256.Sum256(k.Blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// FingerprintMD5 returns the legacy fingerprint of the key, the MD5 of the
+// blob in hex with the bytes separated by colons.
+func (k *ESSHHostKey) FingerprintMD5() string {
+	sum := md5.Sum(k.Blob)
+	h := hex.EncodeToString(sum[:])
+	var b strings.Builder
+	for i := 0; i < len(h); i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(h[i : i+2])
+	}
+	return b.String()
+}
+
+// decodeFromBytes records the reply given by code and decodes the host key
+// at its start. The data includes the random padding of pad bytes. Replies
+// without a host key are kept without one.
+func (s *ESSHKexDHReplyRecord) decodeFromBytes(data []byte, code ESSHType, pad uint8, df gopacket.DecodeFeedback) error {
+	if err := s.ESSHKexDHRecord.decodeFromBytes(data, code, pad, df); err != nil {
+		return err
+	}
+	s.HostKey = decodeHostKey(data[:s.Length])
+	return nil
+}
+
+// decodeHostKey decodes the string K_S at the start of the data, nil if it
+// does not hold a host key.
+func decodeHostKey(data []byte) *ESSHHostKey {
+	blob, ok := readString(data)
+	if !ok {
+		return nil
+	}
+	t, ok := readString(blob)
+	if !ok || len(t) == 0 || !validNameList(string(t)) || strings.Contains(string(t), ",") {
+		return nil
+	}
+	k := &ESSHHostKey{
+		Type: string(t),
+		Blob: append([]byte(nil), blob...),
+	}
+	if IsCertificate(k.Type) {
+		k.Certificate = decodeCertificate(k.Type, k.Blob)
+	}
+	return k
+}
+
+// readString returns the string at the start of the data, as specified by
+// RFC 4251, section 5, and false if it is out of bounds.
+func readString(data []byte) ([]byte, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	l := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(l) {
+		return nil, false
+	}
+	return data[4 : 4+l], true
+}
@@ -0,0 +1,109 @@
+package essh
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket"
+)
+
+var testKexDH = map[string]struct {
+	data       []byte
+	init       *ESSHKexDHRecord
+	reply      *ESSHKexDHReplyRecord
+	gexRequest *ESSHKexGexRequestRecord
+	gexGroup   *ESSHKexGexGroupRecord
+}{
+	"Curve25519 Key Exchange Init": {
+		data: decodeString(`0000002c061e00000020b5f6d3a1c7e7e3b1f0a8d0b6c9f4e2a1d3c5b7a9e1f2d4c6b8a0e2f4d6c8b0a2000000000000`),
+		init: &ESSHKexDHRecord{
+			MessageCode: ESSH_MSG_DHKEXINIT,
+			Length:      36,
+		},
+	},
+	"ECDSA Key Exchange Reply": {
+		data: decodeString(`000001040a1f000000680000001365636473612d736861322d6e69737470323536000000086e697374703235360000004104c1476fc7fc13c09065726fd48c5fca0dfc69810167b74792dbbddaa5edd56dd313e7b3d8f6c9b75f484ed86b1f6ce67e04f4edea2fc9199dd6ed2f691bc7935f000000208258bdb8b20101673f64bb56b577dbd6c25da25b2f3cdaf5cfd7408c3fadc80c000000630000001365636473612d736861322d6e69737470323536000000480000002016b3135f33a46159757c10740822579b89fbcc1f4365f2461daf151bfd366aed00000020215ad1e25c8d527ba3607af9c829db971a06f45771bbb25ad0cc3e94a1b6b5640000000000000000000000`),
+		reply: &ESSHKexDHReplyRecord{
+			ESSHKexDHRecord: ESSHKexDHRecord{
+				MessageCode: ESSH_MSG_DHKEXREPLY,
+				Length:      248,
+			},
+			HostKey: &ESSHHostKey{
+				Type: "ecdsa-sha2-nistp256",
+				Blob: decodeString(`0000001365636473612d736861322d6e69737470323536000000086e697374703235360000004104c1476fc7fc13c09065726fd48c5fca0dfc69810167b74792dbbddaa5edd56dd313e7b3d8f6c9b75f484ed86b1f6ce67e04f4edea2fc9199dd6ed2f691bc7935f`),
+			},
+		},
+	},
+	"Group Exchange Request": {
+		// min 2048, n 8192, max 8192
+		data: decodeString(`000000180a22000008000000200000002000` + `00000000000000000000`),
+		gexRequest: &ESSHKexGexRequestRecord{
+			Min: 2048,
+			N:   8192,
+			Max: 8192,
+		},
+	},
+	"Group Exchange Group": {
+		// SSH_MSG_KEX_DH_GEX_GROUP, p and g: no host key
+		data: decodeString(`0000001a0a1f0000000500c7a1b3d50000000102` + `00000000000000000000`),
+		gexGroup: &ESSHKexGexGroupRecord{
+			GroupBits: 32,
+		},
+	},
+	"Group Exchange Init": {
+		data: decodeString(`000000150a20000000050091b2c3d4e5` + `00000000000000000000`),
+		init: &ESSHKexDHRecord{
+			MessageCode: ESSH_MSG_KEX_DH_GEX_INIT,
+			Length:      9,
+		},
+	},
+	"Group Exchange Reply": {
+		// K_S of an ssh-ed25519 key, f and the signature
+		data: decodeString(`0000004c0a21` + `000000330000000b7373682d6564323535313900000020` + strings.Repeat("8a", 32) + `0000000102` + `00000000` + `00000000000000000000`),
+		reply: &ESSHKexDHReplyRecord{
+			ESSHKexDHRecord: ESSHKexDHRecord{
+				MessageCode: ESSH_MSG_KEX_DH_GEX_REPLY,
+				Length:      64,
+			},
+			HostKey: &ESSHHostKey{
+				Type: "ssh-ed25519",
+				Blob: decodeString(`0000000b7373682d6564323535313900000020` + strings.Repeat("8a", 32)),
+			},
+		},
+	},
+}
+
+func TestKexDHPadding(t *testing.T) {
+	r := &ESSHKexDHRecord{}
+	if err := r.decodeFromBytes([]byte{0, 0}, ESSH_MSG_DHKEXINIT, 4, gopacket.NilDecodeFeedback); !errors.Is(err, ErrMalformedKexDH) {
+		t.Errorf("expected ErrMalformedKexDH for padding longer than the data, got %v", err)
+	}
+}
+
+func TestKexDH(t *testing.T) {
+	for k, test := range testKexDH {
+		t.Run(k, func(t *testing.T) {
+			t.Log(k)
+			s := &ESSH{}
+			_, err := s.decodeKexRecords(test.data, gopacket.NilDecodeFeedback)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(s.KexDHInit, test.init) {
+				t.Errorf("failed testcase '%s', mismatch on KexDHInit\n\nexpected:\n%v\ngot: \n%v\n", k, test.init, s.KexDHInit)
+			}
+			if !reflect.DeepEqual(s.KexDHReply, test.reply) {
+				t.Errorf("failed testcase '%s', mismatch on KexDHReply\n\nexpected:\n%v\ngot: \n%v\n", k, test.reply, s.KexDHReply)
+			}
+			if !reflect.DeepEqual(s.KexGexRequest, test.gexRequest) {
+				t.Errorf("failed testcase '%s', mismatch on KexGexRequest\n\nexpected:\n%v\ngot: \n%v\n", k, test.gexRequest, s.KexGexRequest)
+			}
+			if !reflect.DeepEqual(s.KexGexGroup, test.gexGroup) {
+				t.Errorf("failed testcase '%s', mismatch on KexGexGroup\n\nexpected:\n%v\ngot: \n%v\n", k, test.gexGroup, s.KexGexGroup)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package essh
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/google/gopacket"
+)
+
+// Diffie-Hellman Group Exchange, as given by RFC 4419 section 3, used by the
+// diffie-hellman-group-exchange-* methods:
+//
+//	byte      SSH_MSG_KEX_DH_GEX_REQUEST
+//	uint32    min, minimal size in bits of an acceptable group
+//	uint32    n, preferred size in bits of the group
+//	uint32    max, maximal size in bits of an acceptable group
+//
+//	byte      SSH_MSG_KEX_DH_GEX_GROUP
+//	mpint     p, safe prime
+//	mpint     g, generator for subgroup in GF(p)
+//
+// SSH_MSG_KEX_DH_GEX_INIT and SSH_MSG_KEX_DH_GEX_REPLY are the KEXDH_INIT and
+// KEXDH_REPLY of the group sent, and decoded as such. The obsolete
+// SSH_MSG_KEX_DH_GEX_REQUEST_OLD shares its message code with KEXDH_INIT and
+// is not told apart from it.
+type ESSHKexGexRequestRecord struct {
+	Min uint32 `json:"min"`
+	N   uint32 `json:"n"`
+	Max uint32 `json:"max"`
+}
+
+// decodeFromBytes decodes the group sizes the client asks for. The data
+// includes the random padding of pad bytes.
+func (s *ESSHKexGexRequestRecord) decodeFromBytes(data []byte, pad uint8, df gopacket.DecodeFeedback) error {
+	if len(data) != 12+int(pad) {
+		return fmt.Errorf("%w: group exchange request of %d bytes with padding of %d", ErrMalformedKexGex, len(data), pad)
+	}
+	s.Min = binary.BigEndian.Uint32(data[0:4])
+	s.N = binary.BigEndian.Uint32(data[4:8])
+	s.Max = binary.BigEndian.Uint32(data[8:12])
+	return nil
+}
+
+// ESSHKexGexGroupRecord is the group the server chose, of which only the
+// size is kept.
+type ESSHKexGexGroupRecord struct {
+	GroupBits int `json:"group_bits"` // of p
+}
+
+// decodeKexGexGroup decodes the data, including the random padding of pad
+// bytes, as a SSH_MSG_KEX_DH_GEX_GROUP. It returns false if the data does not
+// hold exactly p and g, as the KEXDH_REPLY sharing its message code.
+func decodeKexGexGroup(data []byte, pad uint8) (*ESSHKexGexGroupRecord, bool) {
+	if len(data) < int(pad) {
+		return nil, false
+	}
+	data = data[:len(data)-int(pad)]
+	p, ok := readString(data)
+	if !ok {
+		return nil, false
+	}
+	data = data[4+len(p):]
+	g, ok := readString(data)
+	if !ok || len(data) != 4+len(g) {
+		return nil, false
+	}
+	return &ESSHKexGexGroupRecord{
+		GroupBits: new(big.Int).SetBytes(p).BitLen(),
+	}, true
+}
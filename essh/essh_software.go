@@ -0,0 +1,70 @@
+package essh
+
+import (
+	"regexp"
+)
+
+// SoftwareInfo is a best-effort breakdown of the software version and
+// comments of a banner, e.g. "OpenSSH_8.9p1 Ubuntu-3ubuntu0.1" becomes
+//
+//   Product: "OpenSSH", Version: "8.9p1", OSComment: "Ubuntu-3ubuntu0.1"
+//
+// For unknown software the product is the raw software version.
+type SoftwareInfo struct {
+	Product   string `json:"product"`
+	Version   string `json:"version,omitempty"`
+	OSComment string `json:"os_comment,omitempty"`
+}
+
+// softwarePatterns recognizes common SSH implementations from the software
+// version. The first submatch, if any, is the version. Order matters, the
+// first matching pattern is used.
+var softwarePatterns = []struct {
+	product string
+	re      *regexp.Regexp
+}{
+	{"OpenSSH", regexp.MustCompile(`^OpenSSH_([^\s]+)`)},
+	{"PuTTY", regexp.MustCompile(`^PuTTY(?:_Release)?_([^\s]+)`)},
+	{"libssh2", regexp.MustCompile(`^libssh2_([^\s]+)`)},
+	{"libssh", regexp.MustCompile(`^libssh[_-]([^\s]+)`)},
+	{"paramiko", regexp.MustCompile(`^paramiko_([^\s]+)`)},
+	{"dropbear", regexp.MustCompile(`^dropbear_([^\s]+)`)},
+	{"Go", regexp.MustCompile(`^Go$`)}, // golang.org/x/crypto/ssh
+	{"masscan", regexp.MustCompile(`^masscan(?:[_/-]([^\s]+))?`)},
+}
+
+// KnownProduct reports if the product is one of the implementations
+// ParseSoftware recognizes, rather than a software version it does not.
+func KnownProduct(product string) bool {
+	for _, p := range softwarePatterns {
+		if p.product == product {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSoftware breaks down the software version and comments of a banner.
+func ParseSoftware(software string, comments string) SoftwareInfo {
+	info := SoftwareInfo{
+		Product:   software,
+		OSComment: comments,
+	}
+	for _, p := range softwarePatterns {
+		m := p.re.FindStringSubmatch(software)
+		if m == nil {
+			continue
+		}
+		info.Product = p.product
+		if len(m) > 1 {
+			info.Version = m[1]
+		}
+		break
+	}
+	return info
+}
+
+// SoftwareInfo breaks down the software version and comments of the banner.
+func (s *ESSHBannerRecord) SoftwareInfo() SoftwareInfo {
+	return ParseSoftware(s.SoftwareVersion, s.Comments)
+}
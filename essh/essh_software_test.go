@@ -0,0 +1,65 @@
+package essh
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testSoftware = map[string]struct {
+	software string
+	comments string
+	info     SoftwareInfo
+}{
+	"OpenSSH Ubuntu": {
+		software: "OpenSSH_8.9p1",
+		comments: "Ubuntu-3ubuntu0.1",
+		info:     SoftwareInfo{Product: "OpenSSH", Version: "8.9p1", OSComment: "Ubuntu-3ubuntu0.1"},
+	},
+	"PuTTY": {
+		software: "PuTTY_Release_0.76",
+		info:     SoftwareInfo{Product: "PuTTY", Version: "0.76"},
+	},
+	"libssh": {
+		software: "libssh_0.9.6",
+		info:     SoftwareInfo{Product: "libssh", Version: "0.9.6"},
+	},
+	"libssh2": {
+		software: "libssh2_1.10.0",
+		info:     SoftwareInfo{Product: "libssh2", Version: "1.10.0"},
+	},
+	"paramiko": {
+		software: "paramiko_2.11.0",
+		info:     SoftwareInfo{Product: "paramiko", Version: "2.11.0"},
+	},
+	"dropbear": {
+		software: "dropbear_2020.81",
+		info:     SoftwareInfo{Product: "dropbear", Version: "2020.81"},
+	},
+	"Go": {
+		software: "Go",
+		info:     SoftwareInfo{Product: "Go"},
+	},
+	"masscan": {
+		software: "masscan/1.3",
+		info:     SoftwareInfo{Product: "masscan", Version: "1.3"},
+	},
+	"unknown": {
+		software: "Cisco-1.25",
+		info:     SoftwareInfo{Product: "Cisco-1.25"},
+	},
+}
+
+func TestParseSoftware(t *testing.T) {
+	for k, test := range testSoftware {
+		t.Run(k, func(t *testing.T) {
+			t.Log(k)
+			info := ParseSoftware(test.software, test.comments)
+			if !reflect.DeepEqual(info, test.info) {
+				t.Errorf("failed testcase '%s', mismatch on SoftwareInfo\n\nexpected:\n%+v\ngot: \n%+v\n", k, test.info, info)
+			}
+			if known := k != "unknown"; KnownProduct(info.Product) != known {
+				t.Errorf("failed testcase '%s', expected known product: %t", k, known)
+			}
+		})
+	}
+}
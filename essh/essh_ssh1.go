@@ -0,0 +1,183 @@
+package essh
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/google/gopacket"
+)
+
+// SSH protocol 1, as spoken by servers with a protocol version 1.5, or 1.99
+// to clients sending 1.5, and described in draft-ylonen-ssh-protocol-00.
+// Its packets are:
+//
+//	uint32    length, of the type, data and check
+//	byte[n]   padding, n = 8 - (length % 8)
+//	byte      packet type
+//	byte[m]   data
+//	uint32    check, CRC-32 of the padding, type and data
+//
+// Only the two packets of the key exchange are decoded, everything following
+// them is encrypted:
+//
+//	byte      SSH_SMSG_PUBLIC_KEY
+//	byte[8]   anti-spoofing cookie
+//	uint32    server key bits
+//	mp-int    server key public exponent
+//	mp-int    server key public modulus
+//	uint32    host key bits
+//	mp-int    host key public exponent
+//	mp-int    host key public modulus
+//	uint32    protocol flags
+//	uint32    supported ciphers mask
+//	uint32    supported authentications mask
+//
+//	byte      SSH_CMSG_SESSION_KEY
+//	byte      cipher type
+//	byte[8]   anti-spoofing cookie
+//	mp-int    double-encrypted session key
+//	uint32    protocol flags
+//
+// An mp-int is its size in bits as an uint16, followed by as many bytes as
+// needed.
+const (
+	ESSH1_SMSG_PUBLIC_KEY  ESSHType = 2
+	ESSH1_CMSG_SESSION_KEY ESSHType = 3
+)
+
+// maxSSH1PacketLength is the largest length of a protocol 1 packet.
+const maxSSH1PacketLength = 256 * 1024
+
+// ssh1Ciphers are the names of the protocol 1 ciphers, by cipher type.
+var ssh1Ciphers = []string{"none", "idea", "des", "3des", "tss", "rc4", "blowfish"}
+
+// ssh1Auths are the names of the protocol 1 authentication methods, by
+// their number.
+var ssh1Auths = []string{1: "rhosts", "rsa", "password", "rhosts-rsa", "tis", "kerberos", "kerberos-tgt-passing"}
+
+// ESSH1PublicKeyRecord is the SSH_SMSG_PUBLIC_KEY of a protocol 1 server:
+// the sizes of its keys and what it supports. The keys themselves are not
+// kept.
+type ESSH1PublicKeyRecord struct {
+	ServerKeyBits uint32   `json:"server_key_bits"`
+	HostKeyBits   uint32   `json:"host_key_bits"`
+	ProtocolFlags uint32   `json:"protocol_flags"`
+	CiphersMask   uint32   `json:"ciphers_mask"`
+	AuthMask      uint32   `json:"auth_mask"`
+	Ciphers       []string `json:"ciphers"`
+	Auths         []string `json:"auths"`
+}
+
+func (s *ESSH1PublicKeyRecord) decodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
+	r := certReader{data: data}
+	r.take(8) // cookie
+	s.ServerKeyBits = r.uint32()
+	skipMPInt1(&r)
+	skipMPInt1(&r)
+	s.HostKeyBits = r.uint32()
+	skipMPInt1(&r)
+	skipMPInt1(&r)
+	s.ProtocolFlags = r.uint32()
+	s.CiphersMask = r.uint32()
+	s.AuthMask = r.uint32()
+	if !r.ok() || len(r.data) != 0 {
+		return fmt.Errorf("%w: public key of %d bytes", ErrMalformedSSH1, len(data))
+	}
+	s.Ciphers = maskNames(s.CiphersMask, ssh1Ciphers)
+	s.Auths = maskNames(s.AuthMask, ssh1Auths)
+	return nil
+}
+
+// ESSH1SessionKeyRecord is the SSH_CMSG_SESSION_KEY of a protocol 1 client,
+// choosing the cipher.
+type ESSH1SessionKeyRecord struct {
+	CipherType    uint8  `json:"cipher_type"`
+	Cipher        string `json:"cipher,omitempty"`
+	ProtocolFlags uint32 `json:"protocol_flags"`
+}
+
+func (s *ESSH1SessionKeyRecord) decodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
+	r := certReader{data: data}
+	cipher := r.take(1)
+	r.take(8) // cookie
+	skipMPInt1(&r)
+	s.ProtocolFlags = r.uint32()
+	if !r.ok() || len(r.data) != 0 {
+		return fmt.Errorf("%w: session key of %d bytes", ErrMalformedSSH1, len(data))
+	}
+	s.CipherType = cipher[0]
+	if int(s.CipherType) < len(ssh1Ciphers) {
+		s.Cipher = ssh1Ciphers[s.CipherType]
+	}
+	return nil
+}
+
+// decodeSSH1Record decodes a single protocol 1 packet, and returns its
+// length.
+func (s *ESSH) decodeSSH1Record(data []byte, df gopacket.DecodeFeedback) (int, error) {
+	if len(data) < 4 {
+		df.SetTruncated()
+		return 0, fmt.Errorf("%w: protocol 1 packet length", ErrTruncated)
+	}
+	length := binary.BigEndian.Uint32(data[0:4])
+	if length < 5 || length > maxSSH1PacketLength {
+		return 0, fmt.Errorf("%w: protocol 1 packet of %d bytes", ErrMalformedSSH1, length)
+	}
+	pad := 8 - int(length%8)
+	tl := 4 + pad + int(length)
+	if len(data) < tl {
+		df.SetTruncated()
+		return 0, fmt.Errorf("%w: protocol 1 packet length mismatch", ErrTruncated)
+	}
+	check := binary.BigEndian.Uint32(data[tl-4 : tl])
+	if ssh1CRC(data[4:tl-4]) != check {
+		return 0, fmt.Errorf("%w: protocol 1 packet check mismatch", ErrMalformedSSH1)
+	}
+
+	code, payload := ESSHType(data[4+pad]), data[4+pad+1:tl-4]
+	switch code {
+	case ESSH1_SMSG_PUBLIC_KEY:
+		var r ESSH1PublicKeyRecord
+		if err := r.decodeFromBytes(payload, gopacket.NilDecodeFeedback); err != nil {
+			return 0, err
+		}
+		s.SSH1PublicKey = &r
+	case ESSH1_CMSG_SESSION_KEY:
+		var r ESSH1SessionKeyRecord
+		if err := r.decodeFromBytes(payload, gopacket.NilDecodeFeedback); err != nil {
+			return 0, err
+		}
+		s.SSH1SessionKey = &r
+	default:
+		return 0, fmt.Errorf("%w: %d, should be a protocol 1 public or session key", ErrWrongMessageCode, code)
+	}
+	return tl, nil
+}
+
+// ssh1CRC is the CRC-32 of protocol 1, without the inversions of the IEEE
+// one.
+func ssh1CRC(data []byte) uint32 {
+	return ^crc32.Update(^uint32(0), crc32.IEEETable, data)
+}
+
+// maskNames returns the names of the bits set in the mask.
+func maskNames(mask uint32, names []string) []string {
+	var set []string
+	for i, name := range names {
+		if name != "" && mask&(1<<i) != 0 {
+			set = append(set, name)
+		}
+	}
+	return set
+}
+
+// skipMPInt1 skips a protocol 1 mp-int, read with the reader of the fields
+// of certificates.
+func skipMPInt1(r *certReader) {
+	var bits uint16
+	if b := r.take(2); b != nil {
+		bits = binary.BigEndian.Uint16(b)
+	}
+	r.take((int(bits) + 7) / 8)
+}
@@ -0,0 +1,113 @@
+package essh
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/gopacket"
+)
+
+// ssh1Packet wraps the payload into a protocol 1 packet, with zero padding.
+func ssh1Packet(code ESSHType, payload []byte) []byte {
+	length := 1 + len(payload) + 4
+	pad := 8 - length%8
+	packet := binary.BigEndian.AppendUint32(nil, uint32(length))
+	packet = append(packet, make([]byte, pad)...)
+	packet = append(packet, byte(code))
+	packet = append(packet, payload...)
+	return binary.BigEndian.AppendUint32(packet, ssh1CRC(packet[4:]))
+}
+
+// mpint1 returns a protocol 1 mp-int of the given bits, all set.
+func mpint1(bits int) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(bits))
+	for i := 0; i < (bits+7)/8; i++ {
+		b = append(b, 0xff)
+	}
+	return b
+}
+
+func ssh1PublicKey() []byte {
+	payload := make([]byte, 8) // cookie
+	payload = binary.BigEndian.AppendUint32(payload, 768)
+	payload = append(append(payload, mpint1(6)...), mpint1(768)...)
+	payload = binary.BigEndian.AppendUint32(payload, 1024)
+	payload = append(append(payload, mpint1(6)...), mpint1(1024)...)
+	payload = binary.BigEndian.AppendUint32(payload, 2)         // protocol flags
+	payload = binary.BigEndian.AppendUint32(payload, 1<<3|1<<6) // 3des, blowfish
+	payload = binary.BigEndian.AppendUint32(payload, 1<<2|1<<3) // rsa, password
+	return ssh1Packet(ESSH1_SMSG_PUBLIC_KEY, payload)
+}
+
+func ssh1SessionKey() []byte {
+	payload := append([]byte{3}, make([]byte, 8)...) // 3des, cookie
+	payload = append(payload, mpint1(1024)...)
+	payload = binary.BigEndian.AppendUint32(payload, 2)
+	return ssh1Packet(ESSH1_CMSG_SESSION_KEY, payload)
+}
+
+func TestSSH1(t *testing.T) {
+	publicKey := &ESSH1PublicKeyRecord{
+		ServerKeyBits: 768,
+		HostKeyBits:   1024,
+		ProtocolFlags: 2,
+		CiphersMask:   1<<3 | 1<<6,
+		AuthMask:      1<<2 | 1<<3,
+		Ciphers:       []string{"3des", "blowfish"},
+		Auths:         []string{"rsa", "password"},
+	}
+
+	// Protocol 1 only server
+	s := &ESSH{}
+	data := append([]byte("SSH-1.5-Cisco-1.25\n"), ssh1PublicKey()...)
+	if err := s.DecodeFromBytes(data, gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Protocol1 || len(s.Payload()) != 0 {
+		t.Errorf("protocol 1 banner not followed by protocol 1: %t, %d bytes left", s.Protocol1, len(s.Payload()))
+	}
+	if !reflect.DeepEqual(s.SSH1PublicKey, publicKey) {
+		t.Errorf("mismatch on SSH1PublicKey\n\nexpected:\n%+v\ngot: \n%+v\n", publicKey, s.SSH1PublicKey)
+	}
+
+	// Server speaking both, told the client speaks protocol 1
+	s = &ESSH{BannersComplete: true, Protocol1: true}
+	if err := s.DecodeFromBytes(ssh1PublicKey(), gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.SSH1PublicKey, publicKey) {
+		t.Errorf("mismatch on SSH1PublicKey of 1.99\n\nexpected:\n%+v\ngot: \n%+v\n", publicKey, s.SSH1PublicKey)
+	}
+	s = &ESSH{}
+	if err := s.DecodeFromBytes([]byte("SSH-1.99-OpenSSH_3.9p1\r\n"), gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	if s.Protocol1 {
+		t.Error("protocol 1.99 taken for protocol 1 only")
+	}
+
+	// Client
+	s = &ESSH{}
+	data = append([]byte("SSH-1.5-OpenSSH_3.9p1\n"), ssh1SessionKey()...)
+	if err := s.DecodeFromBytes(data, gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	sessionKey := &ESSH1SessionKeyRecord{CipherType: 3, Cipher: "3des", ProtocolFlags: 2}
+	if !reflect.DeepEqual(s.SSH1SessionKey, sessionKey) {
+		t.Errorf("mismatch on SSH1SessionKey\n\nexpected:\n%+v\ngot: \n%+v\n", sessionKey, s.SSH1SessionKey)
+	}
+
+	// Truncated and corrupted packets
+	packet := ssh1PublicKey()
+	s = &ESSH{BannersComplete: true, Protocol1: true}
+	if err := s.DecodeFromBytes(packet[:len(packet)-1], gopacket.NilDecodeFeedback); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+	packet[len(packet)-1] ^= 1
+	s = &ESSH{BannersComplete: true, Protocol1: true}
+	if err := s.DecodeFromBytes(packet, gopacket.NilDecodeFeedback); !errors.Is(err, ErrMalformedSSH1) {
+		t.Errorf("expected ErrMalformedSSH1, got %v", err)
+	}
+}
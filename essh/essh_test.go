@@ -1,6 +1,10 @@
 package essh
 
 import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/gopacket"
@@ -23,7 +27,7 @@ func TestSshPacket(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			t.Log(k)
 			s := &ESSH{}
-			err := s.decodeESSHRecords(test.data, gopacket.NilDecodeFeedback)
+			_, err := s.decodeESSHRecords(test.data, gopacket.NilDecodeFeedback)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -33,3 +37,158 @@ func TestSshPacket(t *testing.T) {
 		})
 	}
 }
+
+func TestPayload(t *testing.T) {
+	newKeys := decodeString(`0000000c0a1500000000000000000000`)
+	packet := append(append([]byte{}, testSShPacket["Identification String and Kexinit"].data...), newKeys...)
+	trailer := decodeString(`8f3a0c5ed21b7a44`) // encrypted
+	data := append(append([]byte{}, packet...), trailer...)
+
+	s := &ESSH{}
+	if err := s.DecodeFromBytes(data, gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	if s.Kexinit == nil {
+		t.Fatal("KEXINIT not decoded")
+	}
+	if !bytes.Equal(s.LayerContents(), packet) {
+		t.Errorf("mismatch on contents, expected %d bytes, got %d", len(packet), len(s.LayerContents()))
+	}
+	if !bytes.Equal(s.Payload(), trailer) {
+		t.Errorf("mismatch on payload\n\nexpected:\n%x\ngot: \n%x\n", trailer, s.Payload())
+	}
+	if s.NextLayerType() != gopacket.LayerTypePayload {
+		t.Errorf("mismatch on next layer type, got %s", s.NextLayerType())
+	}
+
+	s = &ESSH{}
+	if err := s.DecodeFromBytes(testSShPacket["Identification String and Kexinit"].data, gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Payload()) != 0 || s.NextLayerType() != gopacket.LayerTypeZero {
+		t.Errorf("expected no payload, got %d bytes", len(s.Payload()))
+	}
+}
+
+// TestMultipleRecords decodes all the binary packets sent in one segment,
+// skipping the transport layer messages not decoded, up to SSH_MSG_NEWKEYS.
+func TestMultipleRecords(t *testing.T) {
+	data := append([]byte{}, testSShPacket["Identification String and Kexinit"].data...)
+	// SSH_MSG_IGNORE, KEXDH_REPLY and NEWKEYS
+	data = append(data, decodeString(`0000000c0a0200000000000000000000`+`00000010061f0000000473736821000000000000`+`0000000c0a1500000000000000000000`)...)
+	decoded := len(data)
+	// Encrypted, even if it looks like a KEXINIT
+	trailer := decodeString(`0000000c0a1400000000000000000000`)
+	data = append(data, trailer...)
+
+	s := &ESSH{}
+	if err := s.DecodeFromBytes(data, gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	var codes []ESSHType
+	for _, h := range s.Records {
+		codes = append(codes, h.MessageCode)
+	}
+	if expected := []ESSHType{ESSH_MSG_KEXINIT, 2, ESSH_MSG_DHKEXREPLY, ESSH_MSG_NEW_KEYS}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("mismatch on records\n\nexpected:\n%v\ngot: \n%v\n", expected, codes)
+	}
+	if s.Banner == nil || s.Kexinit == nil || s.KexDHReply == nil || !s.NewKeys {
+		t.Errorf("records not kept, banner %v, KEXINIT %v, KEXDH_REPLY %v, NEWKEYS %v", s.Banner != nil, s.Kexinit != nil, s.KexDHReply != nil, s.NewKeys)
+	}
+	if len(s.LayerContents()) != decoded || !bytes.Equal(s.Payload(), trailer) {
+		t.Errorf("mismatch on payload\n\nexpected:\n%x\ngot: \n%x\n", trailer, s.Payload())
+	}
+}
+
+func TestTruncatedAfterRecord(t *testing.T) {
+	packet := testSShPacket["Identification String and Kexinit"].data
+	data := append(append([]byte{}, packet...), decodeString(`0000002c061e`)...)
+
+	s := &ESSH{}
+	err := s.DecodeFromBytes(data, gopacket.NilDecodeFeedback)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("expected %v, got %v", ErrTruncated, err)
+	}
+	if s.Banner == nil || s.Kexinit == nil {
+		t.Errorf("records before the truncated one not kept")
+	}
+	if len(s.LayerContents()) != len(packet) {
+		t.Errorf("mismatch on decoded length, expected %d, got %d", len(packet), len(s.LayerContents()))
+	}
+}
+
+// TestBannerAndKexinit decodes the version string followed by a KEXINIT in
+// the same data: the KEXINIT must be decoded from right after the terminator,
+// whether it is CR LF or LF.
+func TestBannerAndKexinit(t *testing.T) {
+	kexinit := testKexinit["OpenSSH_7.4 Client Key Exchange Init"]
+	for k, banner := range map[string]string{
+		"CR LF":              "SSH-2.0-OpenSSH_7.4\r\n",
+		"LF":                 "SSH-2.0-OpenSSH_7.4\n",
+		"comments, CR LF":    "SSH-2.0-OpenSSH_7.4 Debian-10\r\n",
+		"comments, LF":       "SSH-2.0-OpenSSH_7.4 Debian-10\n",
+		"preamble and CR LF": "\xff\xfb\x01\r\nSSH-2.0-OpenSSH_7.4\r\n",
+		"pre-banner lines":   "Welcome\r\n\r\nSSH-2.0-OpenSSH_7.4\r\n",
+	} {
+		t.Run(k, func(t *testing.T) {
+			data := append([]byte(banner), kexinit.data...)
+			s := &ESSH{}
+			n, err := s.decodeESSHRecords(data, gopacket.NilDecodeFeedback)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(data) {
+				t.Errorf("mismatch on decoded length\n\nexpected:\n%d\ngot: \n%d\n", len(data), n)
+			}
+			if s.Banner == nil || s.Banner.SoftwareVersion != "OpenSSH_7.4" {
+				t.Fatalf("mismatch on banner, got %+v", s.Banner)
+			}
+			if !reflect.DeepEqual(s.Kexinit, kexinit.record) {
+				t.Errorf("mismatch on KEXINIT\n\nexpected:\n%+v\ngot: \n%+v\n", kexinit.record, s.Kexinit)
+			}
+		})
+	}
+}
+
+var testDecodeErrors = map[string]struct {
+	data            []byte
+	bannersComplete bool
+	err             error
+}{
+	"Truncated banner": {
+		data: []byte("SSH-2.0-Open"),
+		err:  ErrTruncated,
+	},
+	"Not SSH": {
+		data: []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n" + strings.Repeat("x", 255)),
+		err:  ErrNotSSH,
+	},
+	"Truncated record": {
+		data:            decodeString(`000005d40b14`),
+		bannersComplete: true,
+		err:             ErrTruncated,
+	},
+	"Wrong message code": {
+		data:            decodeString(`0000000c0a5e00000000000000000000`), // SSH_MSG_CHANNEL_DATA
+		bannersComplete: true,
+		err:             ErrWrongMessageCode,
+	},
+	"Malformed KEXINIT": {
+		data:            decodeString(`0000001c0a14000102030405060708090a0b0c0d0e0fffffffff00000000000000000000`),
+		bannersComplete: true,
+		err:             ErrMalformedKexinit,
+	},
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for k, test := range testDecodeErrors {
+		t.Run(k, func(t *testing.T) {
+			t.Log(k)
+			s := NewESSH(test.bannersComplete)
+			_, err := s.decodeESSHRecords(test.data, gopacket.NilDecodeFeedback)
+			if !errors.Is(err, test.err) {
+				t.Errorf("failed testcase '%s', mismatch on error\n\nexpected:\n%v\ngot: \n%v\n", k, test.err, err)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/google/gopacket"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -62,3 +63,26 @@ func TestFingerprint(t *testing.T) {
 	fmt.Printf("%s\n", fsha256)
 
 }
+
+func TestHostKeyFingerprint(t *testing.T) {
+	var r ESSHKexDHReplyRecord
+	data := testKexDH["ECDSA Key Exchange Reply"].data
+	if err := r.decodeFromBytes(data[6:], ESSH_MSG_DHKEXREPLY, data[4], gopacket.NilDecodeFeedback); err != nil {
+		t.Fatal(err)
+	}
+	if r.HostKey == nil {
+		t.Fatal("host key not decoded")
+	}
+
+	// As computed by golang.org/x/crypto/ssh, see TestFingerprint
+	pk, err := ssh.ParsePublicKey(r.HostKey.Blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp, expected := r.HostKey.FingerprintSHA256(), ssh.FingerprintSHA256(pk); fp != expected || fp != "SHA256:kulaMadq1/kWxXztZbd9ekXrsXrOltknnBxZftcxQM0" {
+		t.Errorf("mismatch on SHA256 fingerprint\n\nexpected:\n%s\ngot: \n%s\n", expected, fp)
+	}
+	if fp, expected := r.HostKey.FingerprintMD5(), ssh.FingerprintLegacyMD5(pk); fp != expected || fp != "ac:37:1a:95:b0:32:32:76:19:54:1b:ea:87:7c:78:61" {
+		t.Errorf("mismatch on MD5 fingerprint\n\nexpected:\n%s\ngot: \n%s\n", expected, fp)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// maxAggregateExamples is the number of distinct addresses kept per digest
+const maxAggregateExamples = 5
+
+// aggregateEntry counts the sessions sharing a digest, with -aggregate
+type aggregateEntry struct {
+	Digest     string   `json:"digest"`
+	Algorithms string   `json:"algorithms"`
+	Count      int      `json:"count"`
+	Examples   []string `json:"example_ips"` // of the clients for hassh, of the servers for hasshServer
+}
+
+// aggregate holds the distinct HASSH and HASSHServer values seen, instead of
+// writing every session
+type aggregate struct {
+	hassh       map[string]*aggregateEntry
+	hasshServer map[string]*aggregateEntry
+}
+
+// aggregated is the aggregate of the run, guarded by outputMutex
+var aggregated *aggregate
+
+func newAggregate() *aggregate {
+	return &aggregate{
+		hassh:       make(map[string]*aggregateEntry),
+		hasshServer: make(map[string]*aggregateEntry),
+	}
+}
+
+// add counts the digests of the session.
+func (a *aggregate) add(t SSHSession) {
+	if t.Client.HASSH != nil {
+		addAggregate(a.hassh, t.Client.Hassh, t.Client.HasshAlgorithms, t.ClientIP)
+	}
+	if t.Server.HASSHServer != nil {
+		addAggregate(a.hasshServer, t.Server.HasshServer, t.Server.HasshServerAlgorithms, t.ServerIP)
+	}
+}
+
+func addAggregate(m map[string]*aggregateEntry, digest, algorithms, ip string) {
+	e, ok := m[digest]
+	if !ok {
+		e = &aggregateEntry{Digest: digest, Algorithms: algorithms}
+		m[digest] = e
+	}
+	e.Count++
+	if len(e.Examples) >= maxAggregateExamples {
+		return
+	}
+	for _, example := range e.Examples {
+		if example == ip {
+			return
+		}
+	}
+	e.Examples = append(e.Examples, ip)
+}
+
+// sortedAggregate returns the entries, the most seen first.
+func sortedAggregate(m map[string]*aggregateEntry) []*aggregateEntry {
+	entries := make([]*aggregateEntry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Digest < entries[j].Digest
+	})
+	return entries
+}
+
+// write writes the aggregate as a JSON object.
+func (a *aggregate) write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	if opts.JSONIndent {
+		enc.SetIndent("", "    ")
+	}
+	return enc.Encode(struct {
+		HASSH       []*aggregateEntry `json:"hassh"`
+		HASSHServer []*aggregateEntry `json:"hasshServer"`
+	}{sortedAggregate(a.hassh), sortedAggregate(a.hasshServer)})
+}
+
+// writeAggregate writes the aggregate at the end of the run, into
+// aggregate.json in the -j folder or to stdout.
+func writeAggregate() error {
+	if opts.OutputDir == "" {
+		return aggregated.write(os.Stdout)
+	}
+	f, err := os.Create(filepath.Join(opts.OutputDir, "aggregate.json"))
+	if err != nil {
+		return err
+	}
+	if err := aggregated.write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
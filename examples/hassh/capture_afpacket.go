@@ -0,0 +1,71 @@
+//go:build linux && afpacket
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/gopacket/afpacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"golang.org/x/net/bpf"
+)
+
+// afpacketHandle is an AF_PACKET ring, which only captures Ethernet
+type afpacketHandle struct {
+	*afpacket.TPacket
+}
+
+func (h afpacketHandle) LinkType() layers.LinkType {
+	return layers.LinkTypeEthernet
+}
+
+// openAFPacket opens a live capture on the interface with an AF_PACKET ring
+// of -afpacket-frames frames in blocks of -afpacket-block-size bytes, with
+// the BPF filter applied.
+func openAFPacket(iface string) (liveHandle, error) {
+	// Frames hold -snaplen bytes, rounded up to whole pages
+	page := os.Getpagesize()
+	frameSize := (opts.Snaplen + page - 1) / page * page
+	if opts.AFPacketBlockSize%frameSize != 0 {
+		return nil, fmt.Errorf("-afpacket-block-size %d is not a multiple of the frame size %d", opts.AFPacketBlockSize, frameSize)
+	}
+	framesPerBlock := opts.AFPacketBlockSize / frameSize
+	numBlocks := (opts.AFPacketFrames + framesPerBlock - 1) / framesPerBlock
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = afpacket.DefaultPollTimeout
+	}
+	tp, err := afpacket.NewTPacket(
+		afpacket.OptInterface(iface),
+		afpacket.OptFrameSize(frameSize),
+		afpacket.OptBlockSize(opts.AFPacketBlockSize),
+		afpacket.OptNumBlocks(numBlocks),
+		afpacket.OptPollTimeout(timeout),
+		afpacket.SocketRaw,
+		afpacket.TPacketVersion3,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.BPFFilter != "" {
+		Info("Using BPF filter %q\n", opts.BPFFilter)
+		insns, err := pcap.CompileBPFFilter(layers.LinkTypeEthernet, opts.Snaplen, opts.BPFFilter)
+		if err != nil {
+			tp.Close()
+			return nil, err
+		}
+		raw := make([]bpf.RawInstruction, len(insns))
+		for i, ins := range insns {
+			raw[i] = bpf.RawInstruction{Op: ins.Code, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+		}
+		if err := tp.SetBPF(raw); err != nil {
+			tp.Close()
+			return nil, err
+		}
+	}
+	return afpacketHandle{tp}, nil
+}
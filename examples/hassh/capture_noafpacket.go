@@ -0,0 +1,12 @@
+//go:build !(linux && afpacket)
+
+package main
+
+import (
+	"errors"
+)
+
+// openAFPacket is only available on Linux, when built with the afpacket tag.
+func openAFPacket(iface string) (liveHandle, error) {
+	return nil, errors.New("AF_PACKET capture is not built in, rebuild on Linux with -tags afpacket")
+}
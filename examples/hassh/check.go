@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/gopacket/pcap"
+)
+
+// check validates, without capturing, that the inputs can be opened with the
+// BPF filter and that the output folder is writable. It prints a line for
+// every check and returns the exit code.
+func check() int {
+	failed := 0
+	report := func(err error, format string, a ...interface{}) {
+		msg := fmt.Sprintf(format, a...)
+		if err != nil {
+			fmt.Printf("FAIL: %s: %s\n", msg, err)
+			failed++
+			return
+		}
+		fmt.Printf("OK: %s\n", msg)
+	}
+
+	// Inputs, with the filter compiled against each of them
+	open := func(name string, handle *pcap.Handle, err error) {
+		report(err, "open %s", name)
+		if err != nil {
+			return
+		}
+		defer handle.Close()
+		if opts.BPFFilter != "" {
+			report(handle.SetBPFFilter(opts.BPFFilter), "BPF filter %q on %s", opts.BPFFilter, name)
+		}
+	}
+	if len(opts.Files) == 0 {
+		for _, iface := range strings.Split(opts.Interface, ",") {
+			handle, err := openLive(iface)
+			open(iface, handle, err)
+		}
+	} else {
+		for _, fn := range opts.Files {
+			handle, err := pcap.OpenOffline(fn)
+			open(fn, handle, err)
+		}
+	}
+
+	// Output folder
+	if opts.OutputDir != "" {
+		report(checkWritable(opts.OutputDir), "write to %s", opts.OutputDir)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d checks failed\n", failed)
+		return 1
+	}
+	return 0
+}
+
+// checkWritable verifies that dir is a folder files can be created in.
+func checkWritable(dir string) error {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("not a folder")
+	}
+	f, err := os.CreateTemp(dir, ".hassh-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
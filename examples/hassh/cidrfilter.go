@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+// cidrList collects the networks given by repeated -client-cidr or
+// -server-cidr flags.
+type cidrList []*net.IPNet
+
+func (l *cidrList) String() string {
+	if l == nil {
+		return ""
+	}
+	var s []string
+	for _, n := range *l {
+		s = append(s, n.String())
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *cidrList) Set(cidr string) error {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, n)
+	return nil
+}
+
+// contains reports if the address is in any of the networks.
+func (l cidrList) contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range l {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterCIDR reports if the client and server of the session are in the
+// networks of -client-cidr and -server-cidr, and counts the sessions left
+// out. A side without networks is not filtered.
+func filterCIDR(t SSHSession) bool {
+	if len(opts.ClientCIDR) > 0 && !cidrList(opts.ClientCIDR).contains(t.ClientIP) ||
+		len(opts.ServerCIDR) > 0 && !cidrList(opts.ServerCIDR).contains(t.ServerIP) {
+		stats.cidrFiltered++
+		return false
+	}
+	return true
+}
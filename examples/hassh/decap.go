@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/kjelle/gohassh/session"
+)
+
+// Tunnel is the encapsulation a session was captured in, with -decap
+type Tunnel = session.Tunnel
+
+// decapsulate returns the packet carried by the tunnel selected with -decap,
+// decoded from its inner link or network layer, and the tunnel it was in.
+// Packets outside the tunnel are returned as they are, with a nil tunnel.
+// The outer packet is not defragmented, an encapsulated packet fragmented
+// on the way is lost.
+func decapsulate(packet gopacket.Packet) (gopacket.Packet, *Tunnel) {
+	var (
+		tunnel Tunnel
+		tl     gopacket.Layer
+		next   gopacket.LayerType
+	)
+	switch opts.Decap {
+	case "vxlan":
+		vxlan, ok := packet.Layer(layers.LayerTypeVXLAN).(*layers.VXLAN)
+		if !ok {
+			return packet, nil
+		}
+		tunnel.ID = vxlan.VNI
+		tl, next = vxlan, layers.LayerTypeEthernet
+	case "gre":
+		gre, ok := packet.Layer(layers.LayerTypeGRE).(*layers.GRE)
+		if !ok {
+			return packet, nil
+		}
+		if gre.KeyPresent {
+			tunnel.ID = gre.Key
+		}
+		tl, next = gre, gre.NextLayerType()
+	default:
+		return packet, nil
+	}
+	tunnel.Type = opts.Decap
+	if nl := packet.NetworkLayer(); nl != nil {
+		src, dst := nl.NetworkFlow().Endpoints()
+		tunnel.SrcIP, tunnel.DestIP = src.String(), dst.String()
+	}
+
+	inner := gopacket.NewPacket(tl.LayerPayload(), next, gopacket.DecodeOptions{NoCopy: true})
+	inner.Metadata().CaptureInfo = packet.Metadata().CaptureInfo
+	return inner, &tunnel
+}
@@ -0,0 +1,24 @@
+package main
+
+// Enricher annotates a session before it is written, with data the capture
+// does not hold, e.g. from an asset database keyed by the client address.
+// Enrichers are called by every output worker, so they must be safe for
+// concurrent use.
+type Enricher func(*SSHSession)
+
+// enrichers run in the order they were registered
+var enrichers []Enricher
+
+// RegisterEnricher adds an enricher run on every session before it is
+// written. Enrichers are meant to be registered from the init function of a
+// file added to this package, before any session is processed.
+func RegisterEnricher(e Enricher) {
+	enrichers = append(enrichers, e)
+}
+
+// enrich runs the registered enrichers on the session.
+func enrich(s *SSHSession) {
+	for _, e := range enrichers {
+		e(s)
+	}
+}
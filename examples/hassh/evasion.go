@@ -0,0 +1,27 @@
+package main
+
+// evasionPorts are well-known ports of other protocols, commonly allowed
+// through firewalls, which SSH is run on to get past them
+var evasionPorts = map[string]bool{
+	"20":   true, // FTP data
+	"21":   true, // FTP
+	"25":   true, // SMTP
+	"53":   true, // DNS
+	"80":   true, // HTTP
+	"110":  true, // POP3
+	"123":  true, // NTP
+	"143":  true, // IMAP
+	"443":  true, // HTTPS
+	"993":  true, // IMAPS
+	"995":  true, // POP3S
+	"3128": true, // HTTP proxy
+	"3389": true, // RDP
+	"8080": true, // HTTP alternate
+	"8443": true, // HTTPS alternate
+}
+
+// isEvasionPort reports, with -detect-evasion, if the session runs on the
+// port of another protocol.
+func isEvasionPort(t SSHSession) bool {
+	return opts.DetectEvasion && evasionPorts[t.ServerPort]
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// filenameTimeFormat is RFC 3339 without the colons, which are not allowed
+// in filenames on all filesystems
+const filenameTimeFormat = "20060102T150405.000000000Z0700"
+
+var filenameTemplate *template.Template
+
+// filenameData holds the fields available to -filename-template
+type filenameData struct {
+	Timestamp   string
+	ClientIP    string
+	ClientPort  string
+	ServerIP    string
+	ServerPort  string
+	HASSH       string
+	HASSHServer string
+}
+
+// parseFilenameTemplate parses the -filename-template option.
+func parseFilenameTemplate() error {
+	t, err := template.New("filename").Option("missingkey=error").Parse(opts.FilenameTemplate)
+	if err != nil {
+		return err
+	}
+	filenameTemplate = t
+	return nil
+}
+
+// unsafeFilenameChars are replaced in the filenames, as they are not
+// allowed on all filesystems (IPv6 addresses contain colons).
+var unsafeFilenameChars = strings.NewReplacer(
+	"<", "-", ">", "-", ":", "-", "\"", "-", "/", "-", "\\", "-", "|", "-", "?", "-", "*", "-",
+)
+
+// sessionFilename returns the name of the file for the session, from
+// -filename-template, without the extension.
+func sessionFilename(t SSHSession) (string, error) {
+	d := filenameData{
+		Timestamp:  t.Timestamp.UTC().Format(filenameTimeFormat),
+		ClientIP:   t.ClientIP,
+		ClientPort: t.ClientPort,
+		ServerIP:   t.ServerIP,
+		ServerPort: t.ServerPort,
+	}
+	if t.Client.HASSH != nil {
+		d.HASSH = t.Client.Hassh
+	}
+	if t.Server.HASSHServer != nil {
+		d.HASSHServer = t.Server.HasshServer
+	}
+	var b bytes.Buffer
+	if err := filenameTemplate.Execute(&b, d); err != nil {
+		return "", err
+	}
+	return unsafeFilenameChars.Replace(b.String()), nil
+}
+
+// createUnique creates name.ext in dir, appending a counter to name if the
+// file already exists.
+func createUnique(dir string, name string, ext string) (*os.File, error) {
+	fn := filepath.Join(dir, name+ext)
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(fn, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if !errors.Is(err, os.ErrExist) {
+			return f, err
+		}
+		fn = filepath.Join(dir, fmt.Sprintf("%s-%d%s", name, i, ext))
+	}
+}
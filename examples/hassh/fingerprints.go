@@ -0,0 +1,107 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/kjelle/gohassh/essh"
+)
+
+// fingerprintsJSON is the built-in database of the software known to send a
+// HASSH or a HASSHServer. The digests are those of the default configuration
+// of:
+//
+//	OpenSSH_7.4 client and server, the bundled sample
+//	OpenSSH_9.2p1 client
+//	golang.org/x/crypto/ssh v0.31.0 client and server
+//	the OpenSSH, paramiko and dropbear clients of
+//	testdata/hassh/salesforce_hassh_fingerprints.json
+//
+// Other software, such as PuTTY, libssh or masscan, which sends no KEXINIT,
+// is only known by its banner, see essh.ParseSoftware.
+//
+//go:embed fingerprints.json
+var fingerprintsJSON []byte
+
+// fingerprintDB maps digests to the software, named as the Product of
+// essh.SoftwareInfo.
+type fingerprintDB struct {
+	HASSH       map[string]string `json:"hassh"`
+	HASSHServer map[string]string `json:"hasshServer"`
+}
+
+var fingerprints = readFingerprints(fingerprintsJSON)
+
+func readFingerprints(b []byte) *fingerprintDB {
+	db := &fingerprintDB{}
+	if err := json.Unmarshal(b, db); err != nil {
+		panic("invalid fingerprints.json: " + err.Error())
+	}
+	return db
+}
+
+// digests returns the software of both the HASSH and HASSHServer digests.
+func (db *fingerprintDB) digests() map[string]string {
+	m := make(map[string]string)
+	for digest, name := range db.HASSH {
+		m[digest] = name
+	}
+	for digest, name := range db.HASSHServer {
+		m[digest] = name
+	}
+	return m
+}
+
+// claimedImplementation returns the implementation the banner of the record
+// names, empty if it names none that essh.ParseSoftware recognizes.
+func claimedImplementation(r *SSHRecord) string {
+	if r.SoftwareInfo == nil || !essh.KnownProduct(r.SoftwareInfo.Product) {
+		return ""
+	}
+	return r.SoftwareInfo.Product
+}
+
+// recordImplementation returns the implementation known to send the digest of
+// the record, as given by the database and -impl-map, and if its banner
+// claims another known one. The digest is trusted over the banner, which is
+// easily spoofed.
+func recordImplementation(r *SSHRecord, digest string) (impl string, known bool, mismatch bool) {
+	impl, known = implementations[digest]
+	if !known {
+		return "", false, false
+	}
+	banner := claimedImplementation(r)
+	return impl, true, banner != "" && banner != impl
+}
+
+// sessionDigests returns the HASSH and HASSHServer of the session, empty if
+// not computed.
+func sessionDigests(s *SSHSession) (hassh string, hasshServer string) {
+	if s.Client.HASSH != nil {
+		hassh = s.Client.Hassh
+	}
+	if s.Server.HASSHServer != nil {
+		hasshServer = s.Server.HasshServer
+	}
+	return hassh, hasshServer
+}
+
+// identify sets the software of the client and server: the implementation
+// known to send their digest, else that of their banner. A banner claiming
+// another known implementation than the digest flags the session, see
+// recordImplementation.
+func identify(s *SSHSession) {
+	identifyRecord := func(r *SSHRecord, digest string) string {
+		impl, known, mismatch := recordImplementation(r, digest)
+		if !known {
+			return claimedImplementation(r)
+		}
+		if mismatch {
+			s.PossibleSpoofedBanner = true
+		}
+		return impl
+	}
+	hassh, hasshServer := sessionDigests(s)
+	s.IdentifiedClient = identifyRecord(&s.Client, hassh)
+	s.IdentifiedServer = identifyRecord(&s.Server, hasshServer)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// flushLog receives a JSON record for every assembler flush, when -flush-log
+// is given
+var flushLog io.Writer
+
+// flushRecord describes one run of the assembler flush
+type flushRecord struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`      // periodic, idle, carryover or final
+	Reference time.Time `json:"reference"` // capture time the timeouts are relative to
+	Flushed   int       `json:"flushed"`
+	Closed    int       `json:"closed"`
+}
+
+// openFlushLog opens the -flush-log destination, "-" meaning stderr.
+func openFlushLog() {
+	switch opts.FlushLog {
+	case "":
+		return
+	case "-":
+		flushLog = os.Stderr
+	default:
+		f, err := os.OpenFile(opts.FlushLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatal("Unable to open flush log:", err)
+		}
+		flushLog = f
+	}
+}
+
+// logFlush records a flush of the assembler in the flush log and the
+// metrics.
+func logFlush(kind string, ref time.Time, flushed, closed int) {
+	metrics.flushedStreams.Add(float64(flushed))
+	metrics.closedStreams.Add(float64(closed))
+	Debug("%s flush: %d flushed, %d closed (%s)\n", kind, flushed, closed, ref)
+	if flushLog == nil {
+		return
+	}
+	b, err := json.Marshal(flushRecord{
+		Time:      time.Now(),
+		Kind:      kind,
+		Reference: ref,
+		Flushed:   flushed,
+		Closed:    closed,
+	})
+	if err != nil {
+		Error("FlushLog", "Unable to marshal flush record: %s\n", err)
+		return
+	}
+	if _, err := flushLog.Write(append(b, '\n')); err != nil {
+		Error("FlushLog", "Unable to write flush record: %s\n", err)
+	}
+}
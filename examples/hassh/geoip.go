@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"sync/atomic"
+
+	"github.com/kjelle/gohassh/session"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Geo locates an address, from the MaxMind databases of -geoip
+type Geo = session.Geo
+
+// geoRecord holds the fields of the GeoLite2 Country, City and ASN
+// databases we use, each database fills its own
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	ASN uint   `maxminddb:"autonomous_system_number"`
+	Org string `maxminddb:"autonomous_system_organization"`
+}
+
+// geoIP looks up addresses in the databases of -geoip. The databases are
+// opened once and shared by the output workers.
+type geoIP struct {
+	readers []*maxminddb.Reader
+	lookups atomic.Int64
+	misses  atomic.Int64 // addresses found in none of the databases
+	errors  atomic.Int64 // lookups failing on a corrupt database
+}
+
+// geo is the enricher of -geoip, nil unless its databases were opened
+var geo *geoIP
+
+// openGeoIP opens the comma-separated MaxMind databases, as GeoLite2 keeps
+// the countries and the autonomous systems in separate files.
+func openGeoIP(files string) (*geoIP, error) {
+	g := &geoIP{}
+	for _, fn := range strings.Split(files, ",") {
+		r, err := maxminddb.Open(fn)
+		if err != nil {
+			g.close()
+			return nil, err
+		}
+		g.readers = append(g.readers, r)
+	}
+	return g, nil
+}
+
+// lookup returns what the databases know of the address, nil if none holds
+// it.
+func (g *geoIP) lookup(addr string) *Geo {
+	g.lookups.Add(1)
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		g.misses.Add(1)
+		return nil
+	}
+	var (
+		r     geoRecord
+		found bool
+	)
+	for _, db := range g.readers {
+		_, ok, err := db.LookupNetwork(ip, &r)
+		if err != nil {
+			// Logged once, a corrupt database fails every lookup
+			if g.errors.Add(1) == 1 {
+				Error("GeoIP", "Unable to look up %s, further errors are only counted: %s\n", addr, err)
+			}
+			continue
+		}
+		if ok {
+			found = true
+		}
+	}
+	if !found {
+		g.misses.Add(1)
+		return nil
+	}
+	return &Geo{Country: r.Country.ISOCode, ASN: r.ASN, Org: r.Org}
+}
+
+// enrich sets the locations of the client and server.
+func (g *geoIP) enrich(s *SSHSession) {
+	s.ClientGeo = g.lookup(s.ClientIP)
+	s.ServerGeo = g.lookup(s.ServerIP)
+}
+
+func (g *geoIP) close() {
+	for _, r := range g.readers {
+		r.Close()
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// hasshList is a set of HASSH digests read from a file
+type hasshList map[string]bool
+
+// hasshAllow holds the known-good digests of -hassh-allow, sessions matching
+// it are not written
+var hasshAllow hasshList
+
+// hasshDeny holds the watched digests of -hassh-deny, only sessions matching
+// it are written
+var hasshDeny hasshList
+
+// readHASSHList reads a file of newline-separated digests. Empty lines and
+// lines starting with # are ignored.
+func readHASSHList(fn string) (hasshList, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l := make(hasshList)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		l[strings.ToLower(line)] = true
+	}
+	return l, scanner.Err()
+}
+
+// readHASSHLists reads the -hassh-allow and -hassh-deny files.
+func readHASSHLists() error {
+	switch opts.HASSHMatch {
+	case "client", "server", "both":
+	default:
+		return fmt.Errorf("invalid -hassh-match %q, expected client, server or both", opts.HASSHMatch)
+	}
+	var err error
+	if opts.HASSHAllow != "" {
+		if hasshAllow, err = readHASSHList(opts.HASSHAllow); err != nil {
+			return err
+		}
+	}
+	if opts.HASSHDeny != "" {
+		if hasshDeny, err = readHASSHList(opts.HASSHDeny); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match reports if the HASSH or HASSHServer of the session, as selected by
+// -hassh-match, is in the list.
+func (l hasshList) match(t SSHSession) bool {
+	if opts.HASSHMatch != "server" && t.Client.HASSH != nil && l[t.Client.Hassh] {
+		return true
+	}
+	if opts.HASSHMatch != "client" && t.Server.HASSHServer != nil && l[t.Server.HasshServer] {
+		return true
+	}
+	return false
+}
+
+// filterHASSH reports if the session passes the allow and deny lists, and
+// counts the matches.
+func filterHASSH(t SSHSession) bool {
+	if hasshAllow != nil && hasshAllow.match(t) {
+		stats.allowMatched++
+		return false
+	}
+	if hasshDeny != nil {
+		if !hasshDeny.match(t) {
+			return false
+		}
+		stats.denyMatched++
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// health is what /healthz reports on, updated by the packet reader
+var health struct {
+	lastPacket atomic.Int64 // wall clock of the last packet read, in Unix nanoseconds
+	stopping   atomic.Bool  // the sessions left are being written on the way out
+}
+
+// healthz answers liveness probes: 200 while capturing, 503 once shutting
+// down or when no packet was read within -healthz-liveness.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	if health.stopping.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	if opts.HealthzLiveness > 0 {
+		if idle := time.Since(time.Unix(0, health.lastPacket.Load())); idle > opts.HealthzLiveness {
+			http.Error(w, fmt.Sprintf("no packet read for %s", idle.Round(time.Second)), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// implementations maps HASSH and HASSHServer digests to the implementation
+// known to send them, named as the Product of essh.SoftwareInfo. They are
+// those of the built-in fingerprints, more can be given, or these
+// overridden, with -impl-map.
+var implementations = fingerprints.digests()
+
+// readImplementations merges the JSON object of digests to implementations
+// in the file over the built-in ones.
+func readImplementations(fn string) error {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+	m := make(map[string]string)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	for digest, impl := range m {
+		implementations[digest] = impl
+	}
+	return nil
+}
+
+// checkImplementation sets, with -impl-check, the implementations inferred
+// from the digests of the session, and flags a mismatch with the software
+// the banners claim, the same one as PossibleSpoofedBanner, see
+// recordImplementation.
+func checkImplementation(s *SSHSession) {
+	if !opts.ImplCheck {
+		return
+	}
+	check := func(r *SSHRecord, digest string) {
+		impl, known, mismatch := recordImplementation(r, digest)
+		if !known {
+			return
+		}
+		r.InferredImplementation = impl
+		if mismatch {
+			s.ImplementationMismatch = true
+		}
+	}
+	hassh, hasshServer := sessionDigests(s)
+	check(&s.Client, hassh)
+	check(&s.Server, hasshServer)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/gopacket/ip4defrag"
+	"github.com/google/gopacket/layers"
+)
+
+// Limits of the IPv6 reassembly, see RFC 8200, section 4.5
+const (
+	ip6MaximumSize            = 65535            // largest payload of a reassembled packet, jumbograms are not fragmented
+	ip6MaximumFragmentListLen = 8192             // back out if we get more than this many fragments
+	ip6ReassemblyTimeout      = 60 * time.Second // fragments are discarded if the packet is not complete by then
+)
+
+var (
+	errIP6FragmentOverlap = errors.New("overlapping IPv6 fragments")
+	errIP6FragmentSize    = errors.New("IPv6 fragments exceed the maximum packet size")
+)
+
+// defragmenter reassembles the fragmented IPv4 and IPv6 packets.
+type defragmenter struct {
+	ip4 *ip4defrag.IPv4Defragmenter
+	ip6 *ip6Defragmenter
+}
+
+func newDefragmenter() *defragmenter {
+	return &defragmenter{
+		ip4: ip4defrag.NewIPv4Defragmenter(),
+		ip6: newIP6Defragmenter(),
+	}
+}
+
+// discardOlderThan drops the packets still missing fragments whose last
+// fragment arrived before t, returning how many.
+func (d *defragmenter) discardOlderThan(t time.Time) int {
+	return d.ip4.DiscardOlderThan(t) + d.ip6.discardOlderThan(t)
+}
+
+// ip6Defragmenter is the IPv6 counterpart of ip4defrag.IPv4Defragmenter. It
+// collects the fragments of a packet by source, destination and
+// identification, as given in the fragment header, and returns the
+// fragmentable part once all of it arrived.
+type ip6Defragmenter struct {
+	lists map[ip6FragmentKey]*ip6FragmentList
+}
+
+type ip6FragmentKey struct {
+	src, dst [16]byte
+	id       uint32
+}
+
+type ip6FragmentList struct {
+	fragments  []ip6Fragment
+	size       int // of the payload, -1 until the last fragment arrived
+	first      time.Time
+	last       time.Time
+	nextHeader layers.IPProtocol
+}
+
+type ip6Fragment struct {
+	offset int
+	data   []byte
+}
+
+func newIP6Defragmenter() *ip6Defragmenter {
+	return &ip6Defragmenter{lists: make(map[ip6FragmentKey]*ip6FragmentList)}
+}
+
+// defragIPv6 adds the fragment of the packet captured at ts. Once the packet
+// is complete it returns its payload, starting with the header given as the
+// next header of the first fragment, and the protocol of that header. It
+// returns a nil payload as long as fragments are missing, and an error when
+// the packet cannot be reassembled, after which its fragments are dropped.
+func (d *ip6Defragmenter) defragIPv6(ip6 *layers.IPv6, frag *layers.IPv6Fragment, ts time.Time) ([]byte, layers.IPProtocol, error) {
+	offset := int(frag.FragmentOffset) * 8
+	if offset == 0 && !frag.MoreFragments {
+		// an atomic fragment, RFC 6946
+		return frag.Payload, frag.NextHeader, nil
+	}
+
+	key := ip6FragmentKey{id: frag.Identification}
+	copy(key.src[:], ip6.SrcIP.To16())
+	copy(key.dst[:], ip6.DstIP.To16())
+	fl, ok := d.lists[key]
+	if ok && ts.Sub(fl.first) > ip6ReassemblyTimeout {
+		delete(d.lists, key)
+		ok = false
+	}
+	if !ok {
+		fl = &ip6FragmentList{size: -1, first: ts}
+		d.lists[key] = fl
+	}
+	fl.last = ts
+
+	end := offset + len(frag.Payload)
+	switch {
+	case end > ip6MaximumSize:
+		delete(d.lists, key)
+		return nil, 0, errIP6FragmentSize
+	case len(fl.fragments)+1 > ip6MaximumFragmentListLen:
+		delete(d.lists, key)
+		return nil, 0, fmt.Errorf("more than %d IPv6 fragments", ip6MaximumFragmentListLen)
+	}
+	if offset == 0 {
+		fl.nextHeader = frag.NextHeader
+	}
+	if !frag.MoreFragments {
+		fl.size = end
+	}
+	// Fragments are copied, the packet they came in is not kept
+	fl.fragments = append(fl.fragments, ip6Fragment{offset: offset, data: append([]byte(nil), frag.Payload...)})
+
+	if fl.size < 0 {
+		return nil, 0, nil
+	}
+	sort.Slice(fl.fragments, func(i, j int) bool { return fl.fragments[i].offset < fl.fragments[j].offset })
+	next := 0
+	for _, f := range fl.fragments {
+		switch {
+		case f.offset > next:
+			return nil, 0, nil // a gap, wait for the fragment filling it
+		case f.offset < next:
+			// Overlaps are not allowed, the whole packet is dropped,
+			// RFC 5722
+			delete(d.lists, key)
+			return nil, 0, errIP6FragmentOverlap
+		}
+		next += len(f.data)
+	}
+	if next != fl.size {
+		delete(d.lists, key)
+		return nil, 0, errIP6FragmentSize
+	}
+
+	delete(d.lists, key)
+	payload := make([]byte, 0, fl.size)
+	for _, f := range fl.fragments {
+		payload = append(payload, f.data...)
+	}
+	return payload, fl.nextHeader, nil
+}
+
+// discardOlderThan drops the packets whose last fragment arrived before t,
+// returning how many.
+func (d *ip6Defragmenter) discardOlderThan(t time.Time) int {
+	n := 0
+	for k, fl := range d.lists {
+		if fl.last.Before(t) {
+			delete(d.lists, k)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/reassembly"
+)
+
+// ja4ssh computes a JA4SSH fingerprint over the TCP packets of a window, as
+// published by FoxIO:
+//
+//	c{client mode}s{server mode}_c{client packets}s{server packets}_c{client acks}s{server acks}
+//
+// where the mode is the most common TCP payload length among the packets
+// carrying data, packets are those carrying data and acks are bare ACKs
+// without data. The window starts once the key exchange is complete, so it
+// covers the encrypted session only; it says nothing of the KEXINIT and is
+// independent of HASSH.
+type ja4ssh struct {
+	lengths [2]map[int]int // payload length to count, by direction
+	packets [2]int
+	acks    [2]int
+}
+
+// ja4sshDir indexes the ja4ssh counters by direction, the client first.
+func ja4sshDir(dir reassembly.TCPFlowDirection) int {
+	if dir == reassembly.TCPDirClientToServer {
+		return 0
+	}
+	return 1
+}
+
+// add counts the packet, and reports if the window is full.
+func (j *ja4ssh) add(tcp *layers.TCP, dir reassembly.TCPFlowDirection) bool {
+	d := ja4sshDir(dir)
+	switch {
+	case len(tcp.Payload) > 0:
+		if j.lengths[d] == nil {
+			j.lengths[d] = make(map[int]int)
+		}
+		j.lengths[d][len(tcp.Payload)]++
+		j.packets[d]++
+	case tcp.ACK && !tcp.SYN && !tcp.FIN && !tcp.RST:
+		j.acks[d]++
+	default:
+		return false
+	}
+	return j.count() >= opts.JA4SSHPackets
+}
+
+// count returns the number of packets in the window.
+func (j *ja4ssh) count() int {
+	return j.packets[0] + j.packets[1] + j.acks[0] + j.acks[1]
+}
+
+// mode returns the most common payload length in the direction, the
+// smallest one on ties.
+func (j *ja4ssh) mode(d int) int {
+	mode, n := 0, 0
+	for l, c := range j.lengths[d] {
+		if c > n || (c == n && l < mode) {
+			mode, n = l, c
+		}
+	}
+	return mode
+}
+
+func (j *ja4ssh) String() string {
+	return fmt.Sprintf("c%ds%d_c%ds%d_c%ds%d", j.mode(0), j.mode(1), j.packets[0], j.packets[1], j.acks[0], j.acks[1])
+}
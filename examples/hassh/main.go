@@ -2,61 +2,47 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
-	"io"
-	"io/ioutil"
+	"errors"
 	"net/http"
 	_ "net/http/pprof"
 
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/examples/util"
-	"github.com/google/gopacket/ip4defrag"
 	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcap"
 	"github.com/google/gopacket/reassembly"
 	"github.com/kjelle/gohassh/essh"
+	"github.com/kjelle/gohassh/options"
+	"google.golang.org/protobuf/encoding/protodelim"
 )
 
-var statsevery = flag.Int("stats", 100000, "Output statistics every N packets")
-var nodefrag = flag.Bool("nodefrag", false, "If true, do not do IPv4 defrag")
-var checksum = flag.Bool("checksum", false, "Check TCP checksum")
-var nooptcheck = flag.Bool("nooptcheck", true, "Do not check TCP options (useful to ignore MSS on captures with TSO)")
-var ignorefsmerr = flag.Bool("ignorefsmerr", true, "Ignore TCP FSM errors")
-var verbose = flag.Bool("verbose", false, "Be verbose")
-var debug = flag.Bool("debug", false, "Display debug information")
-var quiet = flag.Bool("quiet", false, "Be quiet regarding errors")
-
-var hexdumppkt = flag.Bool("dumppkt", false, "Dump packet as hex")
-
-// capture
-var iface = flag.String("i", "eth0", "Interface to read packets from")
-var snaplen = flag.Int("s", 65536, "Snap length (number of bytes max to read per packet")
-var fname = flag.String("r", "", "Filename to read from, overrides -i")
-
-// writing
-var jsonIndent = flag.Bool("jsonindent", true, "Write JSON with indent")
-var outCerts = flag.String("w", "", "Folder to write certificates into")
-var outJSON = flag.String("j", "", "Folder to write certificates into, stdin if not set")
-var outFilename = flag.String("f", "", "Output all captures to a single filename")
-var jobQ chan SSHSession
+var opts = options.DefaultOptions()
 
-// debugging
-var pprofenabled = flag.Bool("pprof", false, "enabling net/http/pprof")
-var pprofint = flag.String("pprofint", "0.0.0.0", "interface to listen to")
-var pprofport = flag.Int("pprofport", 8080, "port to listen for pprof")
+func init() {
+	RegisterFlags(opts, flag.CommandLine)
+}
 
-const timeout time.Duration = time.Second * 5       // Pending bytes: TODO: from CLI
-const closeTimeout time.Duration = time.Second * 10 // Closing inactive: TODO: from CLI
+var jobQ chan SSHSession
+var limitC chan struct{}
 
 var assemblerOptions = reassembly.AssemblerOptions{
 	MaxBufferedPagesPerConnection: 0,
@@ -79,37 +65,92 @@ var stats struct {
 	biggestChunkPackets int
 	overlapBytes        int
 	overlapPackets      int
+	sessionsQueued      int
+	sessionsDropped     int
+	allowMatched        int // updated by the output worker
+	socketDropped       int // updated by the output worker
+	denyMatched         int // updated by the output worker
+	cidrFiltered        int // updated by the output worker
+	sampledIn           int // updated by the output worker
+	sampledOut          int // updated by the output worker
 }
 
-var outputLevel int
+var logger *slog.Logger
 var errorsMap map[string]uint
 var errorsMapMutex sync.Mutex
-var errors uint
+var errorCount uint
 var outFile *os.File
+var outputMutex sync.Mutex // serializes writing sessions, guards outFile and written
+var written int            // number of complete sessions written, see -limit
 var done bool
 
-// Too bad for perf that a... is evaluated
+// setupLogging creates the logger writing to stderr, so that it does not
+// interfere with sessions written to stdout. The level is given by
+// -debug, -verbose and -quiet.
+func setupLogging() {
+	level := slog.LevelWarn
+	if opts.Debug {
+		level = slog.LevelDebug
+	} else if opts.Verbose {
+		level = slog.LevelInfo
+	} else if opts.Quiet {
+		level = slog.LevelError
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if opts.LogJSON {
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, handlerOpts))
+	} else {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, handlerOpts))
+	}
+}
+
+// logf formats the message only if the level is enabled.
+func logf(level slog.Level, attrs []slog.Attr, s string, a ...interface{}) {
+	ctx := context.Background()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.LogAttrs(ctx, level, strings.TrimSuffix(fmt.Sprintf(s, a...), "\n"), attrs...)
+}
+
+// Error counts the error by type t and logs it as a warning.
 func Error(t string, s string, a ...interface{}) {
 	errorsMapMutex.Lock()
-	errors++
+	errorCount++
 	nb, _ := errorsMap[t]
 	errorsMap[t] = nb + 1
 	errorsMapMutex.Unlock()
-	if outputLevel >= 0 {
-		//fmt.Printf(s, a...)
-	}
+	metrics.errors.WithLabelValues(t).Inc()
+	logf(slog.LevelWarn, []slog.Attr{slog.String("type", t)}, s, a...)
+}
 
-	fmt.Printf(s, a...)
+// printErrors writes the number of errors counted by Error for every type,
+// the most frequent first.
+func printErrors(w io.Writer) {
+	errorsMapMutex.Lock()
+	defer errorsMapMutex.Unlock()
+	types := make([]string, 0, len(errorsMap))
+	for t := range errorsMap {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if errorsMap[types[i]] != errorsMap[types[j]] {
+			return errorsMap[types[i]] > errorsMap[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	fmt.Fprintf(w, "Errors: %d\n", errorCount)
+	for _, t := range types {
+		fmt.Fprintf(w, " %s:\t\t%d\n", t, errorsMap[t])
+	}
 }
+
 func Info(s string, a ...interface{}) {
-	if outputLevel >= 1 {
-		fmt.Printf(s, a...)
-	}
+	logf(slog.LevelInfo, nil, s, a...)
 }
 func Debug(s string, a ...interface{}) {
-	if outputLevel >= 2 {
-		fmt.Printf(s, a...)
-	}
+	logf(slog.LevelDebug, nil, s, a...)
 }
 
 /*
@@ -117,6 +158,9 @@ func Debug(s string, a ...interface{}) {
  */
 type tcpStreamFactory struct {
 	wg sync.WaitGroup
+
+	// Streams not yet complete, for the reaper
+	streams map[string]*tcpStream
 }
 
 func (factory *tcpStreamFactory) New(net, transport gopacket.Flow, tcp *layers.TCP, ac reassembly.AssemblerContext) reassembly.Stream {
@@ -129,7 +173,22 @@ func (factory *tcpStreamFactory) New(net, transport gopacket.Flow, tcp *layers.T
 		tcpstate:   reassembly.NewTCPSimpleFSM(fsmOptions),
 		ident:      fmt.Sprintf("%s:%s", net, transport),
 		optchecker: reassembly.NewTCPOptionCheck(),
-		sshSession: NewSSHSession(*iface),
+		sshSession: NewSSHSession(opts.Interface),
+	}
+	stream.sshSession.Stream = stream.ident
+	if factory.streams == nil {
+		factory.streams = make(map[string]*tcpStream)
+	}
+	factory.streams[stream.ident] = stream
+	stream.factory = factory
+	// The first packet is sent by the client
+	if c, ok := ac.(*Context); ok {
+		if c.Iface != "" {
+			stream.sshSession.InIface = c.Iface
+		}
+		stream.sshSession.SourceFile = c.Source
+		stream.sshSession.SetLink(c.SrcMAC, c.VLAN)
+		stream.sshSession.Tunnel = c.Tunnel
 	}
 
 	return stream
@@ -144,6 +203,17 @@ func (factory *tcpStreamFactory) WaitGoRoutines() {
  */
 type Context struct {
 	CaptureInfo gopacket.CaptureInfo
+
+	// Interface the packet was captured on, or the file it was read from
+	Iface  string
+	Source string
+
+	// Link layer of the packet, used when it starts a new stream
+	SrcMAC net.HardwareAddr
+	VLAN   uint16
+
+	// Tunnel the packet was unwrapped from, with -decap
+	Tunnel *Tunnel
 }
 
 func (c *Context) GetCaptureInfo() gopacket.CaptureInfo {
@@ -160,11 +230,19 @@ type tcpStream struct {
 	fsmerr         bool
 	optchecker     reassembly.TCPOptionCheck
 	net, transport gopacket.Flow
-	reversed       bool
+	reversed       bool // the assembler took the server for the client
+	started        bool // a SYN or data was seen
 	urls           []string
 	ident          string
+	factory        *tcpStreamFactory
+	firstSeen      time.Time // capture time of the first accepted packet
+	lastSeen       time.Time // capture time of the last accepted packet
+	expired        bool      // older than -max-session-age, no longer buffered
+	ja4ssh         ja4ssh
 	sshSession     SSHSession
-	queued         bool
+	queued         bool          // the session was emitted, guarded by the mutex
+	midstream      bool          // the start of the stream was not captured
+	decoders       [2]sshDecoder // client to server, then server to client
 	ignorefsmerr   bool
 	nooptcheck     bool
 	checksum       bool
@@ -172,6 +250,33 @@ type tcpStream struct {
 }
 
 func (t *tcpStream) Accept(tcp *layers.TCP, ci gopacket.CaptureInfo, dir reassembly.TCPFlowDirection, nextSeq reassembly.Sequence, start *bool, ac reassembly.AssemblerContext) bool {
+	if t.expired && !tcp.FIN && !tcp.RST {
+		return false
+	}
+	// Release the stream as early as possible, the rest is not needed.
+	// FIN and RST are still accepted so that the stream is closed.
+	if opts.BannersOnly && t.sshSession.BannersComplete() && !tcp.FIN && !tcp.RST {
+		return false
+	}
+	// Without the TCP handshake the assembler takes the sender of the first
+	// packet for the client, which is as likely to be the server.
+	if opts.Midstream && !t.started {
+		switch {
+		case tcp.SYN && tcp.ACK:
+			// Sent by the server, whatever the assembler made of it
+			t.setDirection(dir != reassembly.TCPDirClientToServer, dir)
+		case !tcp.SYN && len(tcp.Payload) > 0:
+			t.inferDirection(tcp, dir)
+		}
+	}
+	if tcp.SYN || len(tcp.Payload) > 0 {
+		t.started = true
+	}
+	// With -client-only the server is never buffered, nor the client once
+	// its KEXINIT is seen.
+	if opts.ClientOnly && (t.role(dir) == reassembly.TCPDirServerToClient || t.queued) && !tcp.FIN && !tcp.RST {
+		return false
+	}
 	// FSM
 	if !t.tcpstate.CheckState(tcp, dir) {
 		Error("FSM", "%s: Packet rejected by FSM (state:%s)\n", t.ident, t.tcpstate.String())
@@ -180,7 +285,7 @@ func (t *tcpStream) Accept(tcp *layers.TCP, ci gopacket.CaptureInfo, dir reassem
 			t.fsmerr = true
 			stats.rejectConnFsm++
 		}
-		if !*ignorefsmerr {
+		if !opts.IgnoreFSMErr {
 			return false
 		}
 	}
@@ -189,13 +294,20 @@ func (t *tcpStream) Accept(tcp *layers.TCP, ci gopacket.CaptureInfo, dir reassem
 	if err != nil {
 		Error("OptionChecker", "%s: Packet rejected by OptionChecker: %s\n", t.ident, err)
 		stats.rejectOpt++
-		if !*nooptcheck {
+		if !opts.NoOptCheck {
 			return false
 		}
 	}
+	// Streams picked up after the handshake never see a SYN, so without
+	// forcing a start their data is held back until the stream is flushed.
+	// nextSeq is -1 until the start of the stream is known.
+	if opts.Midstream && nextSeq == -1 && !tcp.SYN && len(tcp.Payload) > 0 {
+		*start = true
+		t.midstream = true
+	}
 	// Checksum
 	accept := true
-	if *checksum {
+	if opts.Checksum {
 		c, err := tcp.ComputeChecksum()
 		if err != nil {
 			Error("ChecksumCompute", "%s: Got error computing checksum: %s\n", t.ident, err)
@@ -207,6 +319,14 @@ func (t *tcpStream) Accept(tcp *layers.TCP, ci gopacket.CaptureInfo, dir reassem
 	}
 	if !accept {
 		stats.rejectOpt++
+	} else {
+		if t.firstSeen.IsZero() {
+			t.firstSeen = ci.Timestamp
+		}
+		t.lastSeen = ci.Timestamp
+		if opts.JA4SSH && t.sshSession.KexExchangeComplete() && t.sshSession.JA4SSH == "" && t.ja4ssh.add(tcp, t.role(dir)) {
+			t.sshSession.JA4SSH = t.ja4ssh.String()
+		}
 	}
 	return accept
 
@@ -234,11 +354,11 @@ func (t *tcpStream) ReassembledSG(sg reassembly.ScatterGather, ac reassembly.Ass
 		stats.biggestChunkPackets = sgStats.Packets
 	}
 	if sgStats.OverlapBytes != 0 && sgStats.OverlapPackets == 0 {
-		fmt.Printf("bytes:%d, pkts:%d\n", sgStats.OverlapBytes, sgStats.OverlapPackets)
-		panic("Invalid overlap")
+		panic(fmt.Sprintf("Invalid overlap, bytes:%d, pkts:%d", sgStats.OverlapBytes, sgStats.OverlapPackets))
 	}
 	stats.overlapBytes += sgStats.OverlapBytes
 	stats.overlapPackets += sgStats.OverlapPackets
+	t.sshSession.Anomalies.Add(sgStats, skip)
 
 	var ident string
 	if dir == reassembly.TCPDirClientToServer {
@@ -247,6 +367,9 @@ func (t *tcpStream) ReassembledSG(sg reassembly.ScatterGather, ac reassembly.Ass
 		ident = fmt.Sprintf("%v %v(%s): ", t.net.Reverse(), t.transport.Reverse(), dir)
 	}
 	Debug("%s: SG reassembled packet with %d bytes (start:%v,end:%v,skip:%d,saved:%d,nb:%d,%d,overlap:%d,%d)\n", ident, length, start, end, skip, saved, sgStats.Packets, sgStats.Chunks, sgStats.OverlapBytes, sgStats.OverlapPackets)
+	// From here on the direction is the role of the sender
+	dir = t.role(dir)
+	dec := t.decoder(dir)
 
 	/*fmt.Printf("%s: Data Length: %d   Skip: %d   BannersComplete: %t\n",
 		ident,
@@ -255,83 +378,240 @@ func (t *tcpStream) ReassembledSG(sg reassembly.ScatterGather, ac reassembly.Ass
 		t.sshSession.BannersComplete(),
 	)*/
 
-	if skip == -1 {
-		// this is allowed
-	} else if skip != 0 {
-		/*		fmt.Printf("!! SKIP %d bytes (length:%d)\n",
-					skip,
-					length,
-				)
-
-				d := sg.Fetch(skip)
-				fmt.Printf(" [...] %02x\n", d)*/
-
-		// Missing bytes in stream: do not even try to parse it
+	switch {
+	case skip == -1:
+		// The start of the stream is unknown, as when it was picked up
+		// without its handshake: decode it all the same, the banner or a
+		// KEXINIT may be in it.
+	case skip > 0:
+		// Missing bytes in stream: do not even try to parse it, nor
+		// complete the pending record with what follows the gap
+		dec.pending = dec.pending[:0]
+		return
+	}
+	// Nothing new to decode, as on a FIN
+	if length == 0 {
 		return
 	}
 	data := sg.Fetch(length)
+	if len(dec.pending) > 0 {
+		// Complete the record left over by the previous chunks
+		data = append(dec.pending, data...)
+		length = len(data)
+	}
 
-	if length > 0 {
+	// Nothing more to decode
+	if opts.BannersOnly && t.sshSession.BannersComplete() {
+		return
+	}
+	if dec.newKeys {
+		return
+	}
 
-		// For all SSH we must first decode the banner, if no banner have been
-		// completed, we do not parse the rest of the stream.
-		var decb bool
-		decb = t.sshSession.BannersComplete()
-		ssh := essh.NewESSH(decb)
+	// For all SSH we must first decode the banner, if no banner have been
+	// completed, we do not parse the rest of the stream.
+	if !dec.ssh.BannersComplete && t.midstream && isKexinit(data) {
+		// The capture started after the banner exchange
+		Debug("%s: KEXINIT without banner\n", ident)
+		dec.bannerless = true
+		dec.ssh.BannersComplete = true
+	}
+	err := dec.decode(data, dir == reassembly.TCPDirClientToServer)
+	ssh := &dec.ssh
+	dec.pending = dec.pending[:0]
 
-		var decoded []gopacket.LayerType
-		p := gopacket.NewDecodingLayerParser(essh.LayerTypeESSH, ssh)
-		p.DecodingLayerParserOptions.IgnoreUnsupported = true
-		err := p.DecodeLayers(data, &decoded)
+	if opts.HexDump && length > 1000 {
+		Debug("%s> Packet content (%d/0x%x)\n%s\n", ident, len(data), len(data), hex.Dump(data))
+		if err != nil {
+			Debug("%s> Error: %s\n", ident, err)
+		}
+	}
 
-		if length > 1000 {
-			fmt.Printf("%s> Packet content (%d/0x%x)\n%s\n", ident, len(data), len(data), hex.Dump(data))
-			if err != nil {
-				fmt.Printf("%s> Error: %s\n", ident, err)
+	if err != nil {
+		switch {
+		case errors.Is(err, essh.ErrTruncated):
+			// If it's fragmented we keep the incomplete record for
+			// next round, the records before it are handled below.
+			rest := data[len(ssh.LayerContents()):]
+			if len(rest) > maxPendingBytes {
+				Error("PendingOverflow", "%s: Incomplete record of more than %d bytes dropped\n", ident, maxPendingBytes)
+			} else {
+				dec.pending = append(dec.pending, rest...)
 			}
+		case errors.Is(err, essh.ErrNotSSH):
+			Debug("%s: Not SSH: %s\n", ident, err)
+		case errors.Is(err, essh.ErrMalformedKexinit):
+			t.sshSession.SetMalformed()
+			Error("MalformedKexinit", "%s: %s\n", ident, err)
+		case errors.Is(err, essh.ErrInvalidNameList):
+			t.sshSession.SetMalformed()
+			Error("InvalidNameList", "%s: %s\n", ident, err)
+		case errors.Is(err, essh.ErrKexinitTooLarge):
+			t.sshSession.SetMalformed()
+			Error("KexinitTooLarge", "%s: %s\n", ident, err)
+		case errors.Is(err, essh.ErrMalformedKexDH):
+			t.sshSession.SetMalformed()
+			Error("MalformedKexDH", "%s: %s\n", ident, err)
+		case errors.Is(err, essh.ErrWrongMessageCode):
+			Debug("%s: %s\n", ident, err)
+		default:
+			Error("Decode", "%s: %s\n", ident, err)
 		}
+	}
 
-		if err != nil {
-			// If it's fragmented we keep for next round
-			sg.KeepFrom(0)
-		} else {
-			//			fmt.Printf("SSH(%s): %s\n", dir, gopacket.LayerDump(ssh))
-			//			Debug("SSH(%s): %s\n", dir, gopacket.LayerDump(ssh))
-			//				Debug("SSH(%s): %s\n", dir, gopacket.LayerGoString(ssh))
-			if ssh.Banner != nil {
-				if t.sshSession.Timestamp.IsZero() {
-					info := sg.CaptureInfo(0)
-					t.sshSession.SetTimestamp(info.Timestamp)
-				}
+	// Records decoded before an error are still handled
+	//			fmt.Printf("SSH(%s): %s\n", dir, gopacket.LayerDump(ssh))
+	//			Debug("SSH(%s): %s\n", dir, gopacket.LayerDump(ssh))
+	//				Debug("SSH(%s): %s\n", dir, gopacket.LayerGoString(ssh))
+	ts := sg.CaptureInfo(0).Timestamp
+	if ssh.Banner != nil && t.sshSession.BannerTime == nil {
+		t.sshSession.BannerTime = &ts
+	}
+	if dec.bannerless && t.sshSession.ClientIP == "" {
+		cip, sip, cp, sp := getIPPorts(t)
+		t.sshSession.SetNetwork(cip, sip, cp, sp)
+	}
 
-				if dir == reassembly.TCPDirClientToServer {
-					t.sshSession.ClientBanner(ssh.Banner)
-				} else {
-					t.sshSession.ServerBanner(ssh.Banner)
-					// Set network information in the session
-					cip, sip, cp, sp := getIPPorts(t)
-					t.sshSession.SetNetwork(cip, sip, cp, sp)
-				}
+	if ssh.Banner != nil {
+		if dir == reassembly.TCPDirClientToServer {
+			t.sshSession.ClientBanner(ssh.Banner)
+			// A server offering both protocols speaks the one of the
+			// client
+			if ssh.Banner.SSH1() {
+				t.decoder(reassembly.TCPDirServerToClient).ssh.Protocol1 = true
 			}
+		} else {
+			t.sshSession.ServerBanner(ssh.Banner)
+			// Set network information in the session
+			cip, sip, cp, sp := getIPPorts(t)
+			t.sshSession.SetNetwork(cip, sip, cp, sp)
+		}
+	}
 
-			if ssh.Kexinit != nil {
-				if ssh.Kexinit.FirstKexFollows {
-					fmt.Printf("%s> FirstKexFollows\n")
-				}
+	if ssh.Kexinit != nil && opts.MinAlgorithms > 0 {
+		if err := ssh.Kexinit.Validate(opts.MinAlgorithms); err != nil {
+			// Not fingerprinted, it would only be noise
+			t.sshSession.SetMalformed()
+			Error("BogusKexinit", "%s: %s\n", ident, err)
+			ssh.Kexinit = nil
+		}
+	}
 
-				if dir == reassembly.TCPDirClientToServer {
-					t.sshSession.ClientKeyExchangeInit(ssh.Kexinit)
-				} else {
-					t.sshSession.ServerKeyExchangeInit(ssh.Kexinit)
-				}
-			}
+	if ssh.Kexinit != nil {
+		if ssh.Kexinit.FirstKexFollows {
+			Debug("%s> FirstKexFollows\n", ident)
+		}
+		if ssh.Kexinit.Sanitized {
+			Error("SanitizedNameList", "%s: KEXINIT with invalid names, sanitized\n", ident)
+		}
 
-			if t.sshSession.KexInitComplete() && !t.queued {
-				t.queueSession()
-			}
+		if t.sshSession.KexinitTime == nil {
+			t.sshSession.KexinitTime = &ts
+		}
+		r := &t.sshSession.Server
+		if dir == reassembly.TCPDirClientToServer {
+			r = &t.sshSession.Client
+			t.sshSession.ClientKeyExchangeInit(ssh.Kexinit, ts)
+		} else {
+			t.sshSession.ServerKeyExchangeInit(ssh.Kexinit, ts)
 		}
+		if opts.ProtoDetail && r.KexinitHeader == nil {
+			h := ssh.Kexinit.Header
+			r.KexinitHeader = &h
+		}
+	}
+
+	if ssh.KexDHInit != nil && dir == reassembly.TCPDirClientToServer {
+		t.sshSession.ClientKexDHInit(ssh.KexDHInit)
+	}
+	if ssh.KexDHReply != nil && dir == reassembly.TCPDirServerToClient {
+		t.sshSession.ServerKexDHReply(ssh.KexDHReply)
+	}
+	if ssh.KexGexRequest != nil && dir == reassembly.TCPDirClientToServer {
+		t.sshSession.ClientKexGexRequest(ssh.KexGexRequest)
+	}
+	if ssh.KexGexGroup != nil && dir == reassembly.TCPDirServerToClient {
+		t.sshSession.ServerKexGexGroup(ssh.KexGexGroup)
+	}
+	if ssh.NewKeys {
+		if dir == reassembly.TCPDirClientToServer {
+			t.sshSession.ClientNewKeys()
+		} else {
+			t.sshSession.ServerNewKeys()
+		}
+		// What follows is encrypted, unless the keys leave it in the
+		// clear: rekeys are then seen as well
+		dec.newKeys = !t.sshSession.Cleartext(dir == reassembly.TCPDirClientToServer)
+	}
+	if ssh.SSH1PublicKey != nil && dir == reassembly.TCPDirServerToClient {
+		t.sshSession.ServerSSH1PublicKey(ssh.SSH1PublicKey)
+		dec.newKeys = true
+	}
+	if ssh.SSH1SessionKey != nil && dir == reassembly.TCPDirClientToServer {
+		t.sshSession.ClientSSH1SessionKey(ssh.SSH1SessionKey)
+		dec.newKeys = true
+	}
+
+	// Sessions are queued once the stream is closed or flushed, with all
+	// that was seen of it. With -client-only they are queued as soon as
+	// the client sent its KEXINIT instead, as nothing later is decoded.
+	if opts.ClientOnly && t.sshSession.Handshake().Has(StateClientKexInit) {
+		t.emit()
+	}
+}
+
+// maxPendingBytes bounds the start of a record kept until the rest of it
+// arrives. Binary packets are at most 35000 bytes, see RFC 4253, section
+// 6.1, and version strings 255.
+const maxPendingBytes = 35000 + 255
+
+// sshDecoder decodes one direction of a stream. It lives as long as the
+// stream, so that what was decoded from the previous chunks carries over to
+// the next: whether the banner is complete, and the start of a record split
+// over chunks.
+type sshDecoder struct {
+	ssh        essh.ESSH
+	pending    []byte // start of a record split over chunks
+	bannerless bool   // the direction was picked up after the banner
+	newKeys    bool   // NEWKEYS, or the protocol 1 key, was sent, the rest is encrypted
+}
+
+// decoder returns the decoder of the direction.
+func (t *tcpStream) decoder(dir reassembly.TCPFlowDirection) *sshDecoder {
+	if dir == reassembly.TCPDirClientToServer {
+		return &t.decoders[0]
+	}
+	return &t.decoders[1]
+}
+
+// decode decodes the data the client, or else the server, sent into the
+// layer. The records of the previous chunk are cleared, only the progress
+// through the banner is kept.
+func (d *sshDecoder) decode(data []byte, client bool) error {
+	d.ssh = essh.ESSH{
+		BannersComplete:  d.ssh.BannersComplete,
+		BannersOnly:      opts.BannersOnly,
+		SkipPreamble:     client,
+		NameListPolicy:   opts.NameListPolicy,
+		MaxNameListNames: opts.MaxNameListNames,
+		MaxNameListBytes: opts.MaxNameListBytes,
+		Protocol1:        d.ssh.Protocol1,
+		Records:          d.ssh.Records[:0],
 	}
+	return d.ssh.DecodeFromBytes(data, gopacket.NilDecodeFeedback)
+}
 
+// cleartext reports if either side of the session sends its packets in the
+// clear once the keys are in use, see Session.Cleartext.
+func (t *tcpStream) cleartext() bool {
+	return t.sshSession.Cleartext(true) || t.sshSession.Cleartext(false)
+}
+
+// isKexinit reports if data starts with a binary packet holding a KEXINIT,
+// as specified by RFC 4253, section 6. Packets are at most 35000 bytes, see
+// section 6.1.
+func isKexinit(data []byte) bool {
+	return len(data) >= 6 && binary.BigEndian.Uint32(data[0:4]) <= 35000 && essh.ESSHType(data[5]) == essh.ESSH_MSG_KEXINIT
 }
 
 func getIPPorts(t *tcpStream) (string, string, string, string) {
@@ -341,87 +621,537 @@ func getIPPorts(t *tcpStream) (string, string, string, string) {
 	tmp = strings.Split(fmt.Sprintf("%v", t.transport), "->")
 	cp := tmp[0]
 	ps := tmp[1]
+	if t.reversed {
+		return ips, ipc, ps, cp
+	}
 	return ipc, ips, cp, ps
 }
 
+// role returns the direction of the assembler as seen from the client and
+// server, which differ when the direction was inferred to be reversed.
+func (t *tcpStream) role(dir reassembly.TCPFlowDirection) reassembly.TCPFlowDirection {
+	if t.reversed {
+		return dir.Reverse()
+	}
+	return dir
+}
+
+// inferDirection decides, from the first data packet of a stream picked up
+// without its TCP handshake, which side is the client:
+//   - the side on -assume-client-port,
+//   - else the side sending a banner followed by a KEXINIT, as clients do
+//     while servers send their banner on its own when the connection opens,
+//   - else the side on the higher port, servers listening on the lower one.
+func (t *tcpStream) inferDirection(tcp *layers.TCP, dir reassembly.TCPFlowDirection) {
+	senderIsClient := true
+	ssh := essh.NewESSH(false)
+	ssh.SkipPreamble = true // as sent by a client
+	ssh.DecodeFromBytes(tcp.Payload, gopacket.NilDecodeFeedback)
+	switch p := layers.TCPPort(opts.AssumeClientPort); {
+	case p != 0 && tcp.SrcPort == p:
+	case p != 0 && tcp.DstPort == p:
+		senderIsClient = false
+	case ssh.Banner != nil && ssh.Kexinit != nil:
+	default:
+		senderIsClient = tcp.SrcPort >= tcp.DstPort
+	}
+	t.setDirection(senderIsClient, dir)
+}
+
+// setDirection records whether the sender of a packet flowing in dir of the
+// assembler is the client.
+func (t *tcpStream) setDirection(senderIsClient bool, dir reassembly.TCPFlowDirection) {
+	t.reversed = senderIsClient != (dir == reassembly.TCPDirClientToServer)
+	t.sshSession.DirectionInferred = true
+	if t.reversed {
+		// Only the MAC of the first sender is known
+		t.sshSession.ClientMAC = ""
+		Debug("%s: Inferred the direction to be reversed\n", t.ident)
+	}
+}
+
 func (t *tcpStream) ReassemblyComplete(ac reassembly.AssemblerContext) bool {
-	partial := true
-	if partial && !t.queued && t.sshSession.state > 0 {
-		t.queueSession()
+	if opts.JA4SSH && t.sshSession.JA4SSH == "" && t.sshSession.KexExchangeComplete() {
+		// The stream ended before the JA4SSH window was full
+		if t.ja4ssh.count() > 0 {
+			t.sshSession.JA4SSH = t.ja4ssh.String()
+		}
+	}
+	if t.complete() {
+		t.emit()
+	} else if opts.Partial && t.sshSession.Handshake() != 0 && t.emitPartial() {
+		metrics.partials.Inc()
+	}
+	if t.factory.streams[t.ident] == t {
+		delete(t.factory.streams, t.ident)
 	}
 
 	// remove connection from the pool
 	return true
 }
 
+// complete reports if the session got as far as it is decoded: both banners
+// with -banners-only, the key exchange with -ja4ssh, else both NEWKEYS, or
+// the session key of protocol 1. Incomplete sessions are only written with
+// -partial.
+func (t *tcpStream) complete() bool {
+	s := &t.sshSession
+	switch {
+	case opts.BannersOnly:
+		return s.BannersComplete()
+	case opts.ClientOnly:
+		return s.Handshake().Has(StateClientKexInit)
+	case opts.JA4SSH:
+		return s.KexExchangeComplete()
+	}
+	return s.KexInitComplete() && s.NewKeysComplete() || s.HandshakeComplete && t.cleartext() || s.SSH1Complete()
+}
+
+// reapStuck queues, with -partial, the incomplete sessions of streams which
+// have been inactive for CloseTimeout at ref. Such streams are only closed by
+// the assembler once all their pending data is flushed, so their sessions
+// are emitted here rather than waiting for it. Returns the number of
+// sessions queued.
+func (factory *tcpStreamFactory) reapStuck(ref time.Time) int {
+	if !opts.Partial {
+		return 0
+	}
+	reaped := 0
+	for ident, t := range factory.streams {
+		if !t.lastSeen.Before(ref.Add(-opts.CloseTimeout)) {
+			continue
+		}
+		delete(factory.streams, ident)
+		if t.sshSession.Handshake() == 0 || !t.emitPartial() {
+			continue
+		}
+		Debug("%s: Reaped stuck stream (state:%d, last seen %s)\n", ident, t.sshSession.Handshake(), t.lastSeen)
+		metrics.partials.Inc()
+		reaped++
+	}
+	return reaped
+}
+
+// expireOld queues the sessions of streams first seen longer than
+// -max-session-age before ref, however active they still are, and stops
+// buffering them. The assembler keeps the connections until they are
+// closed or inactive, but without any data.
+// Returns the number of sessions queued.
+func (factory *tcpStreamFactory) expireOld(ref time.Time) int {
+	if opts.MaxSessionAge <= 0 {
+		return 0
+	}
+	expired := 0
+	for ident, t := range factory.streams {
+		if t.firstSeen.IsZero() || !t.firstSeen.Before(ref.Add(-opts.MaxSessionAge)) {
+			continue
+		}
+		delete(factory.streams, ident)
+		t.expired = true
+		if t.sshSession.Handshake() == 0 || !t.emitPartial() {
+			continue
+		}
+		Debug("%s: Expired stream first seen %s\n", ident, t.firstSeen)
+		metrics.partials.Inc()
+		expired++
+	}
+	return expired
+}
+
 func main() {
 
 	defer util.Run()()
+	opts.BPFFilter = strings.Join(flag.Args(), " ")
 	var handle *pcap.Handle
 	var err error
-	if *debug {
-		outputLevel = 2
-	} else if *verbose {
-		outputLevel = 1
-	} else if *quiet {
-		outputLevel = -1
-	}
+	setupLogging()
 	errorsMap = make(map[string]uint)
-	if *fname != "" {
-		if handle, err = pcap.OpenOffline(*fname); err != nil {
-			log.Fatal("PCAP OpenOffline error:", err)
+
+	if opts.Manifest != "" {
+		files, err := readManifest(opts.Manifest)
+		if err != nil {
+			log.Fatal("Unable to read manifest:", err)
 		}
-	} else {
-		// Open live on interface
-		if handle, err = pcap.OpenLive(*iface, 65536, true, 0); err != nil {
-			log.Fatal("PCAP OpenOffline error:", err)
+		opts.Files = append(opts.Files, files...)
+	}
+
+	openFlushLog()
+	if err := parseFilenameTemplate(); err != nil {
+		log.Fatal("Invalid -filename-template:", err)
+	}
+	if err := readHASSHLists(); err != nil {
+		log.Fatal("Unable to read HASSH lists:", err)
+	}
+	if opts.ImplMap != "" {
+		if err := readImplementations(opts.ImplMap); err != nil {
+			log.Fatal("Unable to read -impl-map:", err)
 		}
-		defer handle.Close()
 	}
-	if len(flag.Args()) > 0 {
-		bpffilter := strings.Join(flag.Args(), " ")
-		Info("Using BPF filter %q\n", bpffilter)
-		if err = handle.SetBPFFilter(bpffilter); err != nil {
-			log.Fatal("BPF filter error:", err)
+	if opts.Policy != "" {
+		if err := readPolicy(opts.Policy); err != nil {
+			log.Fatal("Unable to read -policy:", err)
+		}
+	}
+
+	if opts.Capture != "pcap" && opts.Capture != "afpacket" {
+		log.Fatalf("Invalid -capture %q, expected pcap or afpacket", opts.Capture)
+	}
+
+	// Checked on a timer at half of it
+	if opts.IdleTimeout < 0 || opts.IdleTimeout > 0 && opts.IdleTimeout < time.Second {
+		log.Fatalf("Invalid -flush-on-idle %s, expected 0 or at least 1s", opts.IdleTimeout)
+	}
+	if opts.SampleRate < 0 || opts.SampleRate > 1 {
+		log.Fatalf("Invalid -sample-rate %g, expected 0.0 to 1.0", opts.SampleRate)
+	}
+	switch opts.TimestampSource {
+	case TimestampFirstPacket, TimestampBanner, TimestampKexinit:
+	default:
+		log.Fatalf("Invalid -timestamp-source %q, expected first-packet, banner or kexinit", opts.TimestampSource)
+	}
+	if opts.Proto && opts.UnixSocket != "" {
+		log.Fatal("-proto cannot be used with -unix-socket, which takes NDJSON")
+	}
+	switch opts.Schema {
+	case SchemaGoHASSH:
+	case SchemaHASSH:
+		if opts.Proto || opts.JSONArray {
+			log.Fatal("-schema hassh writes JSON lines, it cannot be used with -proto or -json-array")
+		}
+	default:
+		log.Fatalf("Invalid -schema %q, expected gohassh or hassh", opts.Schema)
+	}
+	if opts.JSONArray && (opts.OutputDir == "" || opts.OutputFile == "" || opts.UnixSocket != "" || opts.Proto) {
+		log.Fatal("-json-array can only be used when writing JSON to a single file (-j and -f)")
+	}
+	if opts.Decap != "" && opts.Decap != "vxlan" && opts.Decap != "gre" {
+		log.Fatalf("Invalid -decap %q, expected vxlan or gre", opts.Decap)
+	}
+
+	if opts.GeoIP != "" {
+		if g, err := openGeoIP(opts.GeoIP); err != nil {
+			log.Println("GeoIP enrichment disabled, unable to open -geoip:", err)
+		} else {
+			geo = g
+			defer geo.close()
+			RegisterEnricher(geo.enrich)
 		}
 	}
 
+	if opts.Selftest {
+		os.Exit(selftest())
+	}
+	if opts.Check {
+		os.Exit(check())
+	}
+
 	// For debug
-	if *pprofenabled {
+	if opts.Pprof {
 		//runtime.SetBlockProfileRate(1)
 		go func() {
-			fmt.Printf(" .. pprof listener on %d\n", *pprofport)
-			http.ListenAndServe(fmt.Sprintf("%s:%d", *pprofint, *pprofport), nil)
-			fmt.Println("!! Pprof listener stopped.\n")
+			Info("Pprof listener on %d\n", opts.PprofPort)
+			err := http.ListenAndServe(fmt.Sprintf("%s:%d", opts.PprofAddr, opts.PprofPort), nil)
+			Error("Pprof", "Pprof listener stopped: %s\n", err)
 		}()
 
 	}
+	if opts.Metrics || opts.Healthz {
+		serveMetrics()
+	}
 
+	// Signal chan for system signals
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+
+	p := newPipeline()
+
+	if len(opts.Files) == 0 {
+		if opts.Sort {
+			log.Fatal("-sort can only be used when reading from files (-r)")
+		}
+		// Open live on every interface, all feeding the same assembler
+		packets := make(chan capturedPacket, 1024)
+		var handles []liveHandle
+		var captures sync.WaitGroup
+		for _, iface := range strings.Split(opts.Interface, ",") {
+			handle, err := openCapture(iface)
+			if err != nil {
+				Error("OpenLive", "Unable to capture on %s: %s\n", iface, err)
+				continue
+			}
+			handles = append(handles, handle)
+			captures.Add(1)
+			go capture(handle, iface, "", packets, p.stop, &captures)
+		}
+		if len(handles) == 0 {
+			log.Fatal("PCAP OpenLive error: no interface could be opened")
+		}
+		go func() {
+			captures.Wait()
+			close(packets)
+		}()
+		p.readPackets(packets, signalChan, false)
+		for _, handle := range handles {
+			handle.Close()
+		}
+	} else {
+		// All files go through the same assembler, so that a session
+		// split over rotated captures is reassembled as one.
+		for _, fn := range opts.Files {
+			if done {
+				break
+			}
+			if handle, err = pcap.OpenOffline(fn); err != nil {
+				log.Fatal("PCAP OpenOffline error:", err)
+			}
+			setBPFFilter(handle)
+			queued := stats.sessionsQueued
+			c, b := p.processPcap(handle, opts.Interface, fn, signalChan)
+			handle.Close()
+			fmt.Fprintf(os.Stderr, "File %s: %d packets, %d bytes, %d sessions queued\n", fn, c, b, stats.sessionsQueued-queued)
+		}
+	}
+
+	Info(fmt.Sprintf("%d Bytes read.\n", p.bytes))
+
+	health.stopping.Store(true)
+	p.close()
+
+	fmt.Fprintf(os.Stderr, "TCP stats:\n")
+	fmt.Fprintf(os.Stderr, " packets read:\t\t%d\n", p.count)
+	fmt.Fprintf(os.Stderr, " missed bytes:\t\t%d\n", stats.missedBytes)
+	fmt.Fprintf(os.Stderr, " total packets:\t\t%d\n", stats.pkt)
+	fmt.Fprintf(os.Stderr, " rejected FSM:\t\t%d\n", stats.rejectFsm)
+	fmt.Fprintf(os.Stderr, " rejected Options:\t%d\n", stats.rejectOpt)
+	fmt.Fprintf(os.Stderr, " reassembled bytes:\t%d\n", stats.sz)
+	fmt.Fprintf(os.Stderr, " total TCP bytes:\t%d\n", stats.totalsz)
+	fmt.Fprintf(os.Stderr, " conn rejected FSM:\t%d\n", stats.rejectConnFsm)
+	fmt.Fprintf(os.Stderr, " reassembled chunks:\t%d\n", stats.reassembled)
+	fmt.Fprintf(os.Stderr, " out-of-order packets:\t%d\n", stats.outOfOrderPackets)
+	fmt.Fprintf(os.Stderr, " out-of-order bytes:\t%d\n", stats.outOfOrderBytes)
+	fmt.Fprintf(os.Stderr, " biggest-chunk packets:\t%d\n", stats.biggestChunkPackets)
+	fmt.Fprintf(os.Stderr, " biggest-chunk bytes:\t%d\n", stats.biggestChunkBytes)
+	fmt.Fprintf(os.Stderr, " overlap packets:\t%d\n", stats.overlapPackets)
+	fmt.Fprintf(os.Stderr, " overlap bytes:\t\t%d\n", stats.overlapBytes)
+	fmt.Fprintf(os.Stderr, "Session stats:\n")
+	fmt.Fprintf(os.Stderr, " queued sessions:\t%d\n", stats.sessionsQueued)
+	fmt.Fprintf(os.Stderr, " dropped sessions:\t%d\n", stats.sessionsDropped)
+	if opts.UnixSocket != "" {
+		fmt.Fprintf(os.Stderr, " socket dropped:\t%d\n", stats.socketDropped)
+	}
+	printErrors(os.Stderr)
+
+	if hasshAllow != nil || hasshDeny != nil {
+		fmt.Fprintf(os.Stderr, "Filter stats:\n")
+		fmt.Fprintf(os.Stderr, " allowlist matches:\t%d\n", stats.allowMatched)
+		fmt.Fprintf(os.Stderr, " denylist matches:\t%d\n", stats.denyMatched)
+	}
+	if len(opts.ClientCIDR) > 0 || len(opts.ServerCIDR) > 0 {
+		fmt.Fprintf(os.Stderr, "CIDR filter stats:\n")
+		fmt.Fprintf(os.Stderr, " filtered sessions:\t%d\n", stats.cidrFiltered)
+	}
+	if geo != nil {
+		fmt.Fprintf(os.Stderr, "GeoIP stats:\n")
+		fmt.Fprintf(os.Stderr, " lookups:\t\t%d\n", geo.lookups.Load())
+		fmt.Fprintf(os.Stderr, " misses:\t\t%d\n", geo.misses.Load())
+		fmt.Fprintf(os.Stderr, " errors:\t\t%d\n", geo.errors.Load())
+	}
+	if opts.SampleRate < 1 {
+		fmt.Fprintf(os.Stderr, "Sample stats:\n")
+		fmt.Fprintf(os.Stderr, " sampled in:\t\t%d\n", stats.sampledIn)
+		fmt.Fprintf(os.Stderr, " sampled out:\t\t%d\n", stats.sampledOut)
+		if n := stats.sampledIn + stats.sampledOut; n > 0 {
+			fmt.Fprintf(os.Stderr, " effective rate:\t%.4f\n", float64(stats.sampledIn)/float64(n))
+		}
+	}
+}
+
+// openLive opens a live capture on the interface with the -snaplen, -promisc
+// and -timeout options.
+func openLive(iface string) (*pcap.Handle, error) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = pcap.BlockForever
+	}
+	return pcap.OpenLive(iface, int32(opts.Snaplen), opts.Promisc, timeout)
+}
+
+// liveHandle is a live capture, closed once done with
+type liveHandle interface {
+	packetHandle
+	Close()
+}
+
+// openCapture opens a live capture on the interface with the -capture
+// method, with the BPF filter applied.
+func openCapture(iface string) (liveHandle, error) {
+	if opts.Capture == "afpacket" {
+		return openAFPacket(iface)
+	}
+	handle, err := openLive(iface)
+	if err != nil {
+		return nil, err
+	}
+	setBPFFilter(handle)
+	return handle, nil
+}
+
+// setBPFFilter applies the BPF filter to the handle.
+func setBPFFilter(handle *pcap.Handle) {
+	if opts.BPFFilter != "" {
+		Info("Using BPF filter %q\n", opts.BPFFilter)
+		if err := handle.SetBPFFilter(opts.BPFFilter); err != nil {
+			log.Fatal("BPF filter error:", err)
+		}
+	}
+}
+
+// capturedPacket is a packet along with the interface it was captured on,
+// and the file it was read from
+type capturedPacket struct {
+	gopacket.Packet
+	iface  string
+	source string
+}
+
+// capture sends all packets read from the handle to out, until the handle is
+// exhausted or closed, or stop is closed.
+func capture(handle packetHandle, iface string, file string, out chan<- capturedPacket, stop <-chan struct{}, w *sync.WaitGroup) {
+	if w != nil {
+		defer w.Done()
+	}
 	source := gopacket.NewPacketSource(handle, handle.LinkType())
 	source.Lazy = false
 	source.NoCopy = true
-	Info("Starting to read packets\n")
-	count := 0
-	bytes := int64(0)
-	defragger := ip4defrag.NewIPv4Defragmenter()
+	for packet := range source.Packets() {
+		select {
+		case out <- capturedPacket{Packet: packet, iface: iface, source: file}:
+		case <-stop:
+			return
+		}
+	}
+}
 
-	streamFactory := &tcpStreamFactory{}
-	streamPool := reassembly.NewStreamPool(streamFactory)
-	assembler := reassembly.NewAssembler(streamPool)
-	assembler.AssemblerOptions = assemblerOptions
+// packetHandle is what packets are captured from: a pcap handle, or any
+// other reader of a capture such as pcapgo.
+type packetHandle interface {
+	gopacket.PacketDataSource
+	LinkType() layers.LinkType
+}
 
-	// Signal chan for system signals
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt)
+// pipeline is the assembler and the output workers shared by all the
+// inputs of a run.
+type pipeline struct {
+	assembler     *reassembly.Assembler
+	streamFactory *tcpStreamFactory
+	defragger     *defragmenter
+	workers       sync.WaitGroup
+	stop          chan struct{} // closed once reading stops before the end
+	inputs        int           // captures read so far
+	count         int           // packets read
+	bytes         int64         // bytes read
+}
+
+// newPipeline sets up the assembler and the job queue, and starts the output
+// workers.
+func newPipeline() *pipeline {
+	p := &pipeline{
+		streamFactory: &tcpStreamFactory{},
+		defragger:     newDefragmenter(),
+		stop:          make(chan struct{}),
+	}
+	p.assembler = reassembly.NewAssembler(reassembly.NewStreamPool(p.streamFactory))
+	p.assembler.AssemblerOptions = assemblerOptions
 
 	// Job chan to hold Completed sessions to write
-	jobQ = make(chan SSHSession, 4096)
-	cancelC := make(chan string)
+	jobQ = make(chan SSHSession, opts.QueueSize)
+
+	// Closed by the worker once -limit sessions have been written
+	limitC = make(chan struct{})
+
+	if opts.Aggregate {
+		aggregated = newAggregate()
+	}
 
 	// We start a worker to send the processed connection the outside world
-	var w sync.WaitGroup
-	w.Add(1)
-	go processCompletedSession(cancelC, jobQ, &w)
+	// With -sort a single worker collects all sessions
+	workers := opts.OutputWorkers
+	if opts.Sort || workers < 1 {
+		workers = 1
+	}
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go processCompletedSession(jobQ, &p.workers)
+	}
+	return p
+}
+
+// processPcap reads all packets of a capture file through the pipeline. The
+// streams of earlier captures are carried over.
+// Returns the number of packets and bytes read.
+func (p *pipeline) processPcap(handle packetHandle, iface string, file string, signalChan <-chan os.Signal) (int, int64) {
+	packets := make(chan capturedPacket, 1024)
+	go func() {
+		capture(handle, iface, file, packets, p.stop, nil)
+		close(packets)
+	}()
+	c, b := p.readPackets(packets, signalChan, p.inputs > 0)
+	p.inputs++
+	return c, b
+}
+
+// halt stops the reading of packets, and the captures sending them.
+func (p *pipeline) halt() {
+	done = true
+	close(p.stop)
+}
+
+// close flushes the sessions left in the assembler, and waits for the
+// workers to have written all queued sessions.
+func (p *pipeline) close() {
+	closed := p.assembler.FlushAll()
+	logFlush("final", time.Time{}, 0, closed)
+	p.streamFactory.WaitGoRoutines()
+
+	// All systems gone
+	// We close the processing queue
+	close(jobQ)
+	p.workers.Wait()
+	if opts.Aggregate {
+		if err := writeAggregate(); err != nil {
+			Error("Aggregate", "Unable to write the aggregate: %s\n", err)
+		}
+	}
+	if err := closeOutFile(); err != nil {
+		Error("OutputFile", "Unable to close %s: %s\n", opts.OutputFile, err)
+	}
+	if unixOut != nil {
+		unixOut.close()
+		unixOut = nil
+	}
+}
+
+// readPackets feeds all packets into the assembler, until the channel is
+// closed or we are told to stop. If carryOver is set the
+// assembler already holds streams from a previous capture file; those which
+// have been inactive for CloseTimeout when this capture starts are closed, so
+// that only captures overlapping in time share sessions.
+// Returns the number of packets and bytes read.
+func (p *pipeline) readPackets(packets <-chan capturedPacket, signalChan <-chan os.Signal, carryOver bool) (int, int64) {
+	Info("Starting to read packets\n")
+	count := 0
+	bytes := int64(0)
+
+	// With -flush-on-idle streams are flushed on a timer as well, which
+	// keeps firing when packets are few. The capture time it takes as
+	// reference is the one of the last packet, advanced by the time since.
+	var idle <-chan time.Time
+	var lastTS, lastRead time.Time
+	if opts.IdleTimeout > 0 {
+		ticker := time.NewTicker(opts.IdleTimeout / 2)
+		defer ticker.Stop()
+		idle = ticker.C
+	}
 
 	/*	var eth layers.Ethernet
 		var ip4 layers.IPv4
@@ -429,60 +1159,63 @@ func main() {
 		parser := gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &eth, &ip4, &ip6)
 		decoded := []gopacket.LayerType{}*/
 
-	for packet := range source.Packets() {
+	for !done {
+		var cp capturedPacket
+		var more bool
+		select {
+		case cp, more = <-packets:
+		case sig := <-signalChan:
+			// Stop reading, the sessions still in the assembler and the
+			// queue are written on the way out.
+			fmt.Fprintf(os.Stderr, "\nCaught %s: draining\n", sig)
+			p.halt()
+			continue
+		case <-limitC:
+			Info("Limit of %d sessions reached: stopping\n", opts.Limit)
+			p.halt()
+			continue
+		case now := <-idle:
+			if !lastTS.IsZero() {
+				p.flushIdle(lastTS.Add(now.Sub(lastRead)))
+			}
+			continue
+		}
+		if !more {
+			break
+		}
+		packet := cp.Packet
 		count++
+		p.count++
+		metrics.packets.Inc()
+		health.lastPacket.Store(time.Now().UnixNano())
+		if carryOver && count == 1 {
+			ref := packet.Metadata().CaptureInfo.Timestamp
+			flushed, closed := p.assembler.FlushCloseOlderThan(ref.Add(-opts.CloseTimeout))
+			logFlush("carryover", ref, flushed, closed)
+			p.streamFactory.reapStuck(ref)
+		}
+		if idle != nil {
+			lastTS, lastRead = packet.Metadata().CaptureInfo.Timestamp, time.Now()
+		}
 		Debug("PACKET #%d\n", count)
 		data := packet.Data()
 		bytes += int64(len(data))
-		if *hexdumppkt {
+		p.bytes += int64(len(data))
+		if opts.HexDump {
 			Debug("Packet content (%d/0x%x)\n%s\n", len(data), len(data), hex.Dump(data))
 		}
 
-		// defrag the IPv4 packet if required
-		if !*nodefrag {
-			ip4Layer := packet.Layer(layers.LayerTypeIPv4)
-			if ip4Layer == nil {
-				continue
-			}
-			ip4 := ip4Layer.(*layers.IPv4)
-			l := ip4.Length
-			newip4, err := defragger.DefragIPv4(ip4)
-			if err != nil {
-				log.Fatalln("Error while de-fragmenting", err)
-			} else if newip4 == nil {
-				Debug("Fragment...\n")
-				continue // ip packet fragment, we don't have whole packet yet.
-			}
-			if newip4.Length != l {
-				Debug("Decoding re-assembled packet: %s\n", newip4.NextLayerType())
-				pb, ok := packet.(gopacket.PacketBuilder)
-				if !ok {
-					panic("Not a PacketBuilder")
-				}
-				nextDecoder := newip4.NextLayerType()
-				nextDecoder.Decode(newip4.Payload, pb)
-			}
-		}
+		assemblePacket(packet, cp.iface, cp.source, p.assembler, p.defragger)
 
-		tcp := packet.Layer(layers.LayerTypeTCP)
-		if tcp != nil {
-			tcp := tcp.(*layers.TCP)
-			if *checksum {
-				err := tcp.SetNetworkLayerForChecksum(packet.NetworkLayer())
-				if err != nil {
-					log.Fatalf("Failed to set network layer for checksum: %s\n", err)
-				}
-			}
-			c := Context{
-				CaptureInfo: packet.Metadata().CaptureInfo,
-			}
-			stats.totalsz += len(tcp.Payload)
-			assembler.AssembleWithContext(packet.NetworkLayer().NetworkFlow(), tcp, &c)
-		}
-		if count%*statsevery == 0 {
+		if count%opts.StatsEvery == 0 {
 			ref := packet.Metadata().CaptureInfo.Timestamp
-			flushed, closed := assembler.FlushWithOptions(reassembly.FlushOptions{T: ref.Add(-timeout), TC: ref.Add(-closeTimeout)})
-			fmt.Printf(" -- forced flush: %d flushed, %d closed (%s)\n", flushed, closed, ref)
+			flushed, closed := p.assembler.FlushWithOptions(reassembly.FlushOptions{T: ref.Add(-opts.FlushTimeout), TC: ref.Add(-opts.CloseTimeout)})
+			logFlush("periodic", ref, flushed, closed)
+			p.streamFactory.reapStuck(ref)
+			p.streamFactory.expireOld(ref)
+			if n := p.defragger.discardOlderThan(ref.Add(-ip6ReassemblyTimeout)); n > 0 {
+				Debug("Discarded %d incomplete fragmented packets\n", n)
+			}
 		}
 
 		/*
@@ -498,133 +1231,394 @@ func main() {
 					//				fmt.Println("    IP4 ", ip4.SrcIP, ip4.DstIP, len(data))
 
 					//ref := packet.Metadata().CaptureInfo.Timestamp
-					//flushed, closed := assembler.FlushWithOptions(reassembly.FlushOptions{T: ref.Add(time.Minute * 30), TC: ref.Add(time.Minute * 5)})
+					//flushed, closed := p.assembler.FlushWithOptions(reassembly.FlushOptions{T: ref.Add(time.Minute * 30), TC: ref.Add(time.Minute * 5)})
 					//Debug("Forced flush: %d flushed, %d closed (%s)", flushed, closed, ref)
 				}
 			}
 		*/
 
-		select {
-		case <-signalChan:
-			fmt.Fprintf(os.Stderr, "\nCaught SIGINT: aborting\n")
-			if outFile != nil {
-				outFile.Close()
+	}
+
+	return count, bytes
+}
+
+// flushIdle flushes and closes the streams without packets for
+// -flush-on-idle at ref, writing their sessions.
+func (p *pipeline) flushIdle(ref time.Time) {
+	limit := ref.Add(-opts.IdleTimeout)
+	flushed, closed := p.assembler.FlushWithOptions(reassembly.FlushOptions{T: limit, TC: limit})
+	logFlush("idle", ref, flushed, closed)
+}
+
+// assemblePacket defragments the packet if required and feeds its TCP layer
+// into the assembler, tagged with the interface it was captured on and the
+// file it was read from.
+func assemblePacket(packet gopacket.Packet, iface string, source string, assembler *reassembly.Assembler, defragger *defragmenter) {
+	var tunnel *Tunnel
+	if opts.Decap != "" {
+		packet, tunnel = decapsulate(packet)
+	}
+
+	// defrag the IPv4 packet if required. The extension headers of IPv6
+	// are decoded as layers of their own, the TCP layer following them
+	// unless the packet is a fragment.
+	if ip4, ok := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok && !opts.NoDefrag {
+		l := ip4.Length
+		newip4, err := defragger.ip4.DefragIPv4(ip4)
+		if err != nil {
+			log.Fatalln("Error while de-fragmenting", err)
+		} else if newip4 == nil {
+			Debug("Fragment...\n")
+			return // ip packet fragment, we don't have whole packet yet.
+		}
+		if newip4.Length != l {
+			Debug("Decoding re-assembled packet: %s\n", newip4.NextLayerType())
+			pb, ok := packet.(gopacket.PacketBuilder)
+			if !ok {
+				panic("Not a PacketBuilder")
 			}
-			cancelC <- "stop"
-			done = true
-			break
-		default:
-			// NOP: continue
+			nextDecoder := newip4.NextLayerType()
+			nextDecoder.Decode(newip4.Payload, pb)
 		}
-		if done {
-			break
+	}
+	if frag, ok := packet.Layer(layers.LayerTypeIPv6Fragment).(*layers.IPv6Fragment); ok && !opts.NoDefrag {
+		ip6, _ := packet.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+		if ip6 == nil {
+			return
+		}
+		payload, next, err := defragger.ip6.defragIPv6(ip6, frag, packet.Metadata().CaptureInfo.Timestamp)
+		if err != nil {
+			Error("IPv6Defrag", "Packet dropped, unable to de-fragment: %s\n", err)
+			return
+		} else if payload == nil {
+			Debug("Fragment...\n")
+			return
 		}
+		Debug("Decoding re-assembled packet: %s\n", next.LayerType())
+		pb, ok := packet.(gopacket.PacketBuilder)
+		if !ok {
+			panic("Not a PacketBuilder")
+		}
+		next.LayerType().Decode(payload, pb)
 	}
 
-	Info(fmt.Sprintf("%d Bytes read.\n", bytes))
-
-	assembler.FlushAll()
-	streamFactory.WaitGoRoutines()
+	tcp := packet.Layer(layers.LayerTypeTCP)
+	if tcp != nil {
+		tcp := tcp.(*layers.TCP)
+		if opts.Checksum {
+			err := tcp.SetNetworkLayerForChecksum(packet.NetworkLayer())
+			if err != nil {
+				log.Fatalf("Failed to set network layer for checksum: %s\n", err)
+			}
+		}
+		c := Context{
+			CaptureInfo: packet.Metadata().CaptureInfo,
+			Iface:       iface,
+			Source:      source,
+			Tunnel:      tunnel,
+		}
+		if eth, ok := packet.Layer(layers.LayerTypeEthernet).(*layers.Ethernet); ok {
+			c.SrcMAC = eth.SrcMAC
+		}
+		if dot1q, ok := packet.Layer(layers.LayerTypeDot1Q).(*layers.Dot1Q); ok {
+			c.VLAN = dot1q.VLANIdentifier
+		}
+		stats.totalsz += len(tcp.Payload)
+		assembler.AssembleWithContext(packet.NetworkLayer().NetworkFlow(), tcp, &c)
+	}
+}
 
-	fmt.Printf("TCP stats:\n")
-	fmt.Printf(" missed bytes:\t\t%d\n", stats.missedBytes)
-	fmt.Printf(" total packets:\t\t%d\n", stats.pkt)
-	fmt.Printf(" rejected FSM:\t\t%d\n", stats.rejectFsm)
-	fmt.Printf(" rejected Options:\t%d\n", stats.rejectOpt)
-	fmt.Printf(" reassembled bytes:\t%d\n", stats.sz)
-	fmt.Printf(" total TCP bytes:\t%d\n", stats.totalsz)
-	fmt.Printf(" conn rejected FSM:\t%d\n", stats.rejectConnFsm)
-	fmt.Printf(" reassembled chunks:\t%d\n", stats.reassembled)
-	fmt.Printf(" out-of-order packets:\t%d\n", stats.outOfOrderPackets)
-	fmt.Printf(" out-of-order bytes:\t%d\n", stats.outOfOrderBytes)
-	fmt.Printf(" biggest-chunk packets:\t%d\n", stats.biggestChunkPackets)
-	fmt.Printf(" biggest-chunk bytes:\t%d\n", stats.biggestChunkBytes)
-	fmt.Printf(" overlap packets:\t%d\n", stats.overlapPackets)
-	fmt.Printf(" overlap bytes:\t\t%d\n", stats.overlapBytes)
-	fmt.Printf("Errors: %d\n", errors)
-	for e, _ := range errorsMap {
-		fmt.Printf(" %s:\t\t%d\n", e, errorsMap[e])
+// emit queues the session of the stream for output, unless it already was:
+// whichever of the end of the stream, or the reaping or expiry on a flush
+// comes first emits the session, the others do nothing.
+// It reports if the session was emitted by this call, even if it was then
+// dropped on a full queue.
+func (t *tcpStream) emit() bool {
+	t.Lock()
+	defer t.Unlock()
+	if t.queued {
+		return false
 	}
+	t.queued = true
+	t.queueSession()
+	return true
+}
 
-	// All systems gone
-	// We close the processing queue
-	close(jobQ)
-	w.Wait()
+// emitPartial emits the session of a stream which ended, or was given up
+// on, marking it partial if its handshake did not complete.
+func (t *tcpStream) emitPartial() bool {
+	if !t.sshSession.HandshakeComplete {
+		t.sshSession.Partial = true
+	}
+	return t.emit()
 }
 
-// queueSession tries to enqueue the session for output
-// returns true if it succeeded or false if it failed to publish the
+// queueSession tries to enqueue the session for output. If the queue is full
+// it waits up to -queue-timeout for the writer to catch up, which slows down
+// reassembly rather than losing the session.
+// Returns true if it succeeded or false if the session was dropped.
+// Sessions are queued through emit.
 func (t *tcpStream) queueSession() bool {
-	t.queued = true
+	t.sshSession.State = t.sshSession.ConnectionState()
+	t.sshSession.BannersOnly = opts.BannersOnly
+	t.sshSession.ClientOnly = opts.ClientOnly
+	// Sessions without a server banner have not had their network set yet
+	if t.sshSession.ClientIP == "" {
+		cip, sip, cp, sp := getIPPorts(t)
+		t.sshSession.SetNetwork(cip, sip, cp, sp)
+	}
+	t.sshSession.FirstSeen = t.firstSeen
+	t.sshSession.SetTimestamp(t.sshSession.SourceTimestamp(opts.TimestampSource))
+	t.sshSession.UID = sessionUID(t.sshSession, t.sshSession.FirstSeen)
+	t.sshSession.EvasionPort = isEvasionPort(t.sshSession)
+	checkSSH1(&t.sshSession)
+	identify(&t.sshSession)
+	checkImplementation(&t.sshSession)
+	checkWeakAlgorithms(&t.sshSession)
+	checkPolicy(&t.sshSession)
 	select {
 	case jobQ <- t.sshSession:
+		stats.sessionsQueued++
 		return true
 	default:
-		return false
 	}
+
+	timer := time.NewTimer(opts.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case jobQ <- t.sshSession:
+		stats.sessionsQueued++
+		return true
+	case <-timer.C:
+	}
+	stats.sessionsDropped++
+	metrics.dropped.Inc()
+	Error("QueueFull", "%s: Session dropped, output queue is full\n", t.ident)
+	return false
 }
 
-func processCompletedSession(cancelC <-chan string, jobQ <-chan SSHSession, w *sync.WaitGroup) {
+func processCompletedSession(jobQ <-chan SSHSession, w *sync.WaitGroup) {
 	defer func() {
 		w.Done()
 
 	}()
-	for {
-		select {
-		case m, more := <-jobQ:
-			if more {
-				output(m)
-			} else {
-				return
+	e := newSessionEncoder()
+	write := func(m SSHSession) {
+		enrich(&m)
+		if output(m, e) {
+			metrics.sessions.Inc()
+		}
+	}
+
+	// With -sort nothing is written until all sessions have been queued
+	var sorted []SSHSession
+	for m := range jobQ {
+		if opts.Sort {
+			sorted = append(sorted, m)
+			continue
+		}
+		write(m)
+	}
+	if opts.Sort {
+		sortSessions(sorted)
+		for _, m := range sorted {
+			write(m)
+		}
+	}
+}
+
+// sortSessions sorts sessions by timestamp, then by stream.
+func sortSessions(sessions []SSHSession) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if !sessions[i].Timestamp.Equal(sessions[j].Timestamp) {
+			return sessions[i].Timestamp.Before(sessions[j].Timestamp)
+		}
+		return sessions[i].Stream < sessions[j].Stream
+	})
+}
+
+// closeOutFile closes the file of -f, ending the array of -json-array. It
+// is called once the workers are done.
+func closeOutFile() error {
+	if outFile == nil {
+		return nil
+	}
+	if opts.JSONArray {
+		if _, err := fmt.Fprint(outFile, "\n]\n"); err != nil {
+			outFile.Close()
+			outFile = nil
+			return err
+		}
+	}
+	err := outFile.Close()
+	outFile = nil
+	return err
+}
+
+// sessionEncoder marshals the sessions of an output worker into a buffer
+// reused from one session to the next. Sessions are marshalled before taking
+// the output lock, so the encoder does not write to the destination itself.
+type sessionEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+func newSessionEncoder() *sessionEncoder {
+	e := &sessionEncoder{}
+	e.enc = json.NewEncoder(&e.buf)
+	if opts.JSONIndent && opts.UnixSocket == "" && opts.Schema != SchemaHASSH {
+		e.enc.SetIndent("", "    ")
+	}
+	return e
+}
+
+// encode marshals the session, returning it as a line ending with a newline,
+// or as a length-delimited protobuf record with -proto. With -schema hassh it
+// is the lines of the records of hassh.py, none without a KEXINIT. It is
+// valid until the next call.
+func (e *sessionEncoder) encode(t SSHSession) ([]byte, error) {
+	e.buf.Reset()
+	if opts.Schema == SchemaHASSH {
+		for _, r := range hasshRecords(t) {
+			if err := e.enc.Encode(r); err != nil {
+				return nil, err
 			}
-		case <-cancelC:
-			return
 		}
+		return e.buf.Bytes(), nil
+	}
+	if opts.Proto {
+		if _, err := protodelim.MarshalTo(&e.buf, toProto(t)); err != nil {
+			return nil, err
+		}
+		return e.buf.Bytes(), nil
 	}
+	if err := e.enc.Encode(t); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
 }
 
-func output(t SSHSession) {
-	var jsonRecord []byte
-	if *jsonIndent {
-		jsonRecord, _ = json.MarshalIndent(t, "", "    ")
-	} else {
-		jsonRecord, _ = json.Marshal(t)
+// output writes the session, unless it is filtered by the HASSH lists or
+// -limit complete sessions have been written. It reports if the session was
+// written.
+// It is called by every output worker with its own encoder: sessions are
+// marshalled concurrently, then written one at a time.
+func output(t SSHSession, e *sessionEncoder) bool {
+	outputMutex.Lock()
+	pass := filterHASSH(t) && filterCIDR(t) && sample(t)
+	if pass && opts.Aggregate {
+		// Aggregated sessions count towards -limit as written ones do
+		if pass = !limitReached(); pass {
+			aggregated.add(t)
+			countWritten(t)
+		}
+	}
+	outputMutex.Unlock()
+	if !pass || opts.Aggregate {
+		return pass
 	}
 
-	// If an output folder was specified for json files
-	if *outJSON != "" {
-		if _, err := os.Stat(fmt.Sprintf("./%s", *outJSON)); !os.IsNotExist(err) {
+	line, err := e.encode(t)
+	if err != nil {
+		Error("Marshal", "Session dropped, unable to marshal: %s\n", err)
+		return false
+	}
+	if len(line) == 0 {
+		return false
+	}
+	record := line
+	if !opts.Proto {
+		record = line[:len(line)-1]
+	}
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	// Once the limit is reached the rest of the queue is drained without
+	// being written.
+	if limitReached() {
+		return false
+	}
+
+	if opts.UnixSocket != "" {
+		if unixOut == nil {
+			unixOut = &unixSink{path: opts.UnixSocket}
+		}
+		if err := unixOut.write(record); err != nil {
+			stats.socketDropped++
+			Error("UnixSocket", "Session dropped, unable to write to %s: %s\n", opts.UnixSocket, err)
+			return false
+		}
+		// If an output folder was specified for json files
+	} else if opts.OutputDir != "" {
+		if _, err := os.Stat(opts.OutputDir); !os.IsNotExist(err) {
 			var err error
-			if len(*outFilename) < 1 {
-				err = ioutil.WriteFile(fmt.Sprintf("./%s/%s.json", *outJSON, t.Timestamp.Format(time.RFC3339)), jsonRecord, 0644)
+			if len(opts.OutputFile) < 1 {
+				var fn string
+				var f *os.File
+				if fn, err = sessionFilename(t); err == nil {
+					if f, err = createUnique(opts.OutputDir, fn, sessionExt()); err == nil {
+						_, err = f.Write(record)
+						f.Close()
+					}
+				}
 			} else {
 
+				// With -json-array sessions are elements of an array,
+				// opened with the first one and closed by closeOutFile
+				var sep string
+				if opts.JSONArray {
+					sep = ",\n"
+				}
+
 				// First time, set the file descriptor
 				if outFile == nil {
-					filename := fmt.Sprintf("./%s/%s", *outJSON, *outFilename)
-					outFile, err = os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+					filename := filepath.Join(opts.OutputDir, opts.OutputFile)
+					flag := os.O_CREATE | os.O_APPEND | os.O_WRONLY
+					if opts.JSONArray {
+						flag, sep = os.O_CREATE|os.O_TRUNC|os.O_WRONLY, "[\n"
+					}
+					outFile, err = os.OpenFile(filename, flag, 0644)
 					if err != nil {
 						panic("Could not open file to write")
 					}
 				}
 
-				_, err = fmt.Fprintf(outFile, "%s", jsonRecord)
+				_, err = fmt.Fprintf(outFile, "%s%s", sep, record)
 			}
 			if err != nil {
 				panic("Could not write to file.")
 			}
 		} else {
-			panic(fmt.Sprintf("./%s does not exist", *outJSON))
+			panic(fmt.Sprintf("%s does not exist", opts.OutputDir))
 		}
 		// If not folder specidied, we output to stdout
 	} else {
-		r := bytes.NewReader(jsonRecord)
-		_, err := io.Copy(os.Stdout, r)
-		if err != nil {
+		if _, err := os.Stdout.Write(line); err != nil {
 			panic("Could not write to stdout.")
 		}
-		fmt.Println()
 	}
 
 	//	Debug(t.String())
+	countWritten(t)
+	return true
+}
+
+// limitReached reports if -limit complete sessions have been written. The
+// caller holds outputMutex.
+func limitReached() bool {
+	return opts.Limit > 0 && written >= opts.Limit
+}
+
+// countWritten counts a written session towards -limit, unless it is
+// partial, and ends the run once -limit sessions have been. The caller holds
+// outputMutex.
+func countWritten(t SSHSession) {
+	if t.Partial {
+		return
+	}
+	written++
+	if opts.Limit > 0 && written == opts.Limit {
+		close(limitC)
+	}
 }
@@ -0,0 +1,2037 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/google/gopacket/reassembly"
+	"github.com/kjelle/gohassh"
+	"github.com/kjelle/gohassh/essh"
+	"github.com/kjelle/gohassh/examples/hassh/sessionpb"
+	"github.com/kjelle/gohassh/options"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+// OpenSSH_7.4 client and server KEXINIT records
+var testClientKexinit = sampleClientKexinit
+var testServerKexinit = sampleServerKexinit
+
+// Curve25519 KEXDH_INIT and ECDSA KEXDH_REPLY records
+var testClientKexDHInit = decodeString(`0000002c061e00000020b5f6d3a1c7e7e3b1f0a8d0b6c9f4e2a1d3c5b7a9e1f2d4c6b8a0e2f4d6c8b0a2000000000000`)
+var testServerKexDHReply = decodeString(`000001040a1f000000680000001365636473612d736861322d6e69737470323536000000086e697374703235360000004104c1476fc7fc13c09065726fd48c5fca0dfc69810167b74792dbbddaa5edd56dd313e7b3d8f6c9b75f484ed86b1f6ce67e04f4edea2fc9199dd6ed2f691bc7935f000000208258bdb8b20101673f64bb56b577dbd6c25da25b2f3cdaf5cfd7408c3fadc80c000000630000001365636473612d736861322d6e69737470323536000000480000002016b3135f33a46159757c10740822579b89fbcc1f4365f2461daf151bfd366aed00000020215ad1e25c8d527ba3607af9c829db971a06f45771bbb25ad0cc3e94a1b6b5640000000000000000000000`)
+
+// SSH_MSG_NEWKEYS, the same from both sides
+var testNewKeys = decodeString(`0000000c0a1500000000000000000000`)
+
+var testClientData = append([]byte("SSH-2.0-OpenSSH_7.4\r\n"), testClientKexinit...)
+var testServerData = append([]byte("SSH-2.0-OpenSSH_7.4\r\n"), testServerKexinit...)
+
+type testSegment struct {
+	client     bool // sent by the client
+	syn        bool
+	retransmit bool // resend the previous segment in this direction
+	data       []byte
+}
+
+var testStreams = map[string]struct {
+	segments    []testSegment
+	hassh       string
+	hasshserver string
+	complete    bool // decoded as far as the options ask for, see complete
+	anomalies   Anomalies
+	rekeys      int    // client KEXINITs after the first
+	handshake   bool   // both NEWKEYS seen
+	clientOnly  bool   // run with -client-only
+	state       string // ConnComplete if empty
+}{
+	"Full handshake": {
+		segments: []testSegment{
+			{client: true, syn: true},
+			{client: false, syn: true},
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+	},
+	"Missing TCP handshake": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+	},
+	"Missing TCP handshake with key exchange": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+			{client: true, data: testClientKexDHInit},
+			{client: false, data: concat(testServerKexDHReply, testNewKeys)},
+			{client: true, data: testNewKeys},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		complete:    true,
+		handshake:   true,
+	},
+	"Key exchange without NEWKEYS": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+			{client: true, data: testClientKexDHInit},
+			{client: false, data: testServerKexDHReply},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+	},
+	"Encrypted after NEWKEYS": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+			{client: true, data: testClientKexDHInit},
+			{client: false, data: concat(testServerKexDHReply, testNewKeys)},
+			// Not a rekey, it would be encrypted
+			{client: true, data: concat(testNewKeys, testClientKexinit)},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		complete:    true,
+		handshake:   true,
+	},
+	"Retransmitted after NEWKEYS": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+			{client: true, data: testClientKexDHInit},
+			{client: false, data: concat(testServerKexDHReply, testNewKeys)},
+			{client: true, data: testNewKeys},
+			{client: true, retransmit: true, data: testNewKeys},
+			{client: true, data: testClientKexinit}, // reports the overlap
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		complete:    true,
+		handshake:   true,
+		anomalies:   Anomalies{OverlapBytes: 16, OverlapPackets: 1},
+	},
+	"Missing banners": {
+		segments: []testSegment{
+			{client: true, data: testClientKexinit},
+			{client: false, data: testServerKexinit},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		state:       ConnPartial,
+	},
+	"Client KEXINIT before server banner": {
+		segments: []testSegment{
+			{client: true, data: []byte("SSH-2.0-OpenSSH_7.4\r\n")},
+			{client: true, data: testClientKexinit},
+			{client: false, data: testServerData},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+	},
+	"Key exchange in the same segments as KEXINIT": {
+		segments: []testSegment{
+			{client: true, data: concat(testClientData, testClientKexDHInit)},
+			{client: false, data: concat(testServerData, testServerKexDHReply, testNewKeys)},
+			{client: true, data: testNewKeys},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		complete:    true,
+		handshake:   true,
+	},
+	"KEXINIT split over segments": {
+		segments: []testSegment{
+			{client: true, data: testClientData[:100]},
+			{client: true, data: concat(testClientData[100:], testClientKexDHInit[:10])},
+			{client: true, data: testClientKexDHInit[10:]},
+			{client: false, data: testServerData},
+			{client: false, data: concat(testServerKexDHReply, testNewKeys)},
+			{client: true, data: testNewKeys},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		complete:    true,
+		handshake:   true,
+	},
+	"Key exchange a byte at a time": {
+		segments: byteSegments([]testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+			{client: true, data: testClientKexDHInit},
+			{client: false, data: concat(testServerKexDHReply, testNewKeys)},
+			{client: true, data: testNewKeys},
+		}),
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		complete:    true,
+		handshake:   true,
+	},
+	"Client only": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+		},
+		hassh:      "ec9ea89c70f5fc71cf61061bff5e4740",
+		complete:   true,
+		clientOnly: true,
+		state:      ConnClientOnly,
+	},
+	"Retransmitted segment": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: true, retransmit: true, data: testClientData},
+			{client: false, data: testServerData},
+			{client: true, data: testClientKexDHInit}, // reports the overlap
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		anomalies:   Anomalies{OverlapBytes: 1517, OverlapPackets: 1},
+	},
+	"Rekey": {
+		segments: []testSegment{
+			{client: true, data: testClientData},
+			{client: false, data: testServerData},
+			{client: true, data: testClientKexinit},
+		},
+		hassh:       "ec9ea89c70f5fc71cf61061bff5e4740",
+		hasshserver: "6832f1ce43d4397c2c0a3e2f8c94334e",
+		rekeys:      1,
+	},
+}
+
+// byteSegments splits the data of the segments into segments of a single
+// byte each.
+func byteSegments(segments []testSegment) []testSegment {
+	var split []testSegment
+	for _, s := range segments {
+		for i := range s.data {
+			split = append(split, testSegment{client: s.client, data: s.data[i : i+1]})
+		}
+	}
+	return split
+}
+
+func TestStreams(t *testing.T) {
+	for k, test := range testStreams {
+		t.Run(k, func(t *testing.T) {
+			t.Log(k)
+			opts = options.DefaultOptions()
+			opts.ClientOnly = test.clientOnly
+			queued, closed := assembleSegments(t, test.segments)
+			sessions := append(queued, closed...)
+			if len(sessions) != 1 {
+				t.Fatalf("failed testcase '%s', expected 1 session, got %d", k, len(sessions))
+			}
+			// Only -client-only sessions are queued before the stream is closed
+			if test.clientOnly != (len(queued) == 1) {
+				t.Errorf("failed testcase '%s', expected session to be queued before close: %t", k, test.clientOnly)
+			}
+			s := sessions[0]
+			if s.Client.HASSH == nil || s.Client.Hassh != test.hassh {
+				t.Errorf("failed testcase '%s', mismatch on hassh\n\nexpected:\n%s\ngot: \n%+v\n", k, test.hassh, s.Client.HASSH)
+			}
+			if test.clientOnly {
+				if s.State != ConnClientOnly || !s.ClientOnly || s.Server.HASSHServer != nil || s.Server.ESSHBannerRecord != nil {
+					t.Errorf("failed testcase '%s', expected client only session, got %+v", k, s)
+				}
+			} else if s.Server.HASSHServer == nil || s.Server.HasshServer != test.hasshserver {
+				t.Errorf("failed testcase '%s', mismatch on hasshServer\n\nexpected:\n%s\ngot: \n%+v\n", k, test.hasshserver, s.Server.HASSHServer)
+			}
+			if partial := !test.complete && !test.handshake; s.Partial != partial {
+				t.Errorf("failed testcase '%s', mismatch on Partial\n\nexpected:\n%t\ngot: \n%t\n", k, partial, s.Partial)
+			}
+			if s.HandshakeComplete != test.handshake {
+				t.Errorf("failed testcase '%s', mismatch on HandshakeComplete\n\nexpected:\n%t\ngot: \n%t\n", k, test.handshake, s.HandshakeComplete)
+			}
+			if len(s.Client.Rekeys) != test.rekeys {
+				t.Errorf("failed testcase '%s', mismatch on rekeys\n\nexpected:\n%d\ngot: \n%d\n", k, test.rekeys, len(s.Client.Rekeys))
+			}
+			if s.Anomalies != test.anomalies {
+				t.Errorf("failed testcase '%s', mismatch on Anomalies\n\nexpected:\n%+v\ngot: \n%+v\n", k, test.anomalies, s.Anomalies)
+			}
+			state := test.state
+			if state == "" {
+				state = ConnComplete
+			}
+			if s.State != state {
+				t.Errorf("failed testcase '%s', mismatch on State\n\nexpected:\n%s\ngot: \n%s\n", k, state, s.State)
+			}
+			seen := [4]bool{s.Client.ESSHBannerRecord != nil, s.Server.ESSHBannerRecord != nil, s.Client.HASSH != nil, s.Server.HASSHServer != nil}
+			if flags := [4]bool{s.SeenClientBanner, s.SeenServerBanner, s.SeenClientKex, s.SeenServerKex}; flags != seen {
+				t.Errorf("failed testcase '%s', mismatch on the banners and kex seen\n\nexpected:\n%v\ngot: \n%v\n", k, seen, flags)
+			}
+			if s.ClientMAC != "00:00:00:00:00:01" {
+				t.Errorf("failed testcase '%s', mismatch on ClientMAC\n\nexpected:\n%s\ngot: \n%s\n", k, "00:00:00:00:00:01", s.ClientMAC)
+			}
+		})
+	}
+}
+
+func TestSelftest(t *testing.T) {
+	opts = options.DefaultOptions()
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	if code := selftest(); code != 0 {
+		t.Errorf("selftest failed with exit code %d", code)
+	}
+}
+
+// assembleSegments sends the segments of a single connection through the
+// assembler and closes it. It returns the sessions queued while assembling
+// and those queued when the stream was closed.
+func assembleSegments(t testing.TB, segments []testSegment) ([]SSHSession, []SSHSession) {
+	p, _ := feedSegments(t, segments)
+	queued := drainQueue()
+	p.assembler.FlushAll()
+	return queued, drainQueue()
+}
+
+// feedSegments sets up the assembler and the job queue of a pipeline, without
+// its output workers, and feeds the segments to it, one millisecond apart.
+// Returns the pipeline and the time of the last segment.
+func feedSegments(t testing.TB, segments []testSegment) (*pipeline, time.Time) {
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	jobQ = make(chan SSHSession, 16)
+
+	p := &pipeline{streamFactory: &tcpStreamFactory{}, defragger: newDefragmenter()}
+	p.assembler = reassembly.NewAssembler(reassembly.NewStreamPool(p.streamFactory))
+
+	ts := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	seq := map[bool]uint32{true: 1000, false: 5000}
+	for _, s := range segments {
+		ts = ts.Add(time.Millisecond)
+		if s.retransmit {
+			seq[s.client] -= uint32(len(s.data))
+		}
+		assemblePacket(testPacket(t, s, seq[s.client], ts), opts.Interface, "", p.assembler, p.defragger)
+		seq[s.client] += uint32(len(s.data))
+		if s.syn {
+			seq[s.client]++
+		}
+	}
+	return p, ts
+}
+
+// drainQueue returns the sessions waiting in jobQ.
+func drainQueue() []SSHSession {
+	var sessions []SSHSession
+	for {
+		select {
+		case s := <-jobQ:
+			sessions = append(sessions, s)
+		default:
+			return sessions
+		}
+	}
+}
+
+// testPacket builds the packet for the segment, see samplePacket.
+func testPacket(t testing.TB, s testSegment, seq uint32, ts time.Time) gopacket.Packet {
+	p, err := samplePacket(s.client, s.syn, seq, s.data, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Decap != "" {
+		return tunnelPacket(t, s.client, p)
+	}
+	return p
+}
+
+// tunnelPacket encapsulates the packet in the tunnel selected with -decap,
+// between 192.0.2.1 on the client side and 192.0.2.2.
+func tunnelPacket(t testing.TB, client bool, p gopacket.Packet) gopacket.Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 1, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 1, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version: 4,
+		TTL:     64,
+		SrcIP:   net.IP{192, 0, 2, 1},
+		DstIP:   net.IP{192, 0, 2, 2},
+	}
+	if !client {
+		ip.SrcIP, ip.DstIP = ip.DstIP, ip.SrcIP
+	}
+	var tunnel []gopacket.SerializableLayer
+	switch opts.Decap {
+	case "vxlan":
+		ip.Protocol = layers.IPProtocolUDP
+		udp := &layers.UDP{SrcPort: 50000, DstPort: 4789}
+		udp.SetNetworkLayerForChecksum(ip)
+		tunnel = []gopacket.SerializableLayer{udp, &layers.VXLAN{ValidIDFlag: true, VNI: 42}, gopacket.Payload(p.Data())}
+	case "gre":
+		// Without the inner Ethernet header
+		ip.Protocol = layers.IPProtocolGRE
+		tunnel = []gopacket.SerializableLayer{&layers.GRE{Protocol: layers.EthernetTypeIPv4}, gopacket.Payload(p.Data()[14:])}
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	so := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, so, append([]gopacket.SerializableLayer{eth, ip}, tunnel...)...); err != nil {
+		t.Fatal(err)
+	}
+	outer := gopacket.NewPacket(buf.Bytes(), layers.LinkTypeEthernet, gopacket.Default)
+	outer.Metadata().CaptureInfo = p.Metadata().CaptureInfo
+	return outer
+}
+
+func TestDecap(t *testing.T) {
+	for _, test := range []struct {
+		decap     string
+		tunnel    Tunnel
+		clientMAC string
+	}{
+		{"vxlan", Tunnel{Type: "vxlan", SrcIP: "192.0.2.1", DestIP: "192.0.2.2", ID: 42}, "00:00:00:00:00:01"},
+		{"gre", Tunnel{Type: "gre", SrcIP: "192.0.2.1", DestIP: "192.0.2.2"}, ""},
+	} {
+		t.Run(test.decap, func(t *testing.T) {
+			opts = options.DefaultOptions()
+			opts.Decap = test.decap
+			_, sessions := assembleSegments(t, testStreams["Missing TCP handshake with key exchange"].segments)
+			if len(sessions) != 1 {
+				t.Fatalf("expected 1 session, got %d", len(sessions))
+			}
+			s := sessions[0]
+			if s.ClientIP != "10.0.0.1" || s.ServerIP != "10.0.0.2" || s.ServerPort != "22" {
+				t.Errorf("mismatch on network, expected the inner addresses, got %s:%s -> %s:%s", s.ClientIP, s.ClientPort, s.ServerIP, s.ServerPort)
+			}
+			if s.Tunnel == nil || *s.Tunnel != test.tunnel {
+				t.Errorf("mismatch on Tunnel\n\nexpected:\n%+v\ngot: \n%+v\n", test.tunnel, s.Tunnel)
+			}
+			if s.ClientMAC != test.clientMAC {
+				t.Errorf("mismatch on ClientMAC\n\nexpected:\n%s\ngot: \n%s\n", test.clientMAC, s.ClientMAC)
+			}
+			if s.Client.HASSH == nil || s.Client.Hassh != "ec9ea89c70f5fc71cf61061bff5e4740" {
+				t.Errorf("mismatch on hassh, got %+v", s.Client.HASSH)
+			}
+		})
+	}
+}
+
+// ipv6Packet builds the packet for the segment like samplePacket, between
+// 2001:db8::1 and 2001:db8::2 over IPv6 with a destination options header.
+func ipv6Packet(t testing.TB, s testSegment, seq uint32, ts time.Time) gopacket.Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv6,
+	}
+	ip := &layers.IPv6{
+		Version:    6,
+		HopLimit:   64,
+		NextHeader: layers.IPProtocolIPv6Destination,
+		SrcIP:      net.ParseIP("2001:db8::1"),
+		DstIP:      net.ParseIP("2001:db8::2"),
+	}
+	dst := &layers.IPv6Destination{Options: []*layers.IPv6DestinationOption{{OptionType: 1, OptionData: make([]byte, 4)}}}
+	dst.NextHeader = layers.IPProtocolTCP
+	tcp := &layers.TCP{
+		SrcPort: 40000,
+		DstPort: 22,
+		Seq:     seq,
+		SYN:     s.syn,
+		ACK:     !s.syn || !s.client,
+		PSH:     len(s.data) > 0,
+		Window:  65535,
+	}
+	if !s.client {
+		eth.SrcMAC, eth.DstMAC = eth.DstMAC, eth.SrcMAC
+		ip.SrcIP, ip.DstIP = ip.DstIP, ip.SrcIP
+		tcp.SrcPort, tcp.DstPort = tcp.DstPort, tcp.SrcPort
+	}
+	tcp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, eth, ip, dst, tcp, gopacket.Payload(s.data)); err != nil {
+		t.Fatal(err)
+	}
+	p := gopacket.NewPacket(buf.Bytes(), layers.LinkTypeEthernet, gopacket.Default)
+	p.Metadata().Timestamp = ts
+	p.Metadata().CaptureLength = len(buf.Bytes())
+	p.Metadata().Length = len(buf.Bytes())
+	return p
+}
+
+// ipv6Fragments splits the packet built by ipv6Packet into fragments of size
+// bytes of the part after its IPv6 header, returned in the reverse order.
+func ipv6Fragments(t testing.TB, p gopacket.Packet, size int) []gopacket.Packet {
+	eth := p.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	ip := *p.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	data := ip.Payload
+	next := ip.NextHeader
+	ip.NextHeader = layers.IPProtocolIPv6Fragment
+
+	var fragments []gopacket.Packet
+	for offset := 0; offset < len(data); offset += size {
+		header := make([]byte, 8)
+		header[0] = byte(next)
+		binary.BigEndian.PutUint16(header[2:], uint16(offset/8)<<3)
+		if offset+size < len(data) {
+			header[3] |= 1 // more fragments
+		}
+		binary.BigEndian.PutUint32(header[4:], 42)
+
+		buf := gopacket.NewSerializeBuffer()
+		payload := append(header, data[offset:min(offset+size, len(data))]...)
+		if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, eth, &ip, gopacket.Payload(payload)); err != nil {
+			t.Fatal(err)
+		}
+		f := gopacket.NewPacket(buf.Bytes(), layers.LinkTypeEthernet, gopacket.Default)
+		f.Metadata().CaptureInfo = p.Metadata().CaptureInfo
+		f.Metadata().CaptureLength = len(buf.Bytes())
+		f.Metadata().Length = len(buf.Bytes())
+		fragments = append([]gopacket.Packet{f}, fragments...)
+	}
+	return fragments
+}
+
+// assembleIPv6 assembles the segments as built by ipv6Packet, fragmenting
+// those longer than fragment bytes if it is not 0. It returns the sessions
+// written.
+func assembleIPv6(t testing.TB, segments []testSegment, fragment int) []SSHSession {
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	jobQ = make(chan SSHSession, 16)
+	assembler := reassembly.NewAssembler(reassembly.NewStreamPool(&tcpStreamFactory{}))
+	defragger := newDefragmenter()
+
+	ts := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	seq := map[bool]uint32{true: 1000, false: 5000}
+	for _, s := range segments {
+		ts = ts.Add(time.Millisecond)
+		packets := []gopacket.Packet{ipv6Packet(t, s, seq[s.client], ts)}
+		if fragment > 0 && len(s.data) > fragment {
+			packets = ipv6Fragments(t, packets[0], fragment)
+		}
+		for _, p := range packets {
+			assemblePacket(p, opts.Interface, "", assembler, defragger)
+		}
+		seq[s.client] += uint32(len(s.data))
+	}
+	assembler.FlushAll()
+	return drainQueue()
+}
+
+func TestIPv6(t *testing.T) {
+	for _, nodefrag := range []bool{false, true} {
+		opts = options.DefaultOptions()
+		opts.NoDefrag = nodefrag
+		sessions := assembleIPv6(t, testStreams["Missing TCP handshake with key exchange"].segments, 0)
+		if len(sessions) != 1 {
+			t.Fatalf("nodefrag %v: expected 1 session, got %d", nodefrag, len(sessions))
+		}
+		s := sessions[0]
+		if s.ClientIP != "2001:db8::1" || s.ServerIP != "2001:db8::2" || s.ClientPort != "40000" || s.ServerPort != "22" {
+			t.Errorf("nodefrag %v: mismatch on network, got %s:%s -> %s:%s", nodefrag, s.ClientIP, s.ClientPort, s.ServerIP, s.ServerPort)
+		}
+		if s.Client.HASSH == nil || s.Client.Hassh != "ec9ea89c70f5fc71cf61061bff5e4740" {
+			t.Errorf("nodefrag %v: mismatch on hassh, got %+v", nodefrag, s.Client.HASSH)
+		}
+		if s.Server.HASSHServer == nil || s.Server.HasshServer != "6832f1ce43d4397c2c0a3e2f8c94334e" {
+			t.Errorf("nodefrag %v: mismatch on hasshServer, got %+v", nodefrag, s.Server.HASSHServer)
+		}
+	}
+}
+
+func TestIPv6Defrag(t *testing.T) {
+	opts = options.DefaultOptions()
+	sessions := assembleIPv6(t, testStreams["Missing TCP handshake with key exchange"].segments, 256)
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+	if s := sessions[0]; s.Client.HASSH == nil || s.Client.Hassh != "ec9ea89c70f5fc71cf61061bff5e4740" {
+		t.Errorf("mismatch on hassh, got %+v", s.Client.HASSH)
+	}
+
+	// A duplicate fragment overlaps, dropping the packet once complete
+	fragments := ipv6Fragments(t, ipv6Packet(t, testSegment{client: true, data: testClientData}, 1000, time.Now()), 256)
+	n := len(fragments)
+	ip6 := fragments[0].Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	d := newIP6Defragmenter()
+	for i, f := range append(fragments[:n-1:n-1], fragments[1], fragments[n-1]) {
+		payload, _, err := d.defragIPv6(ip6, f.Layer(layers.LayerTypeIPv6Fragment).(*layers.IPv6Fragment), time.Now())
+		if i < n-1 && (payload != nil || err != nil) {
+			t.Fatalf("fragment %d: expected to wait for more fragments, got %v", i, err)
+		}
+		if i == n && err != errIP6FragmentOverlap {
+			t.Errorf("expected %v once complete, got %v", errIP6FragmentOverlap, err)
+		}
+	}
+	if len(d.lists) != 0 {
+		t.Errorf("expected the fragments to be dropped, got %d packets", len(d.lists))
+	}
+}
+
+func TestSessionFilename(t *testing.T) {
+	opts = options.DefaultOptions()
+	if err := parseFilenameTemplate(); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSSHSession("eth0")
+	s.SetTimestamp(time.Date(2019, 1, 1, 12, 30, 0, 5, time.UTC))
+	s.SetNetwork("2001:db8::1", "2001:db8::2", "40000", "22")
+	fn, err := sessionFilename(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "20190101T123000.000000005Z_2001-db8--1_40000"; fn != expected {
+		t.Errorf("mismatch on filename\n\nexpected:\n%s\ngot: \n%s\n", expected, fn)
+	}
+
+	dir := t.TempDir()
+	for i, expected := range []string{fn + ".json", fn + "-1.json", fn + "-2.json"} {
+		f, err := createUnique(dir, fn, ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if filepath.Base(f.Name()) != expected {
+			t.Errorf("mismatch on file %d\n\nexpected:\n%s\ngot: \n%s\n", i, expected, filepath.Base(f.Name()))
+		}
+	}
+}
+
+func TestFilterHASSH(t *testing.T) {
+	opts = options.DefaultOptions()
+	fn := filepath.Join(t.TempDir(), "list")
+	if err := os.WriteFile(fn, []byte("# OpenSSH_7.4\nEC9EA89C70F5FC71CF61061BFF5E4740\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := readHASSHList(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSSHSession("eth0")
+	s.Client.HASSH = &gohassh.HASSH{Hassh: "ec9ea89c70f5fc71cf61061bff5e4740"}
+
+	for _, test := range []struct {
+		allow, deny hasshList
+		match       string
+		pass        bool
+	}{
+		{pass: true},
+		{allow: list, match: "both", pass: false},
+		{allow: list, match: "server", pass: true},
+		{deny: list, match: "client", pass: true},
+		{deny: list, match: "server", pass: false},
+	} {
+		hasshAllow, hasshDeny = test.allow, test.deny
+		opts.HASSHMatch = test.match
+		if pass := filterHASSH(s); pass != test.pass {
+			t.Errorf("mismatch with allow:%t deny:%t match:%s, expected %t", test.allow != nil, test.deny != nil, test.match, test.pass)
+		}
+	}
+	hasshAllow, hasshDeny = nil, nil
+}
+
+func TestReapStuck(t *testing.T) {
+	opts = options.DefaultOptions()
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	jobQ = make(chan SSHSession, 16)
+
+	streamFactory := &tcpStreamFactory{}
+	assembler := reassembly.NewAssembler(reassembly.NewStreamPool(streamFactory))
+	defragger := newDefragmenter()
+
+	ts := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := testSegment{client: true, data: []byte("SSH-2.0-OpenSSH_7.4\r\n")}
+	assemblePacket(testPacket(t, s, 1000, ts), opts.Interface, "", assembler, defragger)
+
+	if n := streamFactory.reapStuck(ts.Add(opts.CloseTimeout)); n != 0 {
+		t.Errorf("reaped %d streams before CloseTimeout", n)
+	}
+	if n := streamFactory.reapStuck(ts.Add(opts.CloseTimeout + time.Second)); n != 1 {
+		t.Fatalf("expected 1 stream reaped, got %d", n)
+	}
+	sessions := drainQueue()
+	if len(sessions) != 1 || sessions[0].Client.ESSHBannerRecord == nil {
+		t.Fatalf("expected the reaped session with its client banner, got %+v", sessions)
+	}
+
+	// Closing the stream does not queue it again
+	assembler.FlushAll()
+	if sessions := drainQueue(); len(sessions) != 0 {
+		t.Errorf("expected no session on close, got %d", len(sessions))
+	}
+}
+
+func TestExpireOld(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.MaxSessionAge = time.Minute
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	jobQ = make(chan SSHSession, 16)
+
+	streamFactory := &tcpStreamFactory{}
+	assembler := reassembly.NewAssembler(reassembly.NewStreamPool(streamFactory))
+	defragger := newDefragmenter()
+
+	// The stream keeps being active
+	ts := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	banner := []byte("SSH-2.0-OpenSSH_7.4\r\n")
+	seq := uint32(1000)
+	for i := 0; i < 3; i++ {
+		s := testSegment{client: true, data: banner}
+		if i > 0 {
+			s.data = []byte{0}
+		}
+		assemblePacket(testPacket(t, s, seq, ts.Add(time.Duration(i)*30*time.Second)), opts.Interface, "", assembler, defragger)
+		seq += uint32(len(s.data))
+	}
+
+	if n := streamFactory.expireOld(ts.Add(opts.MaxSessionAge)); n != 0 {
+		t.Errorf("expired %d streams before -max-session-age", n)
+	}
+	if n := streamFactory.expireOld(ts.Add(opts.MaxSessionAge + time.Second)); n != 1 {
+		t.Fatalf("expected 1 stream expired, got %d", n)
+	}
+	sessions := drainQueue()
+	if len(sessions) != 1 || sessions[0].Client.ESSHBannerRecord == nil {
+		t.Fatalf("expected the expired session with its client banner, got %+v", sessions)
+	}
+
+	// Closing the stream does not queue it again
+	assembler.FlushAll()
+	if sessions := drainQueue(); len(sessions) != 0 {
+		t.Errorf("expected no session on close, got %d", len(sessions))
+	}
+}
+
+// TestEmitOnce runs every path emitting sessions on the same stream, the
+// session must be written once.
+func TestEmitOnce(t *testing.T) {
+	for k, segments := range map[string][]testSegment{
+		"key exchange": testStreams["Missing TCP handshake with key exchange"].segments,
+		"banners":      {{client: true, data: []byte("SSH-2.0-OpenSSH_7.4\r\n")}, {client: false, data: []byte("SSH-2.0-OpenSSH_7.4\r\n")}},
+	} {
+		t.Run(k, func(t *testing.T) {
+			opts = options.DefaultOptions()
+			opts.Partial = true
+			opts.MaxSessionAge = time.Minute
+			p, ts := feedSegments(t, segments)
+			var streams []*tcpStream
+			for _, stream := range p.streamFactory.streams {
+				streams = append(streams, stream)
+			}
+			if len(streams) != 1 {
+				t.Fatalf("expected 1 stream, got %d", len(streams))
+			}
+
+			later := ts.Add(opts.CloseTimeout + opts.MaxSessionAge)
+			p.streamFactory.reapStuck(later)
+			// Reaping forgets the stream, so that it is not reaped again
+			p.streamFactory.streams[streams[0].ident] = streams[0]
+			p.streamFactory.expireOld(later)
+			p.assembler.FlushAll()
+			if streams[0].emit() {
+				t.Error("emitted the session again")
+			}
+			if sessions := drainQueue(); len(sessions) != 1 {
+				t.Errorf("expected the session once, got %d", len(sessions))
+			}
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	opts = options.DefaultOptions()
+	s := NewSSHSession("eth0")
+	s.SetNetwork("10.0.0.1", "10.0.0.2", "40000", "22")
+	for _, rate := range []float64{0, 1} {
+		opts.SampleRate = rate
+		if sample(s) != (rate == 1) {
+			t.Errorf("mismatch on sampling at rate %g", rate)
+		}
+	}
+
+	opts.SampleRate = 0.25
+	first := sample(s)
+	in := 0
+	for port := 0; port < 4000; port++ {
+		s.SetNetwork("10.0.0.1", "10.0.0.2", fmt.Sprint(port), "22")
+		if sample(s) {
+			in++
+		}
+	}
+	if in < 900 || in > 1100 {
+		t.Errorf("expected about 1000 of 4000 connections sampled in, got %d", in)
+	}
+	s.SetNetwork("10.0.0.1", "10.0.0.2", "40000", "22")
+	if sample(s) != first {
+		t.Error("the connection was sampled differently the second time")
+	}
+}
+
+func TestFilterCIDR(t *testing.T) {
+	opts = options.DefaultOptions()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(opts, fs)
+	if err := fs.Parse([]string{"-client-cidr", "10.1.0.0/16", "-client-cidr", "2001:db8::/32", "-server-cidr", "192.0.2.22/32"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		client, server string
+		expected       bool
+	}{
+		{"10.1.2.3", "192.0.2.22", true},
+		{"2001:db8::1", "192.0.2.22", true},
+		{"10.2.0.1", "192.0.2.22", false},
+		{"10.1.2.3", "192.0.2.23", false},
+		{"192.0.2.22", "10.1.2.3", false},
+	} {
+		s := NewSSHSession("eth0")
+		s.SetNetwork(test.client, test.server, "40000", "22")
+		if filterCIDR(s) != test.expected {
+			t.Errorf("mismatch on %s -> %s, expected %v", test.client, test.server, test.expected)
+		}
+	}
+
+	if err := fs.Parse([]string{"-server-cidr", "192.0.2.22"}); err == nil {
+		t.Error("expected an error on an address without prefix length")
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.HealthzLiveness = time.Minute
+	defer health.stopping.Store(false)
+	for _, test := range []struct {
+		lastPacket time.Duration // ago
+		stopping   bool
+		expected   int
+	}{
+		{time.Second, false, http.StatusOK},
+		{2 * time.Minute, false, http.StatusServiceUnavailable},
+		{time.Second, true, http.StatusServiceUnavailable},
+	} {
+		health.lastPacket.Store(time.Now().Add(-test.lastPacket).UnixNano())
+		health.stopping.Store(test.stopping)
+		w := httptest.NewRecorder()
+		healthz(w, httptest.NewRequest("GET", "/healthz", nil))
+		if w.Code != test.expected {
+			t.Errorf("mismatch on status with last packet %s ago, stopping %v: expected %d, got %d", test.lastPacket, test.stopping, test.expected, w.Code)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.JSONIndent = false
+	a := newAggregate()
+	for _, s := range []struct {
+		ip, hassh, hasshServer string
+	}{
+		{"10.0.0.1", "a", "s"},
+		{"10.0.0.2", "b", "s"},
+		{"10.0.0.2", "b", ""},
+		{"10.0.0.2", "b", ""},
+	} {
+		session := NewSSHSession("eth0")
+		session.SetNetwork(s.ip, "10.0.0.9", "40000", "22")
+		session.Client.HASSH = &gohassh.HASSH{Hassh: s.hassh}
+		if s.hasshServer != "" {
+			session.Server.HASSHServer = &gohassh.HASSHServer{HasshServer: s.hasshServer}
+		}
+		a.add(session)
+	}
+
+	var buf bytes.Buffer
+	if err := a.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"hassh":[{"digest":"b","algorithms":"","count":3,"example_ips":["10.0.0.2"]},{"digest":"a","algorithms":"","count":1,"example_ips":["10.0.0.1"]}],` +
+		`"hasshServer":[{"digest":"s","algorithms":"","count":2,"example_ips":["10.0.0.9"]}]}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("mismatch on aggregate\n\nexpected:\n%s\ngot: \n%s\n", expected, buf.String())
+	}
+
+	// Aggregated sessions count towards -limit
+	opts.Aggregate = true
+	opts.Limit = 2
+	aggregated, written = newAggregate(), 0
+	limitC = make(chan struct{})
+	e := newSessionEncoder()
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		session := NewSSHSession("eth0")
+		session.SetNetwork(ip, "10.0.0.9", "40000", "22")
+		if output(session, e) != (i < opts.Limit) {
+			t.Errorf("mismatch on aggregating session %d with -limit %d", i+1, opts.Limit)
+		}
+	}
+	select {
+	case <-limitC:
+	default:
+		t.Error("expected the run to end once -limit sessions were aggregated")
+	}
+}
+
+func TestProto(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.Proto = true
+	e := newSessionEncoder()
+	var buf bytes.Buffer
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		session := NewSSHSession("eth0")
+		session.SetNetwork(ip, "10.0.0.9", "40000", "22")
+		session.Client.HASSH = &gohassh.HASSH{Hassh: "a", HasshAlgorithms: "kex;enc;mac;comp"}
+		record, err := e.encode(session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(record)
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		var s sessionpb.Session
+		if err := protodelim.UnmarshalFrom(r, &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.SrcIp != ip || s.SrcPort != 40000 || s.DestPort != 22 {
+			t.Errorf("mismatch on endpoints, expected %s:40000 -> :22, got %s:%d -> :%d", ip, s.SrcIp, s.SrcPort, s.DestPort)
+		}
+		if s.Client.GetHassh() != "a" || s.Client.GetHasshAlgorithms() != "kex;enc;mac;comp" {
+			t.Errorf("mismatch on client hassh, got %q %q", s.Client.GetHassh(), s.Client.GetHasshAlgorithms())
+		}
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Errorf("expected the end of the records, got %v", err)
+	}
+}
+
+func TestSchemaHASSH(t *testing.T) {
+	opts = options.DefaultOptions()
+	session := replay(t, []testSegment{{client: true, data: testClientData}, {data: testServerData}})
+	opts.Schema = SchemaHASSH
+	line, err := newSessionEncoder().encode(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(line, []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected a client and a server record, got %q", line)
+	}
+
+	for i, expected := range []map[string]string{
+		{"client": "SSH-2.0-OpenSSH_7.4", "hassh": "ec9ea89c70f5fc71cf61061bff5e4740", "sourceIp": "10.0.0.1", "destinationPort": "22", "hasshVersion": "1.0"},
+		{"server": "SSH-2.0-OpenSSH_7.4", "hasshServer": "6832f1ce43d4397c2c0a3e2f8c94334e", "sourceIp": "10.0.0.2", "sourcePort": "22", "hasshVersion": "1.0"},
+	} {
+		var record map[string]interface{}
+		if err := json.Unmarshal(lines[i], &record); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range expected {
+			if record[k] != v {
+				t.Errorf("record %d: mismatch on %s, expected %q, got %v", i, k, v, record[k])
+			}
+		}
+		for k, present := range map[string]bool{"timestamp": true, "ckex": i == 0, "skex": i == 1, "uid": false} {
+			if _, ok := record[k]; ok != present {
+				t.Errorf("record %d: mismatch on the presence of %s, expected %v", i, k, present)
+			}
+		}
+	}
+
+	if line, _ := newSessionEncoder().encode(NewSSHSession("eth0")); len(line) != 0 {
+		t.Errorf("expected no record without a KEXINIT, got %q", line)
+	}
+}
+
+// TestGroupExchange replays a diffie-hellman-group-exchange: the group
+// sizes are kept, and its INIT and REPLY complete the key exchange.
+func TestGroupExchange(t *testing.T) {
+	opts = options.DefaultOptions()
+	request := decodeString(`000000180a22000008000000200000002000` + `00000000000000000000`)
+	group := decodeString(`0000001a0a1f0000000500c7a1b3d50000000102` + `00000000000000000000`)
+	init := decodeString(`000000150a20000000050091b2c3d4e5` + `00000000000000000000`)
+	reply := append([]byte{}, testServerKexDHReply...)
+	reply[5] = byte(essh.ESSH_MSG_KEX_DH_GEX_REPLY)
+	session := replay(t, []testSegment{
+		{client: true, data: concat(testClientData, request)},
+		{data: concat(testServerData, group)},
+		{client: true, data: init},
+		{data: reply},
+	})
+	if expected := (essh.ESSHKexGexRequestRecord{Min: 2048, N: 8192, Max: 8192}); session.KexGexRequest == nil || *session.KexGexRequest != expected {
+		t.Errorf("mismatch on KexGexRequest\n\nexpected:\n%+v\ngot: \n%+v\n", expected, session.KexGexRequest)
+	}
+	if session.KexGexGroupBits != 32 {
+		t.Errorf("mismatch on KexGexGroupBits\n\nexpected:\n%d\ngot: \n%d\n", 32, session.KexGexGroupBits)
+	}
+	if !session.KexExchangeSeen || session.Server.HostKey == nil {
+		t.Errorf("key exchange not complete, seen %v, host key %v", session.KexExchangeSeen, session.Server.HostKey)
+	}
+}
+
+// kexinitPacket returns a KEXINIT binary packet with the kex, ciphers, MACs
+// and compression name-lists, the same in both directions.
+func kexinitPacket(kex, cipher, mac, comp string) []byte {
+	payload := append([]byte{byte(essh.ESSH_MSG_KEXINIT)}, make([]byte, 16)...)
+	for _, nl := range []string{kex, "ssh-ed25519", cipher, cipher, mac, mac, comp, comp, "", ""} {
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(nl)))
+		payload = append(payload, nl...)
+	}
+	payload = append(payload, 0, 0, 0, 0, 0)
+	pad := 8 - (5+len(payload))%8 + 8
+	packet := binary.BigEndian.AppendUint32(nil, uint32(1+len(payload)+pad))
+	packet = append(append(packet, byte(pad)), payload...)
+	return append(packet, make([]byte, pad)...)
+}
+
+// TestRekeyCleartext replays a rekey with keys leaving the packets in the
+// clear: the session is held back until the stream is closed, with its rekey.
+func TestRekeyCleartext(t *testing.T) {
+	opts = options.DefaultOptions()
+	kexinit := kexinitPacket("curve25519-sha256,diffie-hellman-group14-sha256", "none", "none", "none")
+	banner := []byte("SSH-2.0-OpenSSH_7.4\r\n")
+	queued, closed := assembleSegments(t, []testSegment{
+		{client: true, data: concat(banner, kexinit)},
+		{data: concat(banner, kexinit)},
+		{client: true, data: testClientKexDHInit},
+		{data: concat(testServerKexDHReply, testNewKeys)},
+		{client: true, data: testNewKeys},
+		{client: true, data: kexinit},
+		{data: kexinit},
+	})
+	if len(queued) != 0 || len(closed) != 1 {
+		t.Fatalf("expected 1 session queued when closed, got %d and %d", len(queued), len(closed))
+	}
+	s := closed[0]
+	if !s.HandshakeComplete || s.RekeyCount != 1 || len(s.RekeyTimes) != 1 {
+		t.Errorf("mismatch on rekeys, handshake %t, count %d, times %v", s.HandshakeComplete, s.RekeyCount, s.RekeyTimes)
+	}
+	if len(s.Client.Rekeys) != 1 || len(s.Server.Rekeys) != 1 {
+		t.Errorf("mismatch on rekeyed KEXINITs, client %d, server %d", len(s.Client.Rekeys), len(s.Server.Rekeys))
+	}
+}
+
+func TestPrintErrors(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.Quiet = true
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	errorCount = 0
+	for _, e := range []string{"FSM", "Decode", "FSM", "Checksum", "FSM", "Decode"} {
+		Error(e, "test\n")
+	}
+	var buf bytes.Buffer
+	printErrors(&buf)
+	expected := "Errors: 6\n FSM:\t\t3\n Decode:\t\t2\n Checksum:\t\t1\n"
+	if buf.String() != expected {
+		t.Errorf("mismatch on errors\n\nexpected:\n%q\ngot: \n%q\n", expected, buf.String())
+	}
+}
+
+func TestEnricher(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.OutputDir = t.TempDir()
+	opts.OutputFile = "sessions.json"
+	outFile, written = nil, 0
+	defer func() { enrichers = nil }()
+	enrichers = nil
+	RegisterEnricher(func(s *SSHSession) {
+		s.Enrichment = map[string]interface{}{"owner": "ops", "hostname": s.ClientIP}
+	})
+	RegisterEnricher(func(s *SSHSession) {
+		s.Enrichment["owner"] = s.Enrichment["owner"].(string) + "/db"
+	})
+
+	s := NewSSHSession("eth0")
+	s.SetNetwork("10.0.0.1", "10.0.0.2", "40000", "22")
+	jobQ = make(chan SSHSession, 1)
+	jobQ <- s
+	close(jobQ)
+	var w sync.WaitGroup
+	w.Add(1)
+	processCompletedSession(jobQ, &w)
+	outFile.Close()
+	outFile = nil
+
+	b, err := os.ReadFile(filepath.Join(opts.OutputDir, opts.OutputFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got SSHSession
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"owner": "ops/db", "hostname": "10.0.0.1"}
+	if !reflect.DeepEqual(got.Enrichment, expected) {
+		t.Errorf("mismatch on Enrichment\n\nexpected:\n%v\ngot: \n%v\n", expected, got.Enrichment)
+	}
+}
+
+func TestJSONArray(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.OutputDir = t.TempDir()
+	opts.OutputFile = "sessions.json"
+	opts.JSONArray = true
+	outFile, written = nil, 0
+	limitC = make(chan struct{})
+	fn := filepath.Join(opts.OutputDir, opts.OutputFile)
+	if err := os.WriteFile(fn, []byte("left from a previous run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newSessionEncoder()
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		s := NewSSHSession("eth0")
+		s.SetNetwork(ip, "10.0.0.9", "40000", "22")
+		if !output(s, e) {
+			t.Fatalf("session of %s not written", ip)
+		}
+	}
+	if err := closeOutFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []SSHSession
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("not a JSON array: %s\n%s", err, b)
+	}
+	if len(got) != 3 || got[0].ClientIP != "10.0.0.1" || got[2].ClientIP != "10.0.0.3" {
+		t.Errorf("mismatch on the sessions of the array, got %+v", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.OutputDir = t.TempDir()
+	opts.OutputFile = "sessions.json"
+	opts.Limit = 1
+	outFile, written = nil, 0
+	limitC = make(chan struct{})
+	defer closeOutFile()
+
+	e := newSessionEncoder()
+	for _, test := range []struct {
+		ip      string
+		partial bool
+		written bool
+		limit   bool // the run ends after this session
+	}{
+		{"10.0.0.1", true, true, false}, // a port scan does not count
+		{"10.0.0.2", false, true, true},
+		{"10.0.0.3", false, false, true},
+	} {
+		s := NewSSHSession("eth0")
+		s.SetNetwork(test.ip, "10.0.0.9", "40000", "22")
+		s.Partial = test.partial
+		if output(s, e) != test.written {
+			t.Errorf("mismatch on writing the session of %s, expected %t", test.ip, test.written)
+		}
+		select {
+		case <-limitC:
+			if !test.limit {
+				t.Fatalf("the run ended after the session of %s", test.ip)
+			}
+		default:
+			if test.limit {
+				t.Errorf("the run did not end after the session of %s", test.ip)
+			}
+		}
+	}
+}
+
+func TestGeoIP(t *testing.T) {
+	if _, err := openGeoIP(filepath.Join("testdata", "missing.mmdb")); err == nil {
+		t.Error("opened a missing database")
+	}
+	// Locates 10.0.0.1 only
+	g, err := openGeoIP(filepath.Join("testdata", "geoip.mmdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.close()
+
+	s := NewSSHSession("eth0")
+	s.SetNetwork("10.0.0.1", "10.0.0.2", "40000", "22")
+	g.enrich(&s)
+	expected := Geo{Country: "NO", ASN: 64500, Org: "Example Org"}
+	if s.ClientGeo == nil || *s.ClientGeo != expected {
+		t.Errorf("mismatch on ClientGeo\n\nexpected:\n%+v\ngot: \n%+v\n", expected, s.ClientGeo)
+	}
+	if s.ServerGeo != nil {
+		t.Errorf("expected no ServerGeo, got %+v", s.ServerGeo)
+	}
+	if g.lookups.Load() != 2 || g.misses.Load() != 1 {
+		t.Errorf("mismatch on stats, expected 2 lookups and 1 miss, got %d and %d", g.lookups.Load(), g.misses.Load())
+	}
+
+	// A corrupt search tree fails the lookups
+	b, err := os.ReadFile(filepath.Join("testdata", "geoip.mmdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 64; i++ {
+		b[i] = 0xff
+	}
+	fn := filepath.Join(t.TempDir(), "corrupt.mmdb")
+	if err := os.WriteFile(fn, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if g, err = openGeoIP(fn); err != nil {
+		t.Fatal(err)
+	}
+	defer g.close()
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	for i := 0; i < 2; i++ {
+		if geo := g.lookup("10.0.0.1"); geo != nil {
+			t.Errorf("expected no location from a corrupt database, got %+v", geo)
+		}
+	}
+	if g.errors.Load() != 2 || errorsMap["GeoIP"] != 1 {
+		t.Errorf("expected 2 errors counted and 1 logged, got %d and %d", g.errors.Load(), errorsMap["GeoIP"])
+	}
+}
+
+func TestFlushIdle(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.IdleTimeout = time.Minute
+	p, ts := feedSegments(t, testStreams["Missing TCP handshake"].segments)
+
+	p.flushIdle(ts.Add(opts.IdleTimeout / 2))
+	if sessions := drainQueue(); len(sessions) != 0 {
+		t.Fatalf("expected no session before the stream is idle, got %d", len(sessions))
+	}
+	p.flushIdle(ts.Add(opts.IdleTimeout + time.Second))
+	if sessions := drainQueue(); len(sessions) != 1 {
+		t.Fatalf("expected the idle session, got %d", len(sessions))
+	}
+}
+
+func TestUnixSink(t *testing.T) {
+	opts = options.DefaultOptions()
+	dir, err := os.MkdirTemp("", "hassh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sock")
+	u := &unixSink{path: path}
+	defer u.close()
+
+	// No collector yet
+	if err := u.write([]byte(`{}`)); err == nil {
+		t.Fatal("expected an error without a collector")
+	}
+	if err := u.write([]byte(`{}`)); err != errUnixSinkDown {
+		t.Fatalf("expected to wait before reconnecting, got %v", err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	u.retry = time.Time{}
+	if err := u.write([]byte(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "{\"a\":1}\n" {
+		t.Errorf("expected an NDJSON record, got %q", line)
+	}
+}
+
+func TestCheckImplementation(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.ImplCheck = true
+	session := func(software string) SSHSession {
+		s := SSHSession{}
+		s.ClientBanner(&essh.ESSHBannerRecord{ProtoVersion: "2.0", SoftwareVersion: software})
+		s.Client.HASSH = &gohassh.HASSH{Hassh: sampleHASSH}
+		return s
+	}
+
+	s := session("OpenSSH_7.4")
+	checkImplementation(&s)
+	if s.Client.InferredImplementation != "OpenSSH" || s.ImplementationMismatch {
+		t.Errorf("OpenSSH banner: inferred %q, mismatch %v", s.Client.InferredImplementation, s.ImplementationMismatch)
+	}
+	s = session("PuTTY_Release_0.70")
+	checkImplementation(&s)
+	if !s.ImplementationMismatch {
+		t.Error("PuTTY banner with an OpenSSH HASSH is not flagged")
+	}
+	s = session("Cisco-1.25")
+	checkImplementation(&s)
+	if s.ImplementationMismatch {
+		t.Error("a banner naming no known implementation is flagged")
+	}
+
+	// Overridden by -impl-map
+	saved := implementations[sampleHASSH]
+	defer func() { implementations[sampleHASSH] = saved }()
+	fn := filepath.Join(t.TempDir(), "impl.json")
+	if err := os.WriteFile(fn, []byte(`{"`+sampleHASSH+`": "PuTTY"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := readImplementations(fn); err != nil {
+		t.Fatal(err)
+	}
+	s = session("PuTTY_Release_0.70")
+	checkImplementation(&s)
+	if s.Client.InferredImplementation != "PuTTY" || s.ImplementationMismatch {
+		t.Errorf("overridden: inferred %q, mismatch %v", s.Client.InferredImplementation, s.ImplementationMismatch)
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	for k, test := range map[string]struct {
+		software string
+		hassh    string
+		expected string
+		spoofed  bool
+	}{
+		"digest":                   {"OpenSSH_7.4", sampleHASSH, "OpenSSH", false},
+		"digest over the banner":   {"PuTTY_Release_0.70", sampleHASSH, "OpenSSH", true},
+		"digest of unknown banner": {"Cisco-1.25", sampleHASSH, "OpenSSH", false},
+		"banner":                   {"PuTTY_Release_0.76", "", "PuTTY", false},
+		"banner of unknown digest": {"libssh_0.9.6", "00000000000000000000000000000000", "libssh", false},
+		"masscan":                  {"masscan/1.3", "", "masscan", false},
+		"dropbear":                 {"dropbear_2019.78", "ad00edb0c2a031d9884826ba7b7ba41e", "dropbear", false},
+		"unknown":                  {"Cisco-1.25", "", "", false},
+	} {
+		s := SSHSession{}
+		s.ClientBanner(&essh.ESSHBannerRecord{ProtoVersion: "2.0", SoftwareVersion: test.software})
+		if test.hassh != "" {
+			s.Client.HASSH = &gohassh.HASSH{Hassh: test.hassh}
+		}
+		opts = options.DefaultOptions()
+		opts.ImplCheck = true
+		identify(&s)
+		checkImplementation(&s)
+		if s.ImplementationMismatch != s.PossibleSpoofedBanner {
+			t.Errorf("failed testcase '%s', mismatch on ImplementationMismatch, expected it to match PossibleSpoofedBanner", k)
+		}
+		if s.IdentifiedClient != test.expected {
+			t.Errorf("failed testcase '%s', expected %q, got %q", k, test.expected, s.IdentifiedClient)
+		}
+		if s.PossibleSpoofedBanner != test.spoofed {
+			t.Errorf("failed testcase '%s', mismatch on PossibleSpoofedBanner, expected %t", k, test.spoofed)
+		}
+	}
+
+	s := replay(t, []testSegment{{client: true, data: testClientData}, {data: testServerData}})
+	if s.IdentifiedClient != "OpenSSH" || s.IdentifiedServer != "OpenSSH" {
+		t.Errorf("sample identified as %q and %q", s.IdentifiedClient, s.IdentifiedServer)
+	}
+
+	// The built-in digests hold those of the salesforce/hassh fingerprints
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "hassh", "salesforce_hassh_fingerprints.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for d := json.NewDecoder(f); d.More(); {
+		var r struct {
+			Banner string `json:"clientIdentificationString"`
+			HASSH  string `json:"hassh"`
+		}
+		if err := d.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		software := strings.SplitN(r.Banner, "-", 3)[2]
+		if product := essh.ParseSoftware(software, "").Product; fingerprints.HASSH[r.HASSH] != product {
+			t.Errorf("%s of %s: expected %q, got %q", r.HASSH, r.Banner, product, fingerprints.HASSH[r.HASSH])
+		}
+	}
+}
+
+func TestWeakAlgorithms(t *testing.T) {
+	opts = options.DefaultOptions()
+	s := replay(t, []testSegment{{client: true, data: testClientData}, {data: testServerData}})
+	checkWeakAlgorithms(&s)
+	if s.Client.WeakAlgorithms != nil || s.Server.WeakAlgorithms != nil {
+		t.Errorf("flagged without -weak-algorithms")
+	}
+
+	opts.WeakAlgorithms = true
+	checkWeakAlgorithms(&s)
+	expected := []string{"diffie-hellman-group1-sha1", "ssh-dss-cert-v01@openssh.com", "ssh-dss", "aes128-cbc", "aes192-cbc", "aes256-cbc", "hmac-sha1-etm@openssh.com", "hmac-sha1"}
+	if !reflect.DeepEqual(s.Client.WeakAlgorithms, expected) {
+		t.Errorf("mismatch on client WeakAlgorithms\n\nexpected:\n%q\ngot: \n%q\n", expected, s.Client.WeakAlgorithms)
+	}
+	for _, name := range []string{"diffie-hellman-group1-sha1", "3des-cbc", "blowfish-cbc", "hmac-sha1"} {
+		found := false
+		for _, w := range s.Server.WeakAlgorithms {
+			found = found || w == name
+		}
+		if !found {
+			t.Errorf("%s not flagged on the server, got %q", name, s.Server.WeakAlgorithms)
+		}
+	}
+	for name, weak := range map[string]bool{
+		"arcfour256":                    true,
+		"hmac-md5-96":                   true,
+		"rijndael-cbc@lysator.liu.se":   true,
+		"aes128-ctr":                    false,
+		"hmac-sha2-256":                 false,
+		"diffie-hellman-group14-sha256": false,
+	} {
+		if isWeak(name) != weak {
+			t.Errorf("mismatch on %s, expected weak: %t", name, weak)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	opts = options.DefaultOptions()
+	defer func() { policy = nil }()
+	s := replay(t, []testSegment{{client: true, data: testClientData}, {data: testServerData}})
+	checkPolicy(&s)
+	if s.Client.Policy != nil || s.Server.Policy != nil {
+		t.Errorf("checked without -policy")
+	}
+
+	if err := readPolicy("modern"); err != nil {
+		t.Fatal(err)
+	}
+	checkPolicy(&s)
+	for _, r := range []*SSHRecord{&s.Client, &s.Server} {
+		if r.Policy == nil || r.Policy.Name != "modern" || r.Policy.Pass || len(r.Policy.Violations) == 0 {
+			t.Fatalf("OpenSSH_7.4 passes the modern policy: %+v", r.Policy)
+		}
+	}
+	for _, name := range []string{"diffie-hellman-group14-sha1", "ssh-rsa", "aes128-cbc", "hmac-sha1"} {
+		found := false
+		for _, v := range s.Client.Policy.Violations {
+			found = found || v == name
+		}
+		if !found {
+			t.Errorf("%s not a violation on the client, got %q", name, s.Client.Policy.Violations)
+		}
+	}
+	for _, v := range s.Client.Policy.Violations {
+		if v == "ext-info-c" || v == "curve25519-sha256" || v == "aes128-ctr" {
+			t.Errorf("%s is allowed by the modern policy", v)
+		}
+	}
+
+	kexinit := kexinitPacket("curve25519-sha256,ext-info-c,kex-strict-c-v00@openssh.com", "chacha20-poly1305@openssh.com,aes256-ctr", "hmac-sha2-256-etm@openssh.com", "none")
+	banner := []byte("SSH-2.0-OpenSSH_9.6\r\n")
+	modern := replay(t, []testSegment{{client: true, data: concat(banner, kexinit)}, {data: concat(banner, kexinit)}})
+	checkPolicy(&modern)
+	if p := modern.Client.Policy; p == nil || !p.Pass || p.Violations != nil {
+		t.Errorf("modern client fails the modern policy: %+v", p)
+	}
+
+	// Policy file, lists left out allow anything
+	fn := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(fn, []byte(`{"ciphers": ["aes*-ctr"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := readPolicy(fn); err != nil {
+		t.Fatal(err)
+	}
+	checkPolicy(&modern)
+	expected := []string{"chacha20-poly1305@openssh.com"}
+	if p := modern.Client.Policy; p == nil || p.Name != fn || p.Pass || !reflect.DeepEqual(p.Violations, expected) {
+		t.Errorf("mismatch on the policy file, expected violations %q, got %+v", expected, p)
+	}
+}
+
+func TestSessionUID(t *testing.T) {
+	ts := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := SSHSession{}
+	s.SetNetwork("10.0.0.1", "10.0.0.2", "40000", "22")
+	uid := sessionUID(s, ts)
+	if len(uid) < 2 || uid[0] != 'C' {
+		t.Fatalf("expected a Zeek-style uid, got %q", uid)
+	}
+	if again := sessionUID(s, ts); again != uid {
+		t.Errorf("uid is not reproducible: %q and %q", uid, again)
+	}
+	if other := sessionUID(s, ts.Add(time.Second)); other == uid {
+		t.Errorf("streams first seen at different times share the uid %q", uid)
+	}
+	s.SetNetwork("10.0.0.1", "10.0.0.2", "40001", "22")
+	if other := sessionUID(s, ts); other == uid {
+		t.Errorf("streams on different ports share the uid %q", uid)
+	}
+}
+
+func TestHASSHInput(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.HASSHInput = true
+	_, sessions := assembleSegments(t, testStreams["Missing TCP handshake with key exchange"].segments)
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+	s := sessions[0]
+
+	in := s.Client.HASSHInput
+	if in == nil {
+		t.Fatal("no hasshInput")
+	}
+	if sum := md5.Sum([]byte(strings.Join([]string{in.Kex, in.Enc, in.MAC, in.Comp}, ";"))); hex.EncodeToString(sum[:]) != s.Client.Hassh {
+		t.Errorf("hasshInput does not give the HASSH %s", s.Client.Hassh)
+	}
+	sin := s.Server.HASSHServerInput
+	if sin == nil {
+		t.Fatal("no hasshServerInput")
+	}
+	if sum := md5.Sum([]byte(strings.Join([]string{sin.Kex, sin.Enc, sin.MAC, sin.Comp}, ";"))); hex.EncodeToString(sum[:]) != s.Server.HasshServer {
+		t.Errorf("hasshServerInput does not give the HASSHServer %s", s.Server.HasshServer)
+	}
+}
+
+func TestInferDirection(t *testing.T) {
+	// The capture starts with the server banner
+	segments := []testSegment{
+		{client: false, data: []byte("SSH-2.0-OpenSSH_7.4\r\n")},
+		{client: true, data: testClientData},
+		{client: false, data: testServerKexinit},
+		{client: true, data: testClientKexDHInit},
+		{client: false, data: concat(testServerKexDHReply, testNewKeys)},
+		{client: true, data: testNewKeys},
+	}
+
+	opts = options.DefaultOptions()
+	_, sessions := assembleSegments(t, segments)
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+	s := sessions[0]
+	if !s.DirectionInferred || s.ClientPort != "40000" || s.ServerPort != "22" {
+		t.Errorf("expected the client on port 40000, got %s:%s -> %s:%s (inferred %t)", s.ClientIP, s.ClientPort, s.ServerIP, s.ServerPort, s.DirectionInferred)
+	}
+	if s.Client.HASSH == nil || s.Client.Hassh != sampleHASSH || s.Server.HASSHServer == nil || s.Server.HasshServer != sampleHASSHServer {
+		t.Errorf("client and server swapped: %+v %+v", s.Client.HASSH, s.Server.HASSHServer)
+	}
+
+	// Overridden
+	opts = options.DefaultOptions()
+	opts.AssumeClientPort = 22
+	sessions, closed := assembleSegments(t, segments)
+	sessions = append(sessions, closed...)
+	if len(sessions) != 1 || sessions[0].ClientPort != "22" {
+		t.Errorf("expected the client on port 22 with -assume-client-port, got %+v", sessions)
+	}
+
+	// The capture starts with the SYN-ACK of the server
+	opts = options.DefaultOptions()
+	opts.AssumeClientPort = 22 // not consulted, the SYN-ACK tells the roles
+	sessions, closed = assembleSegments(t, append([]testSegment{{client: false, syn: true}}, segments...))
+	sessions = append(sessions, closed...)
+	if len(sessions) != 1 || sessions[0].ClientPort != "40000" || sessions[0].Client.Hassh != sampleHASSH {
+		t.Errorf("expected the client on port 40000 after a SYN-ACK, got %+v", sessions)
+	}
+}
+
+func TestEvasionPort(t *testing.T) {
+	opts = options.DefaultOptions()
+	s := SSHSession{ServerPort: "443"}
+	if isEvasionPort(s) {
+		t.Error("flagged without -detect-evasion")
+	}
+	opts.DetectEvasion = true
+	if !isEvasionPort(s) {
+		t.Error("port 443 not flagged")
+	}
+	s.ServerPort = "22"
+	if isEvasionPort(s) {
+		t.Error("port 22 flagged")
+	}
+}
+
+func TestSSH1Alert(t *testing.T) {
+	opts = options.DefaultOptions()
+	setupLogging()
+	s := SSHSession{}
+	s.Server.ESSHBannerRecord = &essh.ESSHBannerRecord{ProtoVersion: "1.99", SoftwareVersion: "OpenSSH_3.9p1"}
+	checkSSH1(&s)
+	if s.SSH1Server {
+		t.Error("flagged without -ssh1-alert")
+	}
+	opts.SSH1Alert = true
+	checkSSH1(&s)
+	if !s.SSH1Server {
+		t.Error("protocol 1.99 not flagged")
+	}
+	s = SSHSession{}
+	s.Server.ESSHBannerRecord = &essh.ESSHBannerRecord{ProtoVersion: "2.0", SoftwareVersion: "OpenSSH_9.6"}
+	checkSSH1(&s)
+	if s.SSH1Server {
+		t.Error("protocol 2.0 flagged")
+	}
+}
+
+var (
+	testSSH1PublicKey  = decodeString(`0000010b0000000000020000000000000000000003000006ff0300ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff000004000006ff0400ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff00000002000000480000000c8a42aa76`)
+	testSSH1SessionKey = decodeString(`0000009400000000030300000000000000000400ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff000000022a73e232`)
+)
+
+// TestSSH1Session replays a protocol 1 client against a server speaking both
+// protocols: the session is complete once the client sent the session key.
+func TestSSH1Session(t *testing.T) {
+	opts = options.DefaultOptions()
+	_, closed := assembleSegments(t, []testSegment{
+		{data: []byte("SSH-1.99-OpenSSH_3.9p1\r\n")},
+		{client: true, data: []byte("SSH-1.5-OpenSSH_3.9p1\n")},
+		{data: testSSH1PublicKey},
+		{client: true, data: testSSH1SessionKey},
+	})
+	if len(closed) != 1 {
+		t.Fatalf("expected the session queued when the stream is closed, got %d", len(closed))
+	}
+	s := closed[0]
+	if k := s.Server.SSH1PublicKey; k == nil || k.ServerKeyBits != 768 || k.HostKeyBits != 1024 || !reflect.DeepEqual(k.Ciphers, []string{"3des", "blowfish"}) {
+		t.Errorf("mismatch on the server public key: %+v", k)
+	}
+	if k := s.Client.SSH1SessionKey; k == nil || k.Cipher != "3des" {
+		t.Errorf("mismatch on the client session key: %+v", k)
+	}
+	if s.State != ConnComplete || !s.HandshakeComplete {
+		t.Errorf("expected a complete handshake, got state %s, handshake complete %t", s.State, s.HandshakeComplete)
+	}
+}
+
+func TestJA4SSH(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.JA4SSHPackets = 5
+
+	var j ja4ssh
+	for i, p := range []struct {
+		client bool
+		tcp    *layers.TCP
+		full   bool
+	}{
+		{true, &layers.TCP{ACK: true, BaseLayer: layers.BaseLayer{Payload: make([]byte, 36)}}, false},
+		{false, &layers.TCP{ACK: true, BaseLayer: layers.BaseLayer{Payload: make([]byte, 52)}}, false},
+		{true, &layers.TCP{ACK: true}, false},
+		{true, &layers.TCP{ACK: true, BaseLayer: layers.BaseLayer{Payload: make([]byte, 36)}}, false},
+		{false, &layers.TCP{ACK: true, FIN: true}, false}, // not counted
+		{true, &layers.TCP{ACK: true, BaseLayer: layers.BaseLayer{Payload: make([]byte, 100)}}, true},
+	} {
+		dir := reassembly.TCPDirServerToClient
+		if p.client {
+			dir = reassembly.TCPDirClientToServer
+		}
+		if full := j.add(p.tcp, dir); full != p.full {
+			t.Errorf("packet %d: expected window full %t, got %t", i, p.full, full)
+		}
+	}
+	if expected := "c36s52_c3s1_c1s0"; j.String() != expected {
+		t.Errorf("mismatch on JA4SSH\n\nexpected:\n%s\ngot: \n%s\n", expected, j.String())
+	}
+}
+
+func concat(b ...[]byte) []byte {
+	var c []byte
+	for _, d := range b {
+		c = append(c, d...)
+	}
+	return c
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkWritable(dir); err != nil {
+		t.Errorf("expected %s to be writable: %s", dir, err)
+	}
+	if err := checkWritable(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected a missing folder to fail")
+	}
+	fn := filepath.Join(dir, "file")
+	if err := os.WriteFile(fn, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(fn); err == nil {
+		t.Errorf("expected a file to fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected the check to leave no file behind, got %d entries", len(entries))
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "manifest")
+	if err := os.WriteFile(fn, []byte("# rotated captures\na.pcap\n\n  b.pcap  \n#c.pcap\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := readManifest(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0] != "a.pcap" || files[1] != "b.pcap" {
+		t.Errorf("mismatch on files, got %q", files)
+	}
+}
+
+// BenchmarkOutputWorkers writes sessions enriched from an asset database
+// answering over HTTP in a millisecond, the work the workers share while the
+// writes to the file are one at a time.
+func BenchmarkOutputWorkers(b *testing.B) {
+	_, sessions := assembleSegments(b, testStreams["Missing TCP handshake with key exchange"].segments)
+	db := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		fmt.Fprint(w, "ops")
+	}))
+	defer db.Close()
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 4}}
+	defer func() { enrichers = nil }()
+	enrichers = nil
+	RegisterEnricher(func(s *SSHSession) {
+		resp, err := client.Get(db.URL + "/" + s.ClientIP)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		owner, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		s.Enrichment = map[string]interface{}{"owner": string(owner)}
+	})
+
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			opts = options.DefaultOptions()
+			opts.OutputDir = b.TempDir()
+			opts.OutputFile = "sessions.json"
+			outFile, written = nil, 0
+			limitC = make(chan struct{})
+			jobQ = make(chan SSHSession, opts.QueueSize)
+
+			b.ResetTimer()
+			var w sync.WaitGroup
+			w.Add(workers)
+			for i := 0; i < workers; i++ {
+				go processCompletedSession(jobQ, &w)
+			}
+			for i := 0; i < b.N; i++ {
+				jobQ <- sessions[0]
+			}
+			close(jobQ)
+			w.Wait()
+			outFile.Close()
+		})
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestGolden runs testdata/handshake.pcap through the pipeline, and compares
+// the sessions written against testdata/handshake.golden. Run with -update
+// to write the golden file after a deliberate change of the output.
+func TestGolden(t *testing.T) {
+	opts = options.DefaultOptions()
+	opts.OutputDir = t.TempDir()
+	opts.OutputFile = "sessions.json"
+	opts.Sort = true
+	setupLogging()
+	errorsMap = make(map[string]uint)
+
+	fn := filepath.Join("testdata", "handshake.pcap")
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := pcapgo.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newPipeline()
+	p.processPcap(r, opts.Interface, fn, nil)
+	p.close()
+
+	// One compact session per line
+	out, err := os.Open(filepath.Join(opts.OutputDir, opts.OutputFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	var got bytes.Buffer
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var m json.RawMessage
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Compact(&got, m); err != nil {
+			t.Fatal(err)
+		}
+		got.WriteByte('\n')
+	}
+
+	golden := filepath.Join("testdata", "handshake.golden")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", golden, got.Bytes(), want)
+	}
+}
+
+// testSG is a ScatterGather of a single chunk
+type testSG struct {
+	dir  reassembly.TCPFlowDirection
+	skip int
+	data []byte
+	ts   time.Time
+}
+
+func (sg *testSG) Lengths() (int, int)     { return len(sg.data), 0 }
+func (sg *testSG) Fetch(length int) []byte { return sg.data[:length] }
+func (sg *testSG) KeepFrom(offset int)     {}
+func (sg *testSG) CaptureInfo(int) gopacket.CaptureInfo {
+	return gopacket.CaptureInfo{Timestamp: sg.ts}
+}
+func (sg *testSG) Stats() reassembly.TCPAssemblyStats { return reassembly.TCPAssemblyStats{} }
+func (sg *testSG) Info() (reassembly.TCPFlowDirection, bool, bool, int) {
+	return sg.dir, false, false, sg.skip
+}
+
+// testStream returns a stream from 10.0.0.1:40000 to 10.0.0.2:22, as the
+// assembler creates them, to be fed with testSG.
+func testStream() *tcpStream {
+	setupLogging()
+	errorsMap = make(map[string]uint)
+	netFlow := gopacket.NewFlow(layers.EndpointIPv4, net.IP{10, 0, 0, 1}, net.IP{10, 0, 0, 2})
+	transport := gopacket.NewFlow(layers.EndpointTCPPort, []byte{0x9c, 0x40}, []byte{0, 22})
+	return (&tcpStreamFactory{}).New(netFlow, transport, &layers.TCP{}, &Context{}).(*tcpStream)
+}
+
+// replay hands the data of the segments to ReassembledSG of a testStream,
+// one chunk per segment as the assembler would once they are reassembled,
+// without building packets. It returns the session as queued, emitting it
+// at the end if it was not already.
+func replay(t testing.TB, segments []testSegment) SSHSession {
+	stream := testStream()
+	jobQ = make(chan SSHSession, 1)
+	ts := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream.firstSeen = ts
+	for _, s := range segments {
+		ts = ts.Add(time.Millisecond)
+		sg := &testSG{data: s.data, ts: ts}
+		if !s.client {
+			sg.dir = reassembly.TCPDirServerToClient
+		}
+		stream.ReassembledSG(sg, &Context{})
+	}
+	stream.emit()
+	select {
+	case s := <-jobQ:
+		return s
+	default:
+		t.Fatal("no session queued")
+	}
+	return SSHSession{}
+}
+
+func TestCaptureStop(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "handshake.pcap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := pcapgo.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing reads the packets, the capture must return once stopped
+	packets := make(chan capturedPacket)
+	stop := make(chan struct{})
+	var w sync.WaitGroup
+	w.Add(1)
+	go capture(r, "", "", packets, stop, &w)
+	close(stop)
+
+	returned := make(chan struct{})
+	go func() {
+		w.Wait()
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("capture still blocked on sending after stop")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	badPadding := append([]byte(nil), testClientData...)
+	badPadding[len("SSH-2.0-OpenSSH_7.4\r\n")+4]++ // padding_length of the KEXINIT
+
+	var split []testSegment
+	for data := testClientData; len(data) > 0; data = data[min(7, len(data)):] {
+		split = append(split, testSegment{client: true, data: data[:min(7, len(data))]})
+	}
+
+	for k, test := range map[string]struct {
+		segments []testSegment
+		state    string
+		hassh    string
+	}{
+		"Handshake": {
+			segments: []testSegment{{client: true, data: testClientData}, {data: testServerData}},
+			state:    ConnComplete,
+			hassh:    "ec9ea89c70f5fc71cf61061bff5e4740",
+		},
+		"Server first": {
+			segments: []testSegment{{data: testServerData}, {client: true, data: testClientData}},
+			state:    ConnComplete,
+			hassh:    "ec9ea89c70f5fc71cf61061bff5e4740",
+		},
+		"Split records": {
+			segments: append(split, testSegment{data: testServerData}),
+			state:    ConnComplete,
+			hassh:    "ec9ea89c70f5fc71cf61061bff5e4740",
+		},
+		"Bad padding": {
+			segments: []testSegment{{client: true, data: badPadding}, {data: testServerData}},
+			state:    ConnMalformed,
+		},
+		"Banners only": {
+			segments: []testSegment{{client: true, data: []byte("SSH-2.0-OpenSSH_7.4\r\n")}, {data: []byte("SSH-2.0-OpenSSH_7.4\r\n")}},
+			state:    ConnBannerOnly,
+		},
+	} {
+		t.Run(k, func(t *testing.T) {
+			opts = options.DefaultOptions()
+			s := replay(t, test.segments)
+			if s.State != test.state {
+				t.Errorf("failed testcase '%s', mismatch on State\n\nexpected:\n%s\ngot: \n%s\n", k, test.state, s.State)
+			}
+			var hassh string
+			if s.Client.HASSH != nil {
+				hassh = s.Client.Hassh
+			}
+			if hassh != test.hassh {
+				t.Errorf("failed testcase '%s', mismatch on hassh\n\nexpected:\n%s\ngot: \n%s\n", k, test.hassh, hassh)
+			}
+		})
+	}
+}
+
+func TestReassembledSG(t *testing.T) {
+	banner := []byte("SSH-2.0-OpenSSH_7.4\r\n")
+	for k, test := range map[string]struct {
+		pending []byte
+		sg      testSG
+		banner  bool // the client banner is decoded
+		kept    int  // bytes pending for the next chunk
+	}{
+		"empty":            {sg: testSG{}},
+		"empty, pending":   {pending: banner[:5], sg: testSG{}, kept: 5},
+		"known start":      {sg: testSG{data: banner}, banner: true},
+		"unknown start":    {sg: testSG{skip: -1, data: banner}, banner: true},
+		"missing bytes":    {pending: banner[:5], sg: testSG{skip: 10, data: banner}},
+		"truncated":        {sg: testSG{data: banner[:5]}, kept: 5},
+		"pending complete": {pending: banner[:5], sg: testSG{data: banner[5:]}, banner: true},
+	} {
+		t.Run(k, func(t *testing.T) {
+			opts = options.DefaultOptions()
+			stream := testStream()
+			dec := stream.decoder(reassembly.TCPDirClientToServer)
+			dec.pending = append([]byte(nil), test.pending...)
+
+			stream.ReassembledSG(&test.sg, &Context{})
+			if banner := stream.sshSession.Handshake().Has(StateClientBanner); banner != test.banner {
+				t.Errorf("mismatch on client banner decoded, expected %t", test.banner)
+			}
+			if kept := len(dec.pending); kept != test.kept {
+				t.Errorf("mismatch on pending bytes\n\nexpected:\n%d\ngot: \n%d\n", test.kept, kept)
+			}
+		})
+	}
+}
+
+// TestDecoderState sends the banners and the KEXINITs in chunks of their
+// own: each direction carries on from where its previous chunk stopped.
+func TestDecoderState(t *testing.T) {
+	opts = options.DefaultOptions()
+	banner := []byte("SSH-2.0-OpenSSH_7.4\r\n")
+	s := replay(t, []testSegment{
+		{client: true, data: banner},
+		{data: banner},
+		{client: true, data: testClientData[len(banner):]},
+		{data: testServerData[len(banner):]},
+	})
+	if s.State != ConnComplete {
+		t.Errorf("mismatch on State\n\nexpected:\n%s\ngot: \n%s\n", ConnComplete, s.State)
+	}
+	if s.Client.HASSH == nil || s.Client.Hassh != "ec9ea89c70f5fc71cf61061bff5e4740" {
+		t.Errorf("mismatch on hassh, got %+v", s.Client.HASSH)
+	}
+	if s.Server.HASSHServer == nil || s.Server.HasshServer != "6832f1ce43d4397c2c0a3e2f8c94334e" {
+		t.Errorf("mismatch on hasshServer, got %+v", s.Server.HASSHServer)
+	}
+}
+
+// BenchmarkReassembledSG times the decoding of the chunks of a connection
+// by ReassembledSG, without the assembler and the building of the packets.
+func BenchmarkReassembledSG(b *testing.B) {
+	opts = options.DefaultOptions()
+	var chunks []*testSG
+	for _, s := range testStreams["Missing TCP handshake with key exchange"].segments {
+		sg := &testSG{data: s.data}
+		if !s.client {
+			sg.dir = reassembly.TCPDirServerToClient
+		}
+		chunks = append(chunks, sg)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		stream := testStream()
+		jobQ = make(chan SSHSession, 1)
+		b.StartTimer()
+		for _, sg := range chunks {
+			stream.ReassembledSG(sg, &Context{})
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics are always counted, but only exposed when -metrics is given.
+var metrics = struct {
+	packets        prometheus.Counter
+	sessions       prometheus.Counter
+	partials       prometheus.Counter
+	dropped        prometheus.Counter
+	errors         *prometheus.CounterVec
+	flushedStreams prometheus.Counter
+	closedStreams  prometheus.Counter
+}{
+	packets: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hassh_packets_total",
+		Help: "Number of packets read.",
+	}),
+	sessions: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hassh_sessions_emitted_total",
+		Help: "Number of sessions written.",
+	}),
+	partials: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hassh_sessions_partial_total",
+		Help: "Number of sessions queued without a complete key exchange.",
+	}),
+	dropped: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hassh_sessions_dropped_total",
+		Help: "Number of sessions dropped because the output queue was full.",
+	}),
+	errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hassh_errors_total",
+		Help: "Number of errors by type.",
+	}, []string{"type"}),
+	flushedStreams: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hassh_assembler_flushed_total",
+		Help: "Number of streams flushed by the assembler.",
+	}),
+	closedStreams: prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hassh_assembler_closed_total",
+		Help: "Number of streams closed by the assembler.",
+	}),
+}
+
+// serveMetrics starts the listener of /metrics and /healthz in the
+// background, serving those enabled.
+func serveMetrics() {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		metrics.packets,
+		metrics.sessions,
+		metrics.partials,
+		metrics.dropped,
+		metrics.errors,
+		metrics.flushedStreams,
+		metrics.closedStreams,
+	)
+
+	mux := http.NewServeMux()
+	if opts.Metrics {
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	}
+	if opts.Healthz {
+		health.lastPacket.Store(time.Now().UnixNano())
+		mux.HandleFunc("/healthz", healthz)
+	}
+	go func() {
+		Info("Metrics listener on %d\n", opts.MetricsPort)
+		err := http.ListenAndServe(fmt.Sprintf("%s:%d", opts.MetricsAddr, opts.MetricsPort), mux)
+		Error("Metrics", "Metrics listener stopped: %s\n", err)
+	}()
+}
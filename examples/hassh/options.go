@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kjelle/gohassh/essh"
+	"github.com/kjelle/gohassh/options"
+)
+
+// Options are those of the options package.
+type Options = options.Options
+
+// RegisterFlags defines the command line flags in fs, with the current
+// values of o as defaults.
+func RegisterFlags(o *Options, fs *flag.FlagSet) {
+	fs.IntVar(&o.StatsEvery, "stats", o.StatsEvery, "Output statistics every N packets")
+	fs.BoolVar(&o.NoDefrag, "nodefrag", o.NoDefrag, "If true, do not do IPv4 and IPv6 defrag")
+	fs.BoolVar(&o.Checksum, "checksum", o.Checksum, "Check TCP checksum")
+	fs.BoolVar(&o.NoOptCheck, "nooptcheck", o.NoOptCheck, "Do not check TCP options (useful to ignore MSS on captures with TSO)")
+	fs.BoolVar(&o.IgnoreFSMErr, "ignorefsmerr", o.IgnoreFSMErr, "Ignore TCP FSM errors")
+	fs.BoolVar(&o.Midstream, "midstream", o.Midstream, "Accept streams where the TCP handshake was not captured")
+	fs.IntVar(&o.AssumeClientPort, "assume-client-port", o.AssumeClientPort, "Take the side on this port for the client in streams without a captured handshake, instead of inferring it")
+	fs.DurationVar(&o.FlushTimeout, "flush-timeout", o.FlushTimeout, "Flush pending bytes of streams older than this")
+	fs.DurationVar(&o.CloseTimeout, "close-timeout", o.CloseTimeout, "Close streams inactive for longer than this")
+	fs.DurationVar(&o.IdleTimeout, "flush-on-idle", o.IdleTimeout, "Flush and close streams without packets for longer than this, checked on a timer rather than every -stats packets, for links with little traffic. 0 disables it")
+	fs.DurationVar(&o.MaxSessionAge, "max-session-age", o.MaxSessionAge, "Write the session and stop buffering streams first seen longer ago than this, even if still active, 0 for no limit")
+	fs.StringVar(&o.FlushLog, "flush-log", o.FlushLog, "Write a JSON record for every assembler flush to this file, - for stderr")
+	fs.BoolVar(&o.Partial, "partial", o.Partial, "Write sessions without a complete key exchange when the stream is closed, marked partial")
+	fs.BoolVar(&o.Verbose, "verbose", o.Verbose, "Be verbose")
+	fs.BoolVar(&o.Debug, "debug", o.Debug, "Display debug information")
+	fs.BoolVar(&o.Quiet, "quiet", o.Quiet, "Be quiet regarding errors")
+	fs.BoolVar(&o.LogJSON, "logjson", o.LogJSON, "Write logs as JSON")
+
+	fs.BoolVar(&o.Selftest, "selftest", o.Selftest, "Fingerprint a bundled OpenSSH sample, print PASS or FAIL and exit")
+	fs.BoolVar(&o.Check, "check", o.Check, "Check the inputs can be opened with the BPF filter and the output folder is writable, then exit")
+	fs.BoolVar(&o.HexDump, "dumppkt", o.HexDump, "Dump packet as hex")
+
+	// capture
+	fs.StringVar(&o.Interface, "i", o.Interface, "Interfaces to read packets from, comma-separated")
+	fs.IntVar(&o.Snaplen, "s", o.Snaplen, "Snap length (number of bytes max to read per packet")
+	fs.IntVar(&o.Snaplen, "snaplen", o.Snaplen, "Same as -s, a KEXINIT beyond the snap length is lost")
+	fs.BoolVar(&o.Promisc, "promisc", o.Promisc, "Put live interfaces in promiscuous mode")
+	fs.DurationVar(&o.Timeout, "timeout", o.Timeout, "Read timeout of live captures, 0 blocks until packets arrive")
+	fs.StringVar(&o.Capture, "capture", o.Capture, "Live capture method: pcap, or afpacket on Linux when built with -tags afpacket")
+	fs.IntVar(&o.AFPacketBlockSize, "afpacket-block-size", o.AFPacketBlockSize, "Bytes per block of the AF_PACKET ring, a multiple of the page size and of the frame size (the snap length rounded up to pages)")
+	fs.IntVar(&o.AFPacketFrames, "afpacket-frames", o.AFPacketFrames, "Number of frames in the AF_PACKET ring")
+	fs.StringVar(&o.Decap, "decap", o.Decap, "Unwrap packets from a vxlan or gre tunnel, as sent by cloud traffic mirroring, and record the tunnel endpoints in the session")
+	fs.StringVar(&o.Manifest, "manifest", o.Manifest, "File listing pcap files to read, one per line, after those given with -r")
+	fs.Var((*inputFiles)(&o.Files), "r", "Filename to read from, overrides -i. Repeat to read several files in order")
+
+	// decoding
+	fs.BoolVar(&o.JA4SSH, "ja4ssh", o.JA4SSH, "Compute the JA4SSH of the packets following the key exchange over a window of packets")
+	fs.IntVar(&o.JA4SSHPackets, "ja4ssh-packets", o.JA4SSHPackets, "Number of packets in the JA4SSH window")
+	fs.IntVar(&o.MinAlgorithms, "min-algorithms", o.MinAlgorithms, "Number of key exchange algorithms, pseudo-algorithms aside, and ciphers in each direction a KEXINIT must offer to be fingerprinted, others mark the session malformed. 0 disables the check")
+	fs.BoolVar(&o.ProtoDetail, "proto-detail", o.ProtoDetail, "Write the packet and padding lengths of the KEXINITs")
+	fs.BoolVar(&o.HASSHFull, "hassh-full", o.HASSHFull, "Also write hasshFull, the MD5 of all ten KEXINIT name-lists. It is a stricter superset of HASSH, not comparable against HASSH databases")
+	fs.BoolVar(&o.HASSHInput, "hassh-input", o.HASSHInput, "Write hasshInput and hasshServerInput, the name-lists the MD5 of the HASSH values are computed over")
+	fs.BoolVar(&o.BannerRaw, "banner-raw", o.BannerRaw, "Write every byte up to and including the version string lines exactly as seen, base64 encoded, their terminator: \\r\\n or \\n, and the lines a server sent before")
+	fs.StringVar(&o.GeoIP, "geoip", o.GeoIP, "MaxMind databases, comma-separated, such as GeoLite2-Country.mmdb,GeoLite2-ASN.mmdb: write the country and autonomous system of the client and server as src_geo and dest_geo")
+	fs.BoolVar(&o.ImplCheck, "impl-check", o.ImplCheck, "Infer the implementations from the HASSH and flag sessions whose banners claim another software")
+	fs.StringVar(&o.ImplMap, "impl-map", o.ImplMap, "JSON object of HASSH digests to implementations, as named in software_info.product, merged over the built-in ones for -impl-check")
+	fs.BoolVar(&o.WeakAlgorithms, "weak-algorithms", o.WeakAlgorithms, "Write the known-weak algorithms the client and server offer, such as arcfour, CBC ciphers, hmac-md5, hmac-sha1, diffie-hellman-group1-sha1 and ssh-dss, as weak_algorithms")
+	fs.StringVar(&o.Policy, "policy", o.Policy, "Check the algorithms the client and server offer against a policy, the built-in modern of the Mozilla OpenSSH guidelines or a JSON file with name and kex, host_key, ciphers and macs lists of allowed patterns, and write the verdict and violating algorithms as policy")
+	fs.BoolVar(&o.DetectEvasion, "detect-evasion", o.DetectEvasion, "Flag sessions running SSH on ports of other protocols commonly allowed through firewalls, such as 443, 80 or 53")
+	fs.BoolVar(&o.SSH1Alert, "ssh1-alert", o.SSH1Alert, "Flag sessions whose server still advertises SSH protocol 1, as protocol version 1.99 or 1.5, as ssh1_server and log a warning for each")
+	fs.Var((*nameListPolicy)(&o.NameListPolicy), "namelist-policy", "Handling of KEXINIT names outside the RFC 4251 grammar: accept, reject or sanitize")
+	fs.IntVar(&o.MaxNameListNames, "max-namelist-names", o.MaxNameListNames, "Names allowed in each KEXINIT name-list, larger KEXINITs mark the session malformed. 0 means 512")
+	fs.IntVar(&o.MaxNameListBytes, "max-namelist-bytes", o.MaxNameListBytes, "Bytes allowed in all KEXINIT name-lists together, larger KEXINITs mark the session malformed. 0 means 32768")
+
+	// writing
+	fs.BoolVar(&o.JSONIndent, "jsonindent", o.JSONIndent, "Write JSON with indent")
+	fs.StringVar(&o.Schema, "schema", o.Schema, "JSON records written: gohassh sessions, or hassh for one line per KEXINIT with the fields of the salesforce/hassh Python tool, for parsers built for it")
+	fs.BoolVar(&o.Proto, "proto", o.Proto, "Write sessions as protobuf records, each preceded by its length as a varint, instead of JSON. The message is defined in sessionpb/session.proto")
+	fs.StringVar(&o.TimestampSource, "timestamp-source", o.TimestampSource, "Time given as the timestamp of sessions: first-packet of the stream, first banner, or first kexinit. All three are written as first_seen, banner_time and kexinit_time")
+	fs.StringVar(&o.CertsDir, "w", o.CertsDir, "Folder to write certificates into")
+	fs.StringVar(&o.OutputDir, "j", o.OutputDir, "Folder to write certificates into, stdin if not set")
+	fs.StringVar(&o.OutputFile, "f", o.OutputFile, "Output all captures to a single filename")
+	fs.BoolVar(&o.JSONArray, "json-array", o.JSONArray, "Write the sessions of -f as a single JSON array, closed at shutdown, instead of one after the other. The file is overwritten rather than appended to")
+	fs.StringVar(&o.UnixSocket, "unix-socket", o.UnixSocket, "Write sessions as NDJSON to a collector listening on this Unix domain socket, reconnecting when it goes away, instead of -j or stdout")
+	fs.StringVar(&o.FilenameTemplate, "filename-template", o.FilenameTemplate, "Template naming the per-session files in -j, with fields .Timestamp, .ClientIP, .ClientPort, .ServerIP, .ServerPort, .HASSH and .HASSHServer")
+	fs.IntVar(&o.OutputWorkers, "output-workers", o.OutputWorkers, "Number of workers marshalling and writing sessions, sessions are written in any order unless -sort is given")
+	fs.IntVar(&o.QueueSize, "queue-size", o.QueueSize, "Number of completed sessions which can wait to be written")
+	fs.DurationVar(&o.QueueTimeout, "queue-timeout", o.QueueTimeout, "How long to wait for room in a full queue before dropping a session")
+	fs.BoolVar(&o.Sort, "sort", o.Sort, "Write sessions sorted by timestamp at the end of the run, only when reading from files")
+	fs.StringVar(&o.HASSHAllow, "hassh-allow", o.HASSHAllow, "File of newline-separated known-good digests, matching sessions are not written")
+	fs.StringVar(&o.HASSHDeny, "hassh-deny", o.HASSHDeny, "File of newline-separated watched digests, only matching sessions are written")
+	fs.StringVar(&o.HASSHMatch, "hassh-match", o.HASSHMatch, "Digests -hassh-allow and -hassh-deny apply to: client, server or both")
+	fs.Var((*cidrList)(&o.ClientCIDR), "client-cidr", "Only write sessions whose client is in this network, such as 192.0.2.0/24. Repeat to allow several networks")
+	fs.Var((*cidrList)(&o.ServerCIDR), "server-cidr", "Only write sessions whose server is in this network. Repeat to allow several networks, combined with -client-cidr both sides must match")
+	fs.Float64Var(&o.SampleRate, "sample-rate", o.SampleRate, "Fraction of the connections written, from 0.0 to 1.0. A connection is sampled in or out by a hash of its addresses and ports, the same way across restarts")
+	fs.BoolVar(&o.Aggregate, "aggregate", o.Aggregate, "Instead of sessions, write the distinct hassh and hasshServer values with their counts and example addresses at the end of the run, to stdout or aggregate.json in the -j folder")
+	fs.BoolVar(&o.BannersOnly, "banners-only", o.BannersOnly, "Only capture the banners, stop decoding streams once both banners are seen")
+	fs.BoolVar(&o.ClientOnly, "client-only", o.ClientOnly, "Only decode the client, write sessions as soon as the client KEXINIT is seen")
+	fs.IntVar(&o.Limit, "limit", o.Limit, "Stop after N complete sessions have been written, 0 means no limit")
+
+	// debugging
+	fs.BoolVar(&o.Pprof, "pprof", o.Pprof, "enabling net/http/pprof")
+	fs.StringVar(&o.PprofAddr, "pprofint", o.PprofAddr, "interface to listen to")
+	fs.IntVar(&o.PprofPort, "pprofport", o.PprofPort, "port to listen for pprof")
+	fs.BoolVar(&o.Metrics, "metrics", o.Metrics, "enabling Prometheus /metrics endpoint")
+	fs.StringVar(&o.MetricsAddr, "metricsint", o.MetricsAddr, "interface to listen to for metrics")
+	fs.IntVar(&o.MetricsPort, "metricsport", o.MetricsPort, "port to listen for metrics")
+	fs.BoolVar(&o.Healthz, "healthz", o.Healthz, "enabling /healthz endpoint on the metrics listener, 200 while capturing and 503 once shutting down")
+	fs.DurationVar(&o.HealthzLiveness, "healthz-liveness", o.HealthzLiveness, "/healthz also fails when no packet was read for this long, 0 disables it")
+}
+
+// inputFiles collects the filenames given by repeated -r flags.
+type inputFiles []string
+
+func (f *inputFiles) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+func (f *inputFiles) Set(fn string) error {
+	*f = append(*f, fn)
+	return nil
+}
+
+// nameListPolicy parses the -namelist-policy flag.
+type nameListPolicy essh.NameListPolicy
+
+var nameListPolicies = []string{
+	essh.NameListAccept:   "accept",
+	essh.NameListReject:   "reject",
+	essh.NameListSanitize: "sanitize",
+}
+
+func (p *nameListPolicy) String() string {
+	if p == nil || int(*p) >= len(nameListPolicies) {
+		return ""
+	}
+	return nameListPolicies[*p]
+}
+
+func (p *nameListPolicy) Set(s string) error {
+	for i, name := range nameListPolicies {
+		if s == name {
+			*p = nameListPolicy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s", strings.Join(nameListPolicies, ", "))
+}
+
+// readManifest returns the filenames listed in the manifest, one per line.
+// Blank lines and lines starting with # are ignored.
+func readManifest(fn string) ([]string, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		files = append(files, line)
+	}
+	return files, scanner.Err()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/kjelle/gohassh/essh"
+	"github.com/kjelle/gohassh/session"
+)
+
+// algorithmPolicy lists, as patterns matched by path.Match, the algorithms a
+// side may offer. An empty list allows any algorithm of its kind.
+type algorithmPolicy struct {
+	Name    string   `json:"name"`
+	Kex     []string `json:"kex"`
+	HostKey []string `json:"host_key"`
+	Ciphers []string `json:"ciphers"`
+	MACs    []string `json:"macs"`
+}
+
+// policyModern is the built-in "modern" policy, the OpenSSH configuration
+// of the Mozilla security guidelines.
+var policyModern = &algorithmPolicy{
+	Name: "modern",
+	Kex: []string{
+		"curve25519-sha256",
+		"curve25519-sha256@libssh.org",
+		"ecdh-sha2-nistp521",
+		"ecdh-sha2-nistp384",
+		"ecdh-sha2-nistp256",
+		"diffie-hellman-group-exchange-sha256",
+	},
+	HostKey: []string{
+		"ssh-ed25519",
+		"ssh-ed25519-cert-v01@openssh.com",
+		"rsa-sha2-512",
+		"rsa-sha2-512-cert-v01@openssh.com",
+		"rsa-sha2-256",
+		"rsa-sha2-256-cert-v01@openssh.com",
+		"ecdsa-sha2-nistp*",
+	},
+	Ciphers: []string{
+		"chacha20-poly1305@openssh.com",
+		"aes256-gcm@openssh.com",
+		"aes128-gcm@openssh.com",
+		"aes256-ctr",
+		"aes192-ctr",
+		"aes128-ctr",
+	},
+	MACs: []string{
+		"hmac-sha2-512-etm@openssh.com",
+		"hmac-sha2-256-etm@openssh.com",
+		"umac-128-etm@openssh.com",
+		"hmac-sha2-512",
+		"hmac-sha2-256",
+		"umac-128@openssh.com",
+	},
+}
+
+// policy sessions are checked against with -policy, nil if not given.
+var policy *algorithmPolicy
+
+// readPolicy sets the policy to the built-in one of that name, or else to
+// the JSON object of algorithmPolicy in the file. A policy file without a
+// name is named after the file.
+func readPolicy(fn string) error {
+	if fn == policyModern.Name {
+		policy = policyModern
+		return nil
+	}
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+	p := &algorithmPolicy{}
+	if err := json.Unmarshal(b, p); err != nil {
+		return err
+	}
+	if p.Name == "" {
+		p.Name = fn
+	}
+	policy = p
+	return nil
+}
+
+// checkPolicy sets, with -policy, if the client and server offer only the
+// algorithms of the policy in their first KEXINIT, and those they offer
+// besides.
+func checkPolicy(s *SSHSession) {
+	if policy == nil {
+		return
+	}
+	if c := s.Client.ClientKexinit; c != nil {
+		s.Client.Policy = policy.check(c.KexAlgos, c.ServerHostKeyAlgos, c.CiphersClientServer, c.CiphersServerClient, c.MACsClientServer, c.MACsServerClient)
+	}
+	if sr := s.Server.ServerKexinit; sr != nil {
+		s.Server.Policy = policy.check(sr.KexAlgos, sr.ServerHostKeyAlgos, sr.CiphersClientServer, sr.CiphersServerClient, sr.MACsClientServer, sr.MACsServerClient)
+	}
+}
+
+// check returns the names of the name-lists the policy does not allow, once
+// each, in the order they are offered. Pseudo-algorithms are not checked.
+func (p *algorithmPolicy) check(kex, hostKey, ciphersCS, ciphersSC, macsCS, macsSC string) *session.PolicyResult {
+	var violations []string
+	seen := make(map[string]bool)
+	add := func(nl string, allowed []string) {
+		if len(allowed) == 0 {
+			return
+		}
+		for _, name := range strings.Split(nl, ",") {
+			if name == "" || seen[name] || essh.IsPseudoAlgorithm(name) || matchAny(allowed, name) {
+				continue
+			}
+			seen[name] = true
+			violations = append(violations, name)
+		}
+	}
+	add(kex, p.Kex)
+	add(hostKey, p.HostKey)
+	add(ciphersCS, p.Ciphers)
+	add(ciphersSC, p.Ciphers)
+	add(macsCS, p.MACs)
+	add(macsSC, p.MACs)
+	return &session.PolicyResult{
+		Name:       p.Name,
+		Pass:       len(violations) == 0,
+		Violations: violations,
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
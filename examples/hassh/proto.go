@@ -0,0 +1,220 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/kjelle/gohassh/examples/hassh/sessionpb"
+	"github.com/kjelle/gohassh/session"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// sessionExt is the extension of the per-session files in -j.
+func sessionExt() string {
+	if opts.Proto {
+		return ".pb"
+	}
+	return ".json"
+}
+
+// toProto converts the session to the message written with -proto. The
+// enrichment is left out, its values have no fixed type.
+func toProto(t SSHSession) *sessionpb.Session {
+	s := &sessionpb.Session{
+		Timestamp:              timestamppb.New(t.Timestamp),
+		Uid:                    t.UID,
+		InIface:                t.InIface,
+		SourceFile:             t.SourceFile,
+		EventType:              t.EventType,
+		HasshVersion:           t.HasshVersion,
+		SrcIp:                  t.ClientIP,
+		SrcPort:                protoPort(t.ClientPort),
+		DestIp:                 t.ServerIP,
+		DestPort:               protoPort(t.ServerPort),
+		Proto:                  t.Protocol,
+		SrcMac:                 t.ClientMAC,
+		Vlan:                   uint32(t.VLAN),
+		SrcGeo:                 protoGeo(t.ClientGeo),
+		DestGeo:                protoGeo(t.ServerGeo),
+		FirstSeen:              timestamppb.New(t.FirstSeen),
+		BannerTime:             protoTime(t.BannerTime),
+		KexinitTime:            protoTime(t.KexinitTime),
+		DirectionInferred:      t.DirectionInferred,
+		EvasionPort:            t.EvasionPort,
+		Partial:                t.Partial,
+		ClientBanner:           t.SeenClientBanner,
+		ServerBanner:           t.SeenServerBanner,
+		ClientKex:              t.SeenClientKex,
+		ServerKex:              t.SeenServerKex,
+		IdentifiedClient:       t.IdentifiedClient,
+		IdentifiedServer:       t.IdentifiedServer,
+		PossibleSpoofedBanner:  t.PossibleSpoofedBanner,
+		Ssh1Server:             t.SSH1Server,
+		ImplementationMismatch: t.ImplementationMismatch,
+		TerrapinVulnerable:     t.TerrapinVulnerable,
+		Client:                 protoRecord(t.Client),
+		Server:                 protoRecord(t.Server),
+		Anomalies: &sessionpb.Anomalies{
+			OverlapBytes:      uint64(t.Anomalies.OverlapBytes),
+			OverlapPackets:    uint64(t.Anomalies.OverlapPackets),
+			OutOfOrderBytes:   uint64(t.Anomalies.OutOfOrderBytes),
+			OutOfOrderPackets: uint64(t.Anomalies.OutOfOrderPackets),
+			MissedBytes:       uint64(t.Anomalies.MissedBytes),
+		},
+		BannersOnly:       t.BannersOnly,
+		ClientOnly:        t.ClientOnly,
+		State:             t.State,
+		KexExchangeSeen:   t.KexExchangeSeen,
+		KexdhInitLength:   uint32(t.KexDHInitLength),
+		KexdhReplyLength:  uint32(t.KexDHReplyLength),
+		KexGexGroupBits:   uint32(t.KexGexGroupBits),
+		HandshakeComplete: t.HandshakeComplete,
+		RekeyCount:        uint32(t.RekeyCount),
+		Ja4Ssh:            t.JA4SSH,
+	}
+	for _, ts := range t.RekeyTimes {
+		s.RekeyTimes = append(s.RekeyTimes, timestamppb.New(ts))
+	}
+	if n := t.Negotiated; n != nil {
+		s.Negotiated = &sessionpb.Negotiated{
+			Kex:            n.Kex,
+			HostKey:        n.HostKey,
+			ClientToServer: protoDirection(n.ClientServer),
+			ServerToClient: protoDirection(n.ServerClient),
+		}
+	}
+	if k := t.KexGexRequest; k != nil {
+		s.KexGexRequest = &sessionpb.KexGexRequest{Min: k.Min, N: k.N, Max: k.Max}
+	}
+	if t.Tunnel != nil {
+		s.Tunnel = &sessionpb.Tunnel{
+			Type:   t.Tunnel.Type,
+			SrcIp:  t.Tunnel.SrcIP,
+			DestIp: t.Tunnel.DestIP,
+			Id:     t.Tunnel.ID,
+		}
+	}
+	return s
+}
+
+func protoDirection(d session.Direction) *sessionpb.Direction {
+	return &sessionpb.Direction{Cipher: d.Cipher, Mac: d.MAC, Compression: d.Compression}
+}
+
+func protoRecord(r SSHRecord) *sessionpb.Record {
+	p := &sessionpb.Record{
+		InferredImplementation: r.InferredImplementation,
+		WeakAlgorithms:         r.WeakAlgorithms,
+		SupportsExtInfo:        r.SupportsExtInfo,
+		SupportsStrictKex:      r.SupportsStrictKex,
+	}
+	if b := r.ESSHBannerRecord; b != nil {
+		p.ProtoVersion = b.ProtoVersion
+		p.SoftwareVersion = b.SoftwareVersion
+		p.Comments = b.Comments
+		p.PreambleBytes = uint32(b.PreambleBytes)
+		p.Raw = b.Raw
+		p.Terminator = b.Terminator
+		p.PreBannerLines = b.PreBannerLines
+	}
+	if si := r.SoftwareInfo; si != nil {
+		p.SoftwareInfo = &sessionpb.SoftwareInfo{
+			Product:   si.Product,
+			Version:   si.Version,
+			OsComment: si.OSComment,
+		}
+	}
+	if h := r.HASSH; h != nil {
+		p.Hassh = h.Hassh
+		p.HasshAlgorithms = h.HasshAlgorithms
+		p.HasshVersion = h.HasshVersion
+	}
+	if h := r.HASSHServer; h != nil {
+		p.HasshServer = h.HasshServer
+		p.HasshServerAlgorithms = h.HasshServerAlgorithms
+		p.HasshVersion = h.HasshVersion
+	}
+	if h := r.HASSHFull; h != nil {
+		p.HasshFull = h.HasshFull
+		p.HasshFullAlgorithms = h.HasshFullAlgorithms
+	}
+	if in := r.HASSHInput; in != nil {
+		p.HasshInput = &sessionpb.NameLists{Kex: in.Kex, Enc: in.Enc, Mac: in.MAC, Comp: in.Comp}
+	}
+	if in := r.HASSHServerInput; in != nil {
+		p.HasshInput = &sessionpb.NameLists{Kex: in.Kex, Enc: in.Enc, Mac: in.MAC, Comp: in.Comp}
+	}
+	if pr := r.Policy; pr != nil {
+		p.Policy = &sessionpb.PolicyResult{Name: pr.Name, Pass: pr.Pass, Violations: pr.Violations}
+	}
+	if k := r.SSH1PublicKey; k != nil {
+		p.Ssh1PublicKey = &sessionpb.SSH1PublicKey{
+			ServerKeyBits: k.ServerKeyBits,
+			HostKeyBits:   k.HostKeyBits,
+			ProtocolFlags: k.ProtocolFlags,
+			CiphersMask:   k.CiphersMask,
+			AuthMask:      k.AuthMask,
+			Ciphers:       k.Ciphers,
+			Auths:         k.Auths,
+		}
+	}
+	if k := r.SSH1SessionKey; k != nil {
+		p.Ssh1SessionKey = &sessionpb.SSH1SessionKey{CipherType: uint32(k.CipherType), Cipher: k.Cipher, ProtocolFlags: k.ProtocolFlags}
+	}
+	if k := r.HostKey; k != nil {
+		p.HostKey = &sessionpb.HostKey{Type: k.Type, Blob: k.Blob, Sha256: k.SHA256, Md5: k.MD5}
+		if c := k.Certificate; c != nil {
+			p.HostKey.Certificate = &sessionpb.Certificate{
+				Serial:        c.Serial,
+				CertType:      c.CertType,
+				KeyId:         c.KeyID,
+				Principals:    c.Principals,
+				ValidAfter:    c.ValidAfter,
+				ValidBefore:   c.ValidBefore,
+				CaKeyType:     c.CAKeyType,
+				CaFingerprint: c.CAFingerprint,
+			}
+		}
+	}
+	if h := r.KexinitHeader; h != nil {
+		p.KexinitHeader = &sessionpb.RecordHeader{
+			PacketLength:  h.PacketLength,
+			PaddingLength: uint32(h.PaddingLength),
+			MessageCode:   uint32(h.MessageCode),
+		}
+	}
+	for _, rk := range r.Rekeys {
+		k := &sessionpb.Rekey{Timestamp: timestamppb.New(rk.Timestamp)}
+		if rk.HASSH != nil {
+			k.Hassh = rk.HASSH.Hassh
+			k.HasshAlgorithms = rk.HASSH.HasshAlgorithms
+		}
+		if rk.HASSHServer != nil {
+			k.HasshServer = rk.HASSHServer.HasshServer
+			k.HasshServerAlgorithms = rk.HASSHServer.HasshServerAlgorithms
+		}
+		p.Rekeys = append(p.Rekeys, k)
+	}
+	return p
+}
+
+func protoGeo(g *Geo) *sessionpb.Geo {
+	if g == nil {
+		return nil
+	}
+	return &sessionpb.Geo{Country: g.Country, Asn: uint32(g.ASN), Org: g.Org}
+}
+
+func protoTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// protoPort returns the port of the session as a number, 0 if it is not
+// one.
+func protoPort(port string) uint32 {
+	p, _ := strconv.ParseUint(port, 10, 16)
+	return uint32(p)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+)
+
+// sample reports, with -sample-rate below 1, if the session is sampled in,
+// and counts the decisions. It hashes the 4-tuple rather than drawing a
+// random number, so that a connection is sampled the same way across
+// restarts and by every sensor seeing it.
+func sample(t SSHSession) bool {
+	if opts.SampleRate >= 1 {
+		return true
+	}
+	h := sha1.New()
+	for _, f := range []string{t.ClientIP, t.ClientPort, t.ServerIP, t.ServerPort} {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	// The first 53 bits, as a fraction of 1
+	if float64(binary.BigEndian.Uint64(h.Sum(nil))>>11)/(1<<53) < opts.SampleRate {
+		stats.sampledIn++
+		return true
+	}
+	stats.sampledOut++
+	return false
+}
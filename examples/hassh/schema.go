@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/kjelle/gohassh"
+	"github.com/kjelle/gohassh/essh"
+	"github.com/kjelle/gohassh/options"
+)
+
+// Values of -schema
+const (
+	SchemaGoHASSH = options.SchemaGoHASSH
+	SchemaHASSH   = options.SchemaHASSH
+)
+
+// hasshTimeFormat is how hassh.py writes the capture time of the packets
+const hasshTimeFormat = "2006-01-02T15:04:05.000000"
+
+// hasshClientRecord is the record hassh.py writes for a client KEXINIT,
+// with the ten name-lists under their ckex, cshka, ... names
+type hasshClientRecord struct {
+	Timestamp       string `json:"timestamp"`
+	SourceIP        string `json:"sourceIp"`
+	DestinationIP   string `json:"destinationIp"`
+	SourcePort      string `json:"sourcePort"`
+	DestinationPort string `json:"destinationPort"`
+	Client          string `json:"client"` // identification string
+	*gohassh.ClientRecord
+}
+
+// hasshServerRecord is the record hassh.py writes for a server KEXINIT, the
+// source being the server
+type hasshServerRecord struct {
+	Timestamp       string `json:"timestamp"`
+	SourceIP        string `json:"sourceIp"`
+	DestinationIP   string `json:"destinationIp"`
+	SourcePort      string `json:"sourcePort"`
+	DestinationPort string `json:"destinationPort"`
+	Server          string `json:"server"` // identification string
+	*gohassh.ServerRecord
+}
+
+// hasshRecords returns the records hassh.py would write for the session,
+// one for each KEXINIT of the client and server, in that order. Rekeys are
+// left out.
+func hasshRecords(t SSHSession) []interface{} {
+	ts := t.Timestamp
+	if t.KexinitTime != nil {
+		ts = *t.KexinitTime
+	}
+	timestamp := ts.UTC().Format(hasshTimeFormat)
+
+	var records []interface{}
+	if t.Client.ClientKexinit != nil {
+		records = append(records, hasshClientRecord{
+			Timestamp:       timestamp,
+			SourceIP:        t.ClientIP,
+			DestinationIP:   t.ServerIP,
+			SourcePort:      t.ClientPort,
+			DestinationPort: t.ServerPort,
+			Client:          identification(t.Client.ESSHBannerRecord),
+			ClientRecord:    t.Client.ClientKexinit,
+		})
+	}
+	if t.Server.ServerKexinit != nil {
+		records = append(records, hasshServerRecord{
+			Timestamp:       timestamp,
+			SourceIP:        t.ServerIP,
+			DestinationIP:   t.ClientIP,
+			SourcePort:      t.ServerPort,
+			DestinationPort: t.ClientPort,
+			Server:          identification(t.Server.ESSHBannerRecord),
+			ServerRecord:    t.Server.ServerKexinit,
+		})
+	}
+	return records
+}
+
+// identification returns the identification string of the banner, without
+// its line terminator, empty without a banner.
+func identification(b *essh.ESSHBannerRecord) string {
+	if b == nil {
+		return ""
+	}
+	id := "SSH-" + b.ProtoVersion + "-" + b.SoftwareVersion
+	if b.Comments != "" {
+		id += " " + b.Comments
+	}
+	return id
+}
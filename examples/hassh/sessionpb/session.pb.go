@@ -0,0 +1,2190 @@
+// Sessions as written with -proto, each preceded by its length as a varint.
+// The messages mirror the JSON sessions, see SSHSession, except for the
+// free-form enrichment. Ports are numbers rather than strings.
+//
+// Regenerate session.pb.go with:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative session.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        v5.27.3
+// source: session.proto
+
+package sessionpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Session struct {
+	state                  protoimpl.MessageState   `protogen:"open.v1"`
+	Timestamp              *timestamppb.Timestamp   `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Uid                    string                   `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	InIface                string                   `protobuf:"bytes,3,opt,name=in_iface,json=inIface,proto3" json:"in_iface,omitempty"`
+	SourceFile             string                   `protobuf:"bytes,4,opt,name=source_file,json=sourceFile,proto3" json:"source_file,omitempty"`
+	EventType              string                   `protobuf:"bytes,5,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	SrcIp                  string                   `protobuf:"bytes,6,opt,name=src_ip,json=srcIp,proto3" json:"src_ip,omitempty"`
+	SrcPort                uint32                   `protobuf:"varint,7,opt,name=src_port,json=srcPort,proto3" json:"src_port,omitempty"`
+	DestIp                 string                   `protobuf:"bytes,8,opt,name=dest_ip,json=destIp,proto3" json:"dest_ip,omitempty"`
+	DestPort               uint32                   `protobuf:"varint,9,opt,name=dest_port,json=destPort,proto3" json:"dest_port,omitempty"`
+	Proto                  string                   `protobuf:"bytes,10,opt,name=proto,proto3" json:"proto,omitempty"`
+	SrcMac                 string                   `protobuf:"bytes,11,opt,name=src_mac,json=srcMac,proto3" json:"src_mac,omitempty"`
+	Vlan                   uint32                   `protobuf:"varint,12,opt,name=vlan,proto3" json:"vlan,omitempty"`
+	Tunnel                 *Tunnel                  `protobuf:"bytes,13,opt,name=tunnel,proto3" json:"tunnel,omitempty"`
+	SrcGeo                 *Geo                     `protobuf:"bytes,14,opt,name=src_geo,json=srcGeo,proto3" json:"src_geo,omitempty"`
+	DestGeo                *Geo                     `protobuf:"bytes,15,opt,name=dest_geo,json=destGeo,proto3" json:"dest_geo,omitempty"`
+	FirstSeen              *timestamppb.Timestamp   `protobuf:"bytes,16,opt,name=first_seen,json=firstSeen,proto3" json:"first_seen,omitempty"`
+	BannerTime             *timestamppb.Timestamp   `protobuf:"bytes,17,opt,name=banner_time,json=bannerTime,proto3" json:"banner_time,omitempty"`
+	KexinitTime            *timestamppb.Timestamp   `protobuf:"bytes,18,opt,name=kexinit_time,json=kexinitTime,proto3" json:"kexinit_time,omitempty"`
+	DirectionInferred      bool                     `protobuf:"varint,19,opt,name=direction_inferred,json=directionInferred,proto3" json:"direction_inferred,omitempty"`
+	EvasionPort            bool                     `protobuf:"varint,20,opt,name=evasion_port,json=evasionPort,proto3" json:"evasion_port,omitempty"`
+	ImplementationMismatch bool                     `protobuf:"varint,21,opt,name=implementation_mismatch,json=implementationMismatch,proto3" json:"implementation_mismatch,omitempty"`
+	Client                 *Record                  `protobuf:"bytes,22,opt,name=client,proto3" json:"client,omitempty"`
+	Server                 *Record                  `protobuf:"bytes,23,opt,name=server,proto3" json:"server,omitempty"`
+	Anomalies              *Anomalies               `protobuf:"bytes,24,opt,name=anomalies,proto3" json:"anomalies,omitempty"`
+	State                  string                   `protobuf:"bytes,25,opt,name=state,proto3" json:"state,omitempty"`
+	KexExchangeSeen        bool                     `protobuf:"varint,26,opt,name=kex_exchange_seen,json=kexExchangeSeen,proto3" json:"kex_exchange_seen,omitempty"`
+	KexdhInitLength        uint32                   `protobuf:"varint,27,opt,name=kexdh_init_length,json=kexdhInitLength,proto3" json:"kexdh_init_length,omitempty"`
+	KexdhReplyLength       uint32                   `protobuf:"varint,28,opt,name=kexdh_reply_length,json=kexdhReplyLength,proto3" json:"kexdh_reply_length,omitempty"`
+	Ja4Ssh                 string                   `protobuf:"bytes,29,opt,name=ja4ssh,proto3" json:"ja4ssh,omitempty"`
+	HasshVersion           string                   `protobuf:"bytes,30,opt,name=hassh_version,json=hasshVersion,proto3" json:"hassh_version,omitempty"`
+	KexGexRequest          *KexGexRequest           `protobuf:"bytes,31,opt,name=kex_gex_request,json=kexGexRequest,proto3" json:"kex_gex_request,omitempty"`
+	KexGexGroupBits        uint32                   `protobuf:"varint,32,opt,name=kex_gex_group_bits,json=kexGexGroupBits,proto3" json:"kex_gex_group_bits,omitempty"`
+	HandshakeComplete      bool                     `protobuf:"varint,33,opt,name=handshake_complete,json=handshakeComplete,proto3" json:"handshake_complete,omitempty"`
+	RekeyCount             uint32                   `protobuf:"varint,34,opt,name=rekey_count,json=rekeyCount,proto3" json:"rekey_count,omitempty"`
+	RekeyTimes             []*timestamppb.Timestamp `protobuf:"bytes,35,rep,name=rekey_times,json=rekeyTimes,proto3" json:"rekey_times,omitempty"`
+	TerrapinVulnerable     bool                     `protobuf:"varint,36,opt,name=terrapin_vulnerable,json=terrapinVulnerable,proto3" json:"terrapin_vulnerable,omitempty"`
+	Negotiated             *Negotiated              `protobuf:"bytes,37,opt,name=negotiated,proto3" json:"negotiated,omitempty"`
+	Ssh1Server             bool                     `protobuf:"varint,38,opt,name=ssh1_server,json=ssh1Server,proto3" json:"ssh1_server,omitempty"`
+	IdentifiedClient       string                   `protobuf:"bytes,39,opt,name=identified_client,json=identifiedClient,proto3" json:"identified_client,omitempty"`
+	IdentifiedServer       string                   `protobuf:"bytes,40,opt,name=identified_server,json=identifiedServer,proto3" json:"identified_server,omitempty"`
+	PossibleSpoofedBanner  bool                     `protobuf:"varint,41,opt,name=possible_spoofed_banner,json=possibleSpoofedBanner,proto3" json:"possible_spoofed_banner,omitempty"`
+	Partial                bool                     `protobuf:"varint,42,opt,name=partial,proto3" json:"partial,omitempty"`
+	ClientBanner           bool                     `protobuf:"varint,43,opt,name=client_banner,json=clientBanner,proto3" json:"client_banner,omitempty"`
+	ServerBanner           bool                     `protobuf:"varint,44,opt,name=server_banner,json=serverBanner,proto3" json:"server_banner,omitempty"`
+	ClientKex              bool                     `protobuf:"varint,45,opt,name=client_kex,json=clientKex,proto3" json:"client_kex,omitempty"`
+	ServerKex              bool                     `protobuf:"varint,46,opt,name=server_kex,json=serverKex,proto3" json:"server_kex,omitempty"`
+	BannersOnly            bool                     `protobuf:"varint,47,opt,name=banners_only,json=bannersOnly,proto3" json:"banners_only,omitempty"`
+	ClientOnly             bool                     `protobuf:"varint,48,opt,name=client_only,json=clientOnly,proto3" json:"client_only,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_session_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Session) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Session) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *Session) GetInIface() string {
+	if x != nil {
+		return x.InIface
+	}
+	return ""
+}
+
+func (x *Session) GetSourceFile() string {
+	if x != nil {
+		return x.SourceFile
+	}
+	return ""
+}
+
+func (x *Session) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *Session) GetSrcIp() string {
+	if x != nil {
+		return x.SrcIp
+	}
+	return ""
+}
+
+func (x *Session) GetSrcPort() uint32 {
+	if x != nil {
+		return x.SrcPort
+	}
+	return 0
+}
+
+func (x *Session) GetDestIp() string {
+	if x != nil {
+		return x.DestIp
+	}
+	return ""
+}
+
+func (x *Session) GetDestPort() uint32 {
+	if x != nil {
+		return x.DestPort
+	}
+	return 0
+}
+
+func (x *Session) GetProto() string {
+	if x != nil {
+		return x.Proto
+	}
+	return ""
+}
+
+func (x *Session) GetSrcMac() string {
+	if x != nil {
+		return x.SrcMac
+	}
+	return ""
+}
+
+func (x *Session) GetVlan() uint32 {
+	if x != nil {
+		return x.Vlan
+	}
+	return 0
+}
+
+func (x *Session) GetTunnel() *Tunnel {
+	if x != nil {
+		return x.Tunnel
+	}
+	return nil
+}
+
+func (x *Session) GetSrcGeo() *Geo {
+	if x != nil {
+		return x.SrcGeo
+	}
+	return nil
+}
+
+func (x *Session) GetDestGeo() *Geo {
+	if x != nil {
+		return x.DestGeo
+	}
+	return nil
+}
+
+func (x *Session) GetFirstSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstSeen
+	}
+	return nil
+}
+
+func (x *Session) GetBannerTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.BannerTime
+	}
+	return nil
+}
+
+func (x *Session) GetKexinitTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.KexinitTime
+	}
+	return nil
+}
+
+func (x *Session) GetDirectionInferred() bool {
+	if x != nil {
+		return x.DirectionInferred
+	}
+	return false
+}
+
+func (x *Session) GetEvasionPort() bool {
+	if x != nil {
+		return x.EvasionPort
+	}
+	return false
+}
+
+func (x *Session) GetImplementationMismatch() bool {
+	if x != nil {
+		return x.ImplementationMismatch
+	}
+	return false
+}
+
+func (x *Session) GetClient() *Record {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *Session) GetServer() *Record {
+	if x != nil {
+		return x.Server
+	}
+	return nil
+}
+
+func (x *Session) GetAnomalies() *Anomalies {
+	if x != nil {
+		return x.Anomalies
+	}
+	return nil
+}
+
+func (x *Session) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Session) GetKexExchangeSeen() bool {
+	if x != nil {
+		return x.KexExchangeSeen
+	}
+	return false
+}
+
+func (x *Session) GetKexdhInitLength() uint32 {
+	if x != nil {
+		return x.KexdhInitLength
+	}
+	return 0
+}
+
+func (x *Session) GetKexdhReplyLength() uint32 {
+	if x != nil {
+		return x.KexdhReplyLength
+	}
+	return 0
+}
+
+func (x *Session) GetJa4Ssh() string {
+	if x != nil {
+		return x.Ja4Ssh
+	}
+	return ""
+}
+
+func (x *Session) GetHasshVersion() string {
+	if x != nil {
+		return x.HasshVersion
+	}
+	return ""
+}
+
+func (x *Session) GetKexGexRequest() *KexGexRequest {
+	if x != nil {
+		return x.KexGexRequest
+	}
+	return nil
+}
+
+func (x *Session) GetKexGexGroupBits() uint32 {
+	if x != nil {
+		return x.KexGexGroupBits
+	}
+	return 0
+}
+
+func (x *Session) GetHandshakeComplete() bool {
+	if x != nil {
+		return x.HandshakeComplete
+	}
+	return false
+}
+
+func (x *Session) GetRekeyCount() uint32 {
+	if x != nil {
+		return x.RekeyCount
+	}
+	return 0
+}
+
+func (x *Session) GetRekeyTimes() []*timestamppb.Timestamp {
+	if x != nil {
+		return x.RekeyTimes
+	}
+	return nil
+}
+
+func (x *Session) GetTerrapinVulnerable() bool {
+	if x != nil {
+		return x.TerrapinVulnerable
+	}
+	return false
+}
+
+func (x *Session) GetNegotiated() *Negotiated {
+	if x != nil {
+		return x.Negotiated
+	}
+	return nil
+}
+
+func (x *Session) GetSsh1Server() bool {
+	if x != nil {
+		return x.Ssh1Server
+	}
+	return false
+}
+
+func (x *Session) GetIdentifiedClient() string {
+	if x != nil {
+		return x.IdentifiedClient
+	}
+	return ""
+}
+
+func (x *Session) GetIdentifiedServer() string {
+	if x != nil {
+		return x.IdentifiedServer
+	}
+	return ""
+}
+
+func (x *Session) GetPossibleSpoofedBanner() bool {
+	if x != nil {
+		return x.PossibleSpoofedBanner
+	}
+	return false
+}
+
+func (x *Session) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *Session) GetClientBanner() bool {
+	if x != nil {
+		return x.ClientBanner
+	}
+	return false
+}
+
+func (x *Session) GetServerBanner() bool {
+	if x != nil {
+		return x.ServerBanner
+	}
+	return false
+}
+
+func (x *Session) GetClientKex() bool {
+	if x != nil {
+		return x.ClientKex
+	}
+	return false
+}
+
+func (x *Session) GetServerKex() bool {
+	if x != nil {
+		return x.ServerKex
+	}
+	return false
+}
+
+func (x *Session) GetBannersOnly() bool {
+	if x != nil {
+		return x.BannersOnly
+	}
+	return false
+}
+
+func (x *Session) GetClientOnly() bool {
+	if x != nil {
+		return x.ClientOnly
+	}
+	return false
+}
+
+// Negotiated are the algorithms the first KEXINITs agree on.
+type Negotiated struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Kex            string                 `protobuf:"bytes,1,opt,name=kex,proto3" json:"kex,omitempty"`
+	HostKey        string                 `protobuf:"bytes,2,opt,name=host_key,json=hostKey,proto3" json:"host_key,omitempty"`
+	ClientToServer *Direction             `protobuf:"bytes,3,opt,name=client_to_server,json=clientToServer,proto3" json:"client_to_server,omitempty"`
+	ServerToClient *Direction             `protobuf:"bytes,4,opt,name=server_to_client,json=serverToClient,proto3" json:"server_to_client,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Negotiated) Reset() {
+	*x = Negotiated{}
+	mi := &file_session_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Negotiated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Negotiated) ProtoMessage() {}
+
+func (x *Negotiated) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Negotiated.ProtoReflect.Descriptor instead.
+func (*Negotiated) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Negotiated) GetKex() string {
+	if x != nil {
+		return x.Kex
+	}
+	return ""
+}
+
+func (x *Negotiated) GetHostKey() string {
+	if x != nil {
+		return x.HostKey
+	}
+	return ""
+}
+
+func (x *Negotiated) GetClientToServer() *Direction {
+	if x != nil {
+		return x.ClientToServer
+	}
+	return nil
+}
+
+func (x *Negotiated) GetServerToClient() *Direction {
+	if x != nil {
+		return x.ServerToClient
+	}
+	return nil
+}
+
+type Direction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cipher        string                 `protobuf:"bytes,1,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	Mac           string                 `protobuf:"bytes,2,opt,name=mac,proto3" json:"mac,omitempty"`
+	Compression   string                 `protobuf:"bytes,3,opt,name=compression,proto3" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Direction) Reset() {
+	*x = Direction{}
+	mi := &file_session_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Direction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Direction) ProtoMessage() {}
+
+func (x *Direction) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Direction.ProtoReflect.Descriptor instead.
+func (*Direction) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Direction) GetCipher() string {
+	if x != nil {
+		return x.Cipher
+	}
+	return ""
+}
+
+func (x *Direction) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *Direction) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
+// KexGexRequest is the group sizes, in bits, the client asked for in a
+// Diffie-Hellman group exchange.
+type KexGexRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Min           uint32                 `protobuf:"varint,1,opt,name=min,proto3" json:"min,omitempty"`
+	N             uint32                 `protobuf:"varint,2,opt,name=n,proto3" json:"n,omitempty"`
+	Max           uint32                 `protobuf:"varint,3,opt,name=max,proto3" json:"max,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KexGexRequest) Reset() {
+	*x = KexGexRequest{}
+	mi := &file_session_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KexGexRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KexGexRequest) ProtoMessage() {}
+
+func (x *KexGexRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KexGexRequest.ProtoReflect.Descriptor instead.
+func (*KexGexRequest) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KexGexRequest) GetMin() uint32 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *KexGexRequest) GetN() uint32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *KexGexRequest) GetMax() uint32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+// Record is what was seen of one side of the session.
+type Record struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	ProtoVersion           string                 `protobuf:"bytes,1,opt,name=proto_version,json=protoVersion,proto3" json:"proto_version,omitempty"`
+	SoftwareVersion        string                 `protobuf:"bytes,2,opt,name=software_version,json=softwareVersion,proto3" json:"software_version,omitempty"`
+	Comments               string                 `protobuf:"bytes,3,opt,name=comments,proto3" json:"comments,omitempty"`
+	PreambleBytes          uint32                 `protobuf:"varint,4,opt,name=preamble_bytes,json=preambleBytes,proto3" json:"preamble_bytes,omitempty"`
+	Raw                    []byte                 `protobuf:"bytes,5,opt,name=raw,proto3" json:"raw,omitempty"`
+	Terminator             string                 `protobuf:"bytes,6,opt,name=terminator,proto3" json:"terminator,omitempty"`
+	SoftwareInfo           *SoftwareInfo          `protobuf:"bytes,7,opt,name=software_info,json=softwareInfo,proto3" json:"software_info,omitempty"`
+	Hassh                  string                 `protobuf:"bytes,8,opt,name=hassh,proto3" json:"hassh,omitempty"`
+	HasshAlgorithms        string                 `protobuf:"bytes,9,opt,name=hassh_algorithms,json=hasshAlgorithms,proto3" json:"hassh_algorithms,omitempty"`
+	HasshServer            string                 `protobuf:"bytes,10,opt,name=hassh_server,json=hasshServer,proto3" json:"hassh_server,omitempty"`
+	HasshServerAlgorithms  string                 `protobuf:"bytes,11,opt,name=hassh_server_algorithms,json=hasshServerAlgorithms,proto3" json:"hassh_server_algorithms,omitempty"`
+	HasshFull              string                 `protobuf:"bytes,12,opt,name=hassh_full,json=hasshFull,proto3" json:"hassh_full,omitempty"`
+	HasshFullAlgorithms    string                 `protobuf:"bytes,13,opt,name=hassh_full_algorithms,json=hasshFullAlgorithms,proto3" json:"hassh_full_algorithms,omitempty"`
+	HasshInput             *NameLists             `protobuf:"bytes,14,opt,name=hassh_input,json=hasshInput,proto3" json:"hassh_input,omitempty"`
+	InferredImplementation string                 `protobuf:"bytes,15,opt,name=inferred_implementation,json=inferredImplementation,proto3" json:"inferred_implementation,omitempty"`
+	SupportsExtInfo        bool                   `protobuf:"varint,16,opt,name=supports_ext_info,json=supportsExtInfo,proto3" json:"supports_ext_info,omitempty"`
+	SupportsStrictKex      bool                   `protobuf:"varint,17,opt,name=supports_strict_kex,json=supportsStrictKex,proto3" json:"supports_strict_kex,omitempty"`
+	KexinitHeader          *RecordHeader          `protobuf:"bytes,18,opt,name=kexinit_header,json=kexinitHeader,proto3" json:"kexinit_header,omitempty"`
+	Rekeys                 []*Rekey               `protobuf:"bytes,19,rep,name=rekeys,proto3" json:"rekeys,omitempty"`
+	HasshVersion           string                 `protobuf:"bytes,20,opt,name=hassh_version,json=hasshVersion,proto3" json:"hassh_version,omitempty"`
+	PreBannerLines         []string               `protobuf:"bytes,21,rep,name=pre_banner_lines,json=preBannerLines,proto3" json:"pre_banner_lines,omitempty"`
+	HostKey                *HostKey               `protobuf:"bytes,22,opt,name=host_key,json=hostKey,proto3" json:"host_key,omitempty"`
+	WeakAlgorithms         []string               `protobuf:"bytes,23,rep,name=weak_algorithms,json=weakAlgorithms,proto3" json:"weak_algorithms,omitempty"`
+	Policy                 *PolicyResult          `protobuf:"bytes,24,opt,name=policy,proto3" json:"policy,omitempty"`
+	Ssh1PublicKey          *SSH1PublicKey         `protobuf:"bytes,25,opt,name=ssh1_public_key,json=ssh1PublicKey,proto3" json:"ssh1_public_key,omitempty"`
+	Ssh1SessionKey         *SSH1SessionKey        `protobuf:"bytes,26,opt,name=ssh1_session_key,json=ssh1SessionKey,proto3" json:"ssh1_session_key,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	mi := &file_session_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Record) GetProtoVersion() string {
+	if x != nil {
+		return x.ProtoVersion
+	}
+	return ""
+}
+
+func (x *Record) GetSoftwareVersion() string {
+	if x != nil {
+		return x.SoftwareVersion
+	}
+	return ""
+}
+
+func (x *Record) GetComments() string {
+	if x != nil {
+		return x.Comments
+	}
+	return ""
+}
+
+func (x *Record) GetPreambleBytes() uint32 {
+	if x != nil {
+		return x.PreambleBytes
+	}
+	return 0
+}
+
+func (x *Record) GetRaw() []byte {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+func (x *Record) GetTerminator() string {
+	if x != nil {
+		return x.Terminator
+	}
+	return ""
+}
+
+func (x *Record) GetSoftwareInfo() *SoftwareInfo {
+	if x != nil {
+		return x.SoftwareInfo
+	}
+	return nil
+}
+
+func (x *Record) GetHassh() string {
+	if x != nil {
+		return x.Hassh
+	}
+	return ""
+}
+
+func (x *Record) GetHasshAlgorithms() string {
+	if x != nil {
+		return x.HasshAlgorithms
+	}
+	return ""
+}
+
+func (x *Record) GetHasshServer() string {
+	if x != nil {
+		return x.HasshServer
+	}
+	return ""
+}
+
+func (x *Record) GetHasshServerAlgorithms() string {
+	if x != nil {
+		return x.HasshServerAlgorithms
+	}
+	return ""
+}
+
+func (x *Record) GetHasshFull() string {
+	if x != nil {
+		return x.HasshFull
+	}
+	return ""
+}
+
+func (x *Record) GetHasshFullAlgorithms() string {
+	if x != nil {
+		return x.HasshFullAlgorithms
+	}
+	return ""
+}
+
+func (x *Record) GetHasshInput() *NameLists {
+	if x != nil {
+		return x.HasshInput
+	}
+	return nil
+}
+
+func (x *Record) GetInferredImplementation() string {
+	if x != nil {
+		return x.InferredImplementation
+	}
+	return ""
+}
+
+func (x *Record) GetSupportsExtInfo() bool {
+	if x != nil {
+		return x.SupportsExtInfo
+	}
+	return false
+}
+
+func (x *Record) GetSupportsStrictKex() bool {
+	if x != nil {
+		return x.SupportsStrictKex
+	}
+	return false
+}
+
+func (x *Record) GetKexinitHeader() *RecordHeader {
+	if x != nil {
+		return x.KexinitHeader
+	}
+	return nil
+}
+
+func (x *Record) GetRekeys() []*Rekey {
+	if x != nil {
+		return x.Rekeys
+	}
+	return nil
+}
+
+func (x *Record) GetHasshVersion() string {
+	if x != nil {
+		return x.HasshVersion
+	}
+	return ""
+}
+
+func (x *Record) GetPreBannerLines() []string {
+	if x != nil {
+		return x.PreBannerLines
+	}
+	return nil
+}
+
+func (x *Record) GetHostKey() *HostKey {
+	if x != nil {
+		return x.HostKey
+	}
+	return nil
+}
+
+func (x *Record) GetWeakAlgorithms() []string {
+	if x != nil {
+		return x.WeakAlgorithms
+	}
+	return nil
+}
+
+func (x *Record) GetPolicy() *PolicyResult {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+func (x *Record) GetSsh1PublicKey() *SSH1PublicKey {
+	if x != nil {
+		return x.Ssh1PublicKey
+	}
+	return nil
+}
+
+func (x *Record) GetSsh1SessionKey() *SSH1SessionKey {
+	if x != nil {
+		return x.Ssh1SessionKey
+	}
+	return nil
+}
+
+// SSH1PublicKey is the key exchange of a SSH protocol 1 server.
+type SSH1PublicKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerKeyBits uint32                 `protobuf:"varint,1,opt,name=server_key_bits,json=serverKeyBits,proto3" json:"server_key_bits,omitempty"`
+	HostKeyBits   uint32                 `protobuf:"varint,2,opt,name=host_key_bits,json=hostKeyBits,proto3" json:"host_key_bits,omitempty"`
+	ProtocolFlags uint32                 `protobuf:"varint,3,opt,name=protocol_flags,json=protocolFlags,proto3" json:"protocol_flags,omitempty"`
+	CiphersMask   uint32                 `protobuf:"varint,4,opt,name=ciphers_mask,json=ciphersMask,proto3" json:"ciphers_mask,omitempty"`
+	AuthMask      uint32                 `protobuf:"varint,5,opt,name=auth_mask,json=authMask,proto3" json:"auth_mask,omitempty"`
+	Ciphers       []string               `protobuf:"bytes,6,rep,name=ciphers,proto3" json:"ciphers,omitempty"`
+	Auths         []string               `protobuf:"bytes,7,rep,name=auths,proto3" json:"auths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SSH1PublicKey) Reset() {
+	*x = SSH1PublicKey{}
+	mi := &file_session_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SSH1PublicKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SSH1PublicKey) ProtoMessage() {}
+
+func (x *SSH1PublicKey) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SSH1PublicKey.ProtoReflect.Descriptor instead.
+func (*SSH1PublicKey) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SSH1PublicKey) GetServerKeyBits() uint32 {
+	if x != nil {
+		return x.ServerKeyBits
+	}
+	return 0
+}
+
+func (x *SSH1PublicKey) GetHostKeyBits() uint32 {
+	if x != nil {
+		return x.HostKeyBits
+	}
+	return 0
+}
+
+func (x *SSH1PublicKey) GetProtocolFlags() uint32 {
+	if x != nil {
+		return x.ProtocolFlags
+	}
+	return 0
+}
+
+func (x *SSH1PublicKey) GetCiphersMask() uint32 {
+	if x != nil {
+		return x.CiphersMask
+	}
+	return 0
+}
+
+func (x *SSH1PublicKey) GetAuthMask() uint32 {
+	if x != nil {
+		return x.AuthMask
+	}
+	return 0
+}
+
+func (x *SSH1PublicKey) GetCiphers() []string {
+	if x != nil {
+		return x.Ciphers
+	}
+	return nil
+}
+
+func (x *SSH1PublicKey) GetAuths() []string {
+	if x != nil {
+		return x.Auths
+	}
+	return nil
+}
+
+// SSH1SessionKey is the key exchange of a SSH protocol 1 client.
+type SSH1SessionKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CipherType    uint32                 `protobuf:"varint,1,opt,name=cipher_type,json=cipherType,proto3" json:"cipher_type,omitempty"`
+	Cipher        string                 `protobuf:"bytes,2,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	ProtocolFlags uint32                 `protobuf:"varint,3,opt,name=protocol_flags,json=protocolFlags,proto3" json:"protocol_flags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SSH1SessionKey) Reset() {
+	*x = SSH1SessionKey{}
+	mi := &file_session_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SSH1SessionKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SSH1SessionKey) ProtoMessage() {}
+
+func (x *SSH1SessionKey) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SSH1SessionKey.ProtoReflect.Descriptor instead.
+func (*SSH1SessionKey) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SSH1SessionKey) GetCipherType() uint32 {
+	if x != nil {
+		return x.CipherType
+	}
+	return 0
+}
+
+func (x *SSH1SessionKey) GetCipher() string {
+	if x != nil {
+		return x.Cipher
+	}
+	return ""
+}
+
+func (x *SSH1SessionKey) GetProtocolFlags() uint32 {
+	if x != nil {
+		return x.ProtocolFlags
+	}
+	return 0
+}
+
+// PolicyResult tells if the side offers only the algorithms the policy
+// allows.
+type PolicyResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Pass          bool                   `protobuf:"varint,2,opt,name=pass,proto3" json:"pass,omitempty"`
+	Violations    []string               `protobuf:"bytes,3,rep,name=violations,proto3" json:"violations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PolicyResult) Reset() {
+	*x = PolicyResult{}
+	mi := &file_session_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PolicyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyResult) ProtoMessage() {}
+
+func (x *PolicyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyResult.ProtoReflect.Descriptor instead.
+func (*PolicyResult) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PolicyResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PolicyResult) GetPass() bool {
+	if x != nil {
+		return x.Pass
+	}
+	return false
+}
+
+func (x *PolicyResult) GetViolations() []string {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+type SoftwareInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Product       string                 `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	OsComment     string                 `protobuf:"bytes,3,opt,name=os_comment,json=osComment,proto3" json:"os_comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SoftwareInfo) Reset() {
+	*x = SoftwareInfo{}
+	mi := &file_session_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SoftwareInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SoftwareInfo) ProtoMessage() {}
+
+func (x *SoftwareInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SoftwareInfo.ProtoReflect.Descriptor instead.
+func (*SoftwareInfo) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SoftwareInfo) GetProduct() string {
+	if x != nil {
+		return x.Product
+	}
+	return ""
+}
+
+func (x *SoftwareInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *SoftwareInfo) GetOsComment() string {
+	if x != nil {
+		return x.OsComment
+	}
+	return ""
+}
+
+// NameLists are those the HASSH or HASSHServer is computed over, in the
+// direction of the side.
+type NameLists struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kex           string                 `protobuf:"bytes,1,opt,name=kex,proto3" json:"kex,omitempty"`
+	Enc           string                 `protobuf:"bytes,2,opt,name=enc,proto3" json:"enc,omitempty"`
+	Mac           string                 `protobuf:"bytes,3,opt,name=mac,proto3" json:"mac,omitempty"`
+	Comp          string                 `protobuf:"bytes,4,opt,name=comp,proto3" json:"comp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameLists) Reset() {
+	*x = NameLists{}
+	mi := &file_session_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameLists) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameLists) ProtoMessage() {}
+
+func (x *NameLists) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameLists.ProtoReflect.Descriptor instead.
+func (*NameLists) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *NameLists) GetKex() string {
+	if x != nil {
+		return x.Kex
+	}
+	return ""
+}
+
+func (x *NameLists) GetEnc() string {
+	if x != nil {
+		return x.Enc
+	}
+	return ""
+}
+
+func (x *NameLists) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *NameLists) GetComp() string {
+	if x != nil {
+		return x.Comp
+	}
+	return ""
+}
+
+// HostKey is the public host key of the server, in the format of RFC 4253,
+// section 6.6, and its fingerprints as shown by OpenSSH.
+type HostKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Blob          []byte                 `protobuf:"bytes,2,opt,name=blob,proto3" json:"blob,omitempty"`
+	Sha256        string                 `protobuf:"bytes,3,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Md5           string                 `protobuf:"bytes,4,opt,name=md5,proto3" json:"md5,omitempty"`
+	Certificate   *Certificate           `protobuf:"bytes,5,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostKey) Reset() {
+	*x = HostKey{}
+	mi := &file_session_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostKey) ProtoMessage() {}
+
+func (x *HostKey) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostKey.ProtoReflect.Descriptor instead.
+func (*HostKey) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *HostKey) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HostKey) GetBlob() []byte {
+	if x != nil {
+		return x.Blob
+	}
+	return nil
+}
+
+func (x *HostKey) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *HostKey) GetMd5() string {
+	if x != nil {
+		return x.Md5
+	}
+	return ""
+}
+
+func (x *HostKey) GetCertificate() *Certificate {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+// Certificate is an OpenSSH host certificate, validity is in seconds since
+// the epoch.
+type Certificate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Serial        uint64                 `protobuf:"varint,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	CertType      uint32                 `protobuf:"varint,2,opt,name=cert_type,json=certType,proto3" json:"cert_type,omitempty"`
+	KeyId         string                 `protobuf:"bytes,3,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	Principals    []string               `protobuf:"bytes,4,rep,name=principals,proto3" json:"principals,omitempty"`
+	ValidAfter    uint64                 `protobuf:"varint,5,opt,name=valid_after,json=validAfter,proto3" json:"valid_after,omitempty"`
+	ValidBefore   uint64                 `protobuf:"varint,6,opt,name=valid_before,json=validBefore,proto3" json:"valid_before,omitempty"`
+	CaKeyType     string                 `protobuf:"bytes,7,opt,name=ca_key_type,json=caKeyType,proto3" json:"ca_key_type,omitempty"`
+	CaFingerprint string                 `protobuf:"bytes,8,opt,name=ca_fingerprint,json=caFingerprint,proto3" json:"ca_fingerprint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Certificate) Reset() {
+	*x = Certificate{}
+	mi := &file_session_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Certificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Certificate) ProtoMessage() {}
+
+func (x *Certificate) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Certificate.ProtoReflect.Descriptor instead.
+func (*Certificate) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Certificate) GetSerial() uint64 {
+	if x != nil {
+		return x.Serial
+	}
+	return 0
+}
+
+func (x *Certificate) GetCertType() uint32 {
+	if x != nil {
+		return x.CertType
+	}
+	return 0
+}
+
+func (x *Certificate) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *Certificate) GetPrincipals() []string {
+	if x != nil {
+		return x.Principals
+	}
+	return nil
+}
+
+func (x *Certificate) GetValidAfter() uint64 {
+	if x != nil {
+		return x.ValidAfter
+	}
+	return 0
+}
+
+func (x *Certificate) GetValidBefore() uint64 {
+	if x != nil {
+		return x.ValidBefore
+	}
+	return 0
+}
+
+func (x *Certificate) GetCaKeyType() string {
+	if x != nil {
+		return x.CaKeyType
+	}
+	return ""
+}
+
+func (x *Certificate) GetCaFingerprint() string {
+	if x != nil {
+		return x.CaFingerprint
+	}
+	return ""
+}
+
+type RecordHeader struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PacketLength  uint32                 `protobuf:"varint,1,opt,name=packet_length,json=packetLength,proto3" json:"packet_length,omitempty"`
+	PaddingLength uint32                 `protobuf:"varint,2,opt,name=padding_length,json=paddingLength,proto3" json:"padding_length,omitempty"`
+	MessageCode   uint32                 `protobuf:"varint,3,opt,name=message_code,json=messageCode,proto3" json:"message_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordHeader) Reset() {
+	*x = RecordHeader{}
+	mi := &file_session_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordHeader) ProtoMessage() {}
+
+func (x *RecordHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordHeader.ProtoReflect.Descriptor instead.
+func (*RecordHeader) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RecordHeader) GetPacketLength() uint32 {
+	if x != nil {
+		return x.PacketLength
+	}
+	return 0
+}
+
+func (x *RecordHeader) GetPaddingLength() uint32 {
+	if x != nil {
+		return x.PaddingLength
+	}
+	return 0
+}
+
+func (x *RecordHeader) GetMessageCode() uint32 {
+	if x != nil {
+		return x.MessageCode
+	}
+	return 0
+}
+
+type Rekey struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp             *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Hassh                 string                 `protobuf:"bytes,2,opt,name=hassh,proto3" json:"hassh,omitempty"`
+	HasshAlgorithms       string                 `protobuf:"bytes,3,opt,name=hassh_algorithms,json=hasshAlgorithms,proto3" json:"hassh_algorithms,omitempty"`
+	HasshServer           string                 `protobuf:"bytes,4,opt,name=hassh_server,json=hasshServer,proto3" json:"hassh_server,omitempty"`
+	HasshServerAlgorithms string                 `protobuf:"bytes,5,opt,name=hassh_server_algorithms,json=hasshServerAlgorithms,proto3" json:"hassh_server_algorithms,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *Rekey) Reset() {
+	*x = Rekey{}
+	mi := &file_session_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rekey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rekey) ProtoMessage() {}
+
+func (x *Rekey) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rekey.ProtoReflect.Descriptor instead.
+func (*Rekey) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Rekey) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Rekey) GetHassh() string {
+	if x != nil {
+		return x.Hassh
+	}
+	return ""
+}
+
+func (x *Rekey) GetHasshAlgorithms() string {
+	if x != nil {
+		return x.HasshAlgorithms
+	}
+	return ""
+}
+
+func (x *Rekey) GetHasshServer() string {
+	if x != nil {
+		return x.HasshServer
+	}
+	return ""
+}
+
+func (x *Rekey) GetHasshServerAlgorithms() string {
+	if x != nil {
+		return x.HasshServerAlgorithms
+	}
+	return ""
+}
+
+type Anomalies struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	OverlapBytes      uint64                 `protobuf:"varint,1,opt,name=overlap_bytes,json=overlapBytes,proto3" json:"overlap_bytes,omitempty"`
+	OverlapPackets    uint64                 `protobuf:"varint,2,opt,name=overlap_packets,json=overlapPackets,proto3" json:"overlap_packets,omitempty"`
+	OutOfOrderBytes   uint64                 `protobuf:"varint,3,opt,name=out_of_order_bytes,json=outOfOrderBytes,proto3" json:"out_of_order_bytes,omitempty"`
+	OutOfOrderPackets uint64                 `protobuf:"varint,4,opt,name=out_of_order_packets,json=outOfOrderPackets,proto3" json:"out_of_order_packets,omitempty"`
+	MissedBytes       uint64                 `protobuf:"varint,5,opt,name=missed_bytes,json=missedBytes,proto3" json:"missed_bytes,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Anomalies) Reset() {
+	*x = Anomalies{}
+	mi := &file_session_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Anomalies) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Anomalies) ProtoMessage() {}
+
+func (x *Anomalies) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Anomalies.ProtoReflect.Descriptor instead.
+func (*Anomalies) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Anomalies) GetOverlapBytes() uint64 {
+	if x != nil {
+		return x.OverlapBytes
+	}
+	return 0
+}
+
+func (x *Anomalies) GetOverlapPackets() uint64 {
+	if x != nil {
+		return x.OverlapPackets
+	}
+	return 0
+}
+
+func (x *Anomalies) GetOutOfOrderBytes() uint64 {
+	if x != nil {
+		return x.OutOfOrderBytes
+	}
+	return 0
+}
+
+func (x *Anomalies) GetOutOfOrderPackets() uint64 {
+	if x != nil {
+		return x.OutOfOrderPackets
+	}
+	return 0
+}
+
+func (x *Anomalies) GetMissedBytes() uint64 {
+	if x != nil {
+		return x.MissedBytes
+	}
+	return 0
+}
+
+type Tunnel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	SrcIp         string                 `protobuf:"bytes,2,opt,name=src_ip,json=srcIp,proto3" json:"src_ip,omitempty"`
+	DestIp        string                 `protobuf:"bytes,3,opt,name=dest_ip,json=destIp,proto3" json:"dest_ip,omitempty"`
+	Id            uint32                 `protobuf:"varint,4,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tunnel) Reset() {
+	*x = Tunnel{}
+	mi := &file_session_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tunnel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tunnel) ProtoMessage() {}
+
+func (x *Tunnel) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tunnel.ProtoReflect.Descriptor instead.
+func (*Tunnel) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Tunnel) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Tunnel) GetSrcIp() string {
+	if x != nil {
+		return x.SrcIp
+	}
+	return ""
+}
+
+func (x *Tunnel) GetDestIp() string {
+	if x != nil {
+		return x.DestIp
+	}
+	return ""
+}
+
+func (x *Tunnel) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type Geo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Country       string                 `protobuf:"bytes,1,opt,name=country,proto3" json:"country,omitempty"`
+	Asn           uint32                 `protobuf:"varint,2,opt,name=asn,proto3" json:"asn,omitempty"`
+	Org           string                 `protobuf:"bytes,3,opt,name=org,proto3" json:"org,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Geo) Reset() {
+	*x = Geo{}
+	mi := &file_session_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Geo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Geo) ProtoMessage() {}
+
+func (x *Geo) ProtoReflect() protoreflect.Message {
+	mi := &file_session_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Geo.ProtoReflect.Descriptor instead.
+func (*Geo) Descriptor() ([]byte, []int) {
+	return file_session_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Geo) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Geo) GetAsn() uint32 {
+	if x != nil {
+		return x.Asn
+	}
+	return 0
+}
+
+func (x *Geo) GetOrg() string {
+	if x != nil {
+		return x.Org
+	}
+	return ""
+}
+
+var File_session_proto protoreflect.FileDescriptor
+
+var file_session_proto_rawDesc = string([]byte{
+	0x0a, 0x0d, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0f, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x22, 0xac, 0x0f, 0x0a, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x38, 0x0a,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x69, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x6e, 0x5f,
+	0x69, 0x66, 0x61, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x69, 0x6e, 0x49,
+	0x66, 0x61, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x66,
+	0x69, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x73, 0x72, 0x63, 0x5f, 0x69, 0x70, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x72, 0x63, 0x49, 0x70, 0x12, 0x19, 0x0a, 0x08, 0x73,
+	0x72, 0x63, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x73,
+	0x72, 0x63, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x64, 0x65, 0x73, 0x74, 0x5f, 0x69,
+	0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x73, 0x74, 0x49, 0x70, 0x12,
+	0x1b, 0x0a, 0x09, 0x64, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x08, 0x64, 0x65, 0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x5f, 0x6d, 0x61, 0x63, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x72, 0x63, 0x4d, 0x61, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x76,
+	0x6c, 0x61, 0x6e, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x76, 0x6c, 0x61, 0x6e, 0x12,
+	0x2f, 0x0a, 0x06, 0x74, 0x75, 0x6e, 0x6e, 0x65, 0x6c, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x2e, 0x54, 0x75, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x06, 0x74, 0x75, 0x6e, 0x6e, 0x65, 0x6c,
+	0x12, 0x2d, 0x0a, 0x07, 0x73, 0x72, 0x63, 0x5f, 0x67, 0x65, 0x6f, 0x18, 0x0e, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x2e, 0x47, 0x65, 0x6f, 0x52, 0x06, 0x73, 0x72, 0x63, 0x47, 0x65, 0x6f, 0x12,
+	0x2f, 0x0a, 0x08, 0x64, 0x65, 0x73, 0x74, 0x5f, 0x67, 0x65, 0x6f, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x2e, 0x47, 0x65, 0x6f, 0x52, 0x07, 0x64, 0x65, 0x73, 0x74, 0x47, 0x65, 0x6f,
+	0x12, 0x39, 0x0a, 0x0a, 0x66, 0x69, 0x72, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x10,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x09, 0x66, 0x69, 0x72, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x12, 0x3b, 0x0a, 0x0b, 0x62,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x62, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3d, 0x0a, 0x0c, 0x6b, 0x65, 0x78, 0x69,
+	0x6e, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x6b, 0x65, 0x78, 0x69,
+	0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x12, 0x64, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x66, 0x65, 0x72, 0x72, 0x65, 0x64, 0x18, 0x13, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x11, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e,
+	0x66, 0x65, 0x72, 0x72, 0x65, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x65, 0x76, 0x61, 0x73, 0x69, 0x6f,
+	0x6e, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x65, 0x76,
+	0x61, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x37, 0x0a, 0x17, 0x69, 0x6d, 0x70,
+	0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x69, 0x73, 0x6d,
+	0x61, 0x74, 0x63, 0x68, 0x18, 0x15, 0x20, 0x01, 0x28, 0x08, 0x52, 0x16, 0x69, 0x6d, 0x70, 0x6c,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x73, 0x6d, 0x61, 0x74,
+	0x63, 0x68, 0x12, 0x2f, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x16, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x12, 0x2f, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x17, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x12, 0x38, 0x0a, 0x09, 0x61, 0x6e, 0x6f, 0x6d, 0x61, 0x6c, 0x69, 0x65,
+	0x73, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73,
+	0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x41, 0x6e, 0x6f, 0x6d, 0x61, 0x6c,
+	0x69, 0x65, 0x73, 0x52, 0x09, 0x61, 0x6e, 0x6f, 0x6d, 0x61, 0x6c, 0x69, 0x65, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x19, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x6b, 0x65, 0x78, 0x5f, 0x65, 0x78, 0x63, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0f, 0x6b, 0x65, 0x78, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x53, 0x65, 0x65, 0x6e,
+	0x12, 0x2a, 0x0a, 0x11, 0x6b, 0x65, 0x78, 0x64, 0x68, 0x5f, 0x69, 0x6e, 0x69, 0x74, 0x5f, 0x6c,
+	0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x6b, 0x65, 0x78,
+	0x64, 0x68, 0x49, 0x6e, 0x69, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x2c, 0x0a, 0x12,
+	0x6b, 0x65, 0x78, 0x64, 0x68, 0x5f, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x5f, 0x6c, 0x65, 0x6e, 0x67,
+	0x74, 0x68, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x6b, 0x65, 0x78, 0x64, 0x68, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x6a, 0x61,
+	0x34, 0x73, 0x73, 0x68, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6a, 0x61, 0x34, 0x73,
+	0x73, 0x68, 0x12, 0x23, 0x0a, 0x0d, 0x68, 0x61, 0x73, 0x73, 0x68, 0x5f, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x68, 0x61, 0x73, 0x73, 0x68,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x46, 0x0a, 0x0f, 0x6b, 0x65, 0x78, 0x5f, 0x67,
+	0x65, 0x78, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1e, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x2e, 0x4b, 0x65, 0x78, 0x47, 0x65, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x52, 0x0d, 0x6b, 0x65, 0x78, 0x47, 0x65, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x2b, 0x0a, 0x12, 0x6b, 0x65, 0x78, 0x5f, 0x67, 0x65, 0x78, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x70,
+	0x5f, 0x62, 0x69, 0x74, 0x73, 0x18, 0x20, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x6b, 0x65, 0x78,
+	0x47, 0x65, 0x78, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x42, 0x69, 0x74, 0x73, 0x12, 0x2d, 0x0a, 0x12,
+	0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65,
+	0x74, 0x65, 0x18, 0x21, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x68, 0x61, 0x6e, 0x64, 0x73, 0x68,
+	0x61, 0x6b, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72,
+	0x65, 0x6b, 0x65, 0x79, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x22, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0a, 0x72, 0x65, 0x6b, 0x65, 0x79, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x3b, 0x0a, 0x0b,
+	0x72, 0x65, 0x6b, 0x65, 0x79, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x18, 0x23, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x72,
+	0x65, 0x6b, 0x65, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x12, 0x2f, 0x0a, 0x13, 0x74, 0x65, 0x72,
+	0x72, 0x61, 0x70, 0x69, 0x6e, 0x5f, 0x76, 0x75, 0x6c, 0x6e, 0x65, 0x72, 0x61, 0x62, 0x6c, 0x65,
+	0x18, 0x24, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x74, 0x65, 0x72, 0x72, 0x61, 0x70, 0x69, 0x6e,
+	0x56, 0x75, 0x6c, 0x6e, 0x65, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x3b, 0x0a, 0x0a, 0x6e, 0x65,
+	0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x18, 0x25, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x2e, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x52, 0x0a, 0x6e, 0x65, 0x67,
+	0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x73, 0x68, 0x31, 0x5f,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x26, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x73, 0x73,
+	0x68, 0x31, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x2b, 0x0a, 0x11, 0x69, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x66, 0x69, 0x65, 0x64, 0x5f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x27, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x10, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x64, 0x43,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x2b, 0x0a, 0x11, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66,
+	0x69, 0x65, 0x64, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x28, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x10, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x64, 0x53, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x12, 0x36, 0x0a, 0x17, 0x70, 0x6f, 0x73, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x5f, 0x73,
+	0x70, 0x6f, 0x6f, 0x66, 0x65, 0x64, 0x5f, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x18, 0x29, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x15, 0x70, 0x6f, 0x73, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x53, 0x70, 0x6f,
+	0x6f, 0x66, 0x65, 0x64, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61,
+	0x72, 0x74, 0x69, 0x61, 0x6c, 0x18, 0x2a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x61, 0x72,
+	0x74, 0x69, 0x61, 0x6c, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x62,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x18, 0x2b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x5f, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x18, 0x2c, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x1d,
+	0x0a, 0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x6b, 0x65, 0x78, 0x18, 0x2d, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x4b, 0x65, 0x78, 0x12, 0x1d, 0x0a,
+	0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x78, 0x18, 0x2e, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x78, 0x12, 0x21, 0x0a, 0x0c,
+	0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x2f, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0b, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x4f, 0x6e, 0x6c, 0x79, 0x12,
+	0x1f, 0x0a, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x30,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x4f, 0x6e, 0x6c, 0x79,
+	0x22, 0xc5, 0x01, 0x0a, 0x0a, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
+	0x78, 0x12, 0x19, 0x0a, 0x08, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x68, 0x6f, 0x73, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x44, 0x0a, 0x10,
+	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x6f, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68,
+	0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x12, 0x44, 0x0a, 0x10, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x74, 0x6f, 0x5f,
+	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x44,
+	0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0e, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x54, 0x6f, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x22, 0x57, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x12, 0x10, 0x0a,
+	0x03, 0x6d, 0x61, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x12,
+	0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x22, 0x41, 0x0a, 0x0d, 0x4b, 0x65, 0x78, 0x47, 0x65, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x03, 0x6d, 0x69, 0x6e, 0x12, 0x0c, 0x0a, 0x01, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x01, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x03, 0x6d, 0x61, 0x78, 0x22, 0xbf, 0x09, 0x0a, 0x06, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12,
+	0x23, 0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x6f, 0x66, 0x74, 0x77, 0x61, 0x72, 0x65,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
+	0x73, 0x6f, 0x66, 0x74, 0x77, 0x61, 0x72, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x70,
+	0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0d, 0x70, 0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x61, 0x77, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x03, 0x72, 0x61, 0x77, 0x12, 0x1e, 0x0a, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74,
+	0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x74, 0x6f, 0x72, 0x12, 0x42, 0x0a, 0x0d, 0x73, 0x6f, 0x66, 0x74, 0x77, 0x61, 0x72, 0x65,
+	0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x6f,
+	0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x53, 0x6f,
+	0x66, 0x74, 0x77, 0x61, 0x72, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0c, 0x73, 0x6f, 0x66, 0x74,
+	0x77, 0x61, 0x72, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x68, 0x61, 0x73, 0x73,
+	0x68, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x68, 0x61, 0x73, 0x73, 0x68, 0x12, 0x29,
+	0x0a, 0x10, 0x68, 0x61, 0x73, 0x73, 0x68, 0x5f, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68,
+	0x6d, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x41,
+	0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x68, 0x61, 0x73,
+	0x73, 0x68, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x68, 0x61, 0x73, 0x73, 0x68, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x36, 0x0a, 0x17,
+	0x68, 0x61, 0x73, 0x73, 0x68, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x61, 0x6c, 0x67,
+	0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x68,
+	0x61, 0x73, 0x73, 0x68, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69,
+	0x74, 0x68, 0x6d, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x68, 0x61, 0x73, 0x73, 0x68, 0x5f, 0x66, 0x75,
+	0x6c, 0x6c, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x68, 0x61, 0x73, 0x73, 0x68, 0x46,
+	0x75, 0x6c, 0x6c, 0x12, 0x32, 0x0a, 0x15, 0x68, 0x61, 0x73, 0x73, 0x68, 0x5f, 0x66, 0x75, 0x6c,
+	0x6c, 0x5f, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x13, 0x68, 0x61, 0x73, 0x73, 0x68, 0x46, 0x75, 0x6c, 0x6c, 0x41, 0x6c, 0x67,
+	0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x12, 0x3b, 0x0a, 0x0b, 0x68, 0x61, 0x73, 0x73, 0x68,
+	0x5f, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x4e,
+	0x61, 0x6d, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x73, 0x52, 0x0a, 0x68, 0x61, 0x73, 0x73, 0x68, 0x49,
+	0x6e, 0x70, 0x75, 0x74, 0x12, 0x37, 0x0a, 0x17, 0x69, 0x6e, 0x66, 0x65, 0x72, 0x72, 0x65, 0x64,
+	0x5f, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x69, 0x6e, 0x66, 0x65, 0x72, 0x72, 0x65, 0x64, 0x49,
+	0x6d, 0x70, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a,
+	0x11, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x5f, 0x65, 0x78, 0x74, 0x5f, 0x69, 0x6e,
+	0x66, 0x6f, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72,
+	0x74, 0x73, 0x45, 0x78, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2e, 0x0a, 0x13, 0x73, 0x75, 0x70,
+	0x70, 0x6f, 0x72, 0x74, 0x73, 0x5f, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x5f, 0x6b, 0x65, 0x78,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x73,
+	0x53, 0x74, 0x72, 0x69, 0x63, 0x74, 0x4b, 0x65, 0x78, 0x12, 0x44, 0x0a, 0x0e, 0x6b, 0x65, 0x78,
+	0x69, 0x6e, 0x69, 0x74, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x12, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x52, 0x0d, 0x6b, 0x65, 0x78, 0x69, 0x6e, 0x69, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12,
+	0x2e, 0x0a, 0x06, 0x72, 0x65, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x13, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x2e, 0x52, 0x65, 0x6b, 0x65, 0x79, 0x52, 0x06, 0x72, 0x65, 0x6b, 0x65, 0x79, 0x73, 0x12,
+	0x23, 0x0a, 0x0d, 0x68, 0x61, 0x73, 0x73, 0x68, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x68, 0x61, 0x73, 0x73, 0x68, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x28, 0x0a, 0x10, 0x70, 0x72, 0x65, 0x5f, 0x62, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x15, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e,
+	0x70, 0x72, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x4c, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x33,
+	0x0a, 0x08, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4b, 0x65, 0x79, 0x52, 0x07, 0x68, 0x6f, 0x73, 0x74,
+	0x4b, 0x65, 0x79, 0x12, 0x27, 0x0a, 0x0f, 0x77, 0x65, 0x61, 0x6b, 0x5f, 0x61, 0x6c, 0x67, 0x6f,
+	0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x18, 0x17, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x77, 0x65,
+	0x61, 0x6b, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x12, 0x35, 0x0a, 0x06,
+	0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x67,
+	0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x50,
+	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x06, 0x70, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x12, 0x46, 0x0a, 0x0f, 0x73, 0x73, 0x68, 0x31, 0x5f, 0x70, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x19, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x67,
+	0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x53,
+	0x53, 0x48, 0x31, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x0d, 0x73, 0x73,
+	0x68, 0x31, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x49, 0x0a, 0x10, 0x73,
+	0x73, 0x68, 0x31, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18,
+	0x1a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x53, 0x53, 0x48, 0x31, 0x53, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x52, 0x0e, 0x73, 0x73, 0x68, 0x31, 0x53, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x22, 0xf2, 0x01, 0x0a, 0x0d, 0x53, 0x53, 0x48, 0x31, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x26, 0x0a, 0x0f, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x62, 0x69, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x42, 0x69, 0x74, 0x73,
+	0x12, 0x22, 0x0a, 0x0d, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x62, 0x69, 0x74,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x68, 0x6f, 0x73, 0x74, 0x4b, 0x65, 0x79,
+	0x42, 0x69, 0x74, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x5f, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x46, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x63,
+	0x69, 0x70, 0x68, 0x65, 0x72, 0x73, 0x5f, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0b, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x73, 0x4d, 0x61, 0x73, 0x6b, 0x12, 0x1b,
+	0x0a, 0x09, 0x61, 0x75, 0x74, 0x68, 0x5f, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x08, 0x61, 0x75, 0x74, 0x68, 0x4d, 0x61, 0x73, 0x6b, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x69, 0x70, 0x68, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x69,
+	0x70, 0x68, 0x65, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x75, 0x74, 0x68, 0x73, 0x18, 0x07,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x75, 0x74, 0x68, 0x73, 0x22, 0x70, 0x0a, 0x0e, 0x53,
+	0x53, 0x48, 0x31, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x12, 0x1f, 0x0a,
+	0x0b, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0a, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x5f, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x46, 0x6c, 0x61, 0x67, 0x73, 0x22, 0x56, 0x0a,
+	0x0c, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x04, 0x70, 0x61, 0x73, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x76, 0x69, 0x6f, 0x6c, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x61, 0x0a, 0x0c, 0x53, 0x6f, 0x66, 0x74, 0x77, 0x61, 0x72,
+	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x12,
+	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x6f, 0x73, 0x5f,
+	0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6f,
+	0x73, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0x55, 0x0a, 0x09, 0x4e, 0x61, 0x6d, 0x65,
+	0x4c, 0x69, 0x73, 0x74, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x63, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x63, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x63,
+	0x6f, 0x6d, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x6d, 0x70, 0x22,
+	0x9b, 0x01, 0x0a, 0x07, 0x48, 0x6f, 0x73, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x62, 0x6c, 0x6f, 0x62, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x62,
+	0x6c, 0x6f, 0x62, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x12, 0x10, 0x0a, 0x03, 0x6d,
+	0x64, 0x35, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x64, 0x35, 0x12, 0x3e, 0x0a,
+	0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2e, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x52, 0x0b, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x22, 0x84, 0x02,
+	0x0a, 0x0b, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x73,
+	0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x63, 0x65, 0x72, 0x74, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x6b, 0x65, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6b, 0x65, 0x79, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x72, 0x69,
+	0x6e, 0x63, 0x69, 0x70, 0x61, 0x6c, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x70,
+	0x72, 0x69, 0x6e, 0x63, 0x69, 0x70, 0x61, 0x6c, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x76, 0x61, 0x6c,
+	0x69, 0x64, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
+	0x76, 0x61, 0x6c, 0x69, 0x64, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x76, 0x61,
+	0x6c, 0x69, 0x64, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0b, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x1e, 0x0a,
+	0x0b, 0x63, 0x61, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x63, 0x61, 0x4b, 0x65, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x25, 0x0a,
+	0x0e, 0x63, 0x61, 0x5f, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x61, 0x46, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70,
+	0x72, 0x69, 0x6e, 0x74, 0x22, 0x7d, 0x0a, 0x0c, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x6c,
+	0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x70, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x61, 0x64,
+	0x64, 0x69, 0x6e, 0x67, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0d, 0x70, 0x61, 0x64, 0x64, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68,
+	0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x43,
+	0x6f, 0x64, 0x65, 0x22, 0xdd, 0x01, 0x0a, 0x05, 0x52, 0x65, 0x6b, 0x65, 0x79, 0x12, 0x38, 0x0a,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x68, 0x61, 0x73, 0x73, 0x68,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x68, 0x61, 0x73, 0x73, 0x68, 0x12, 0x29, 0x0a,
+	0x10, 0x68, 0x61, 0x73, 0x73, 0x68, 0x5f, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x41, 0x6c,
+	0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x68, 0x61, 0x73, 0x73,
+	0x68, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x68, 0x61, 0x73, 0x73, 0x68, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x36, 0x0a, 0x17, 0x68,
+	0x61, 0x73, 0x73, 0x68, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x61, 0x6c, 0x67, 0x6f,
+	0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x68, 0x61,
+	0x73, 0x73, 0x68, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74,
+	0x68, 0x6d, 0x73, 0x22, 0xda, 0x01, 0x0a, 0x09, 0x41, 0x6e, 0x6f, 0x6d, 0x61, 0x6c, 0x69, 0x65,
+	0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x76, 0x65, 0x72, 0x6c, 0x61, 0x70, 0x5f, 0x62, 0x79, 0x74,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x6f, 0x76, 0x65, 0x72, 0x6c, 0x61,
+	0x70, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6f, 0x76, 0x65, 0x72, 0x6c, 0x61,
+	0x70, 0x5f, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0e, 0x6f, 0x76, 0x65, 0x72, 0x6c, 0x61, 0x70, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12,
+	0x2b, 0x0a, 0x12, 0x6f, 0x75, 0x74, 0x5f, 0x6f, 0x66, 0x5f, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x6f, 0x75, 0x74,
+	0x4f, 0x66, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2f, 0x0a, 0x14,
+	0x6f, 0x75, 0x74, 0x5f, 0x6f, 0x66, 0x5f, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f, 0x70, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x11, 0x6f, 0x75, 0x74, 0x4f,
+	0x66, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x21, 0x0a,
+	0x0c, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0b, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73,
+	0x22, 0x5c, 0x0a, 0x06, 0x54, 0x75, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x15,
+	0x0a, 0x06, 0x73, 0x72, 0x63, 0x5f, 0x69, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x73, 0x72, 0x63, 0x49, 0x70, 0x12, 0x17, 0x0a, 0x07, 0x64, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x70,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x73, 0x74, 0x49, 0x70, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x69, 0x64, 0x22, 0x43,
+	0x0a, 0x03, 0x47, 0x65, 0x6f, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x61, 0x73, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x61, 0x73,
+	0x6e, 0x12, 0x10, 0x0a, 0x03, 0x6f, 0x72, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6f, 0x72, 0x67, 0x42, 0x34, 0x5a, 0x32, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x6b, 0x6a, 0x65, 0x6c, 0x6c, 0x65, 0x2f, 0x67, 0x6f, 0x68, 0x61, 0x73, 0x73, 0x68,
+	0x2f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x2f, 0x68, 0x61, 0x73, 0x73, 0x68, 0x2f,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+})
+
+var (
+	file_session_proto_rawDescOnce sync.Once
+	file_session_proto_rawDescData []byte
+)
+
+func file_session_proto_rawDescGZIP() []byte {
+	file_session_proto_rawDescOnce.Do(func() {
+		file_session_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_session_proto_rawDesc), len(file_session_proto_rawDesc)))
+	})
+	return file_session_proto_rawDescData
+}
+
+var file_session_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_session_proto_goTypes = []any{
+	(*Session)(nil),               // 0: gohassh.session.Session
+	(*Negotiated)(nil),            // 1: gohassh.session.Negotiated
+	(*Direction)(nil),             // 2: gohassh.session.Direction
+	(*KexGexRequest)(nil),         // 3: gohassh.session.KexGexRequest
+	(*Record)(nil),                // 4: gohassh.session.Record
+	(*SSH1PublicKey)(nil),         // 5: gohassh.session.SSH1PublicKey
+	(*SSH1SessionKey)(nil),        // 6: gohassh.session.SSH1SessionKey
+	(*PolicyResult)(nil),          // 7: gohassh.session.PolicyResult
+	(*SoftwareInfo)(nil),          // 8: gohassh.session.SoftwareInfo
+	(*NameLists)(nil),             // 9: gohassh.session.NameLists
+	(*HostKey)(nil),               // 10: gohassh.session.HostKey
+	(*Certificate)(nil),           // 11: gohassh.session.Certificate
+	(*RecordHeader)(nil),          // 12: gohassh.session.RecordHeader
+	(*Rekey)(nil),                 // 13: gohassh.session.Rekey
+	(*Anomalies)(nil),             // 14: gohassh.session.Anomalies
+	(*Tunnel)(nil),                // 15: gohassh.session.Tunnel
+	(*Geo)(nil),                   // 16: gohassh.session.Geo
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+}
+var file_session_proto_depIdxs = []int32{
+	17, // 0: gohassh.session.Session.timestamp:type_name -> google.protobuf.Timestamp
+	15, // 1: gohassh.session.Session.tunnel:type_name -> gohassh.session.Tunnel
+	16, // 2: gohassh.session.Session.src_geo:type_name -> gohassh.session.Geo
+	16, // 3: gohassh.session.Session.dest_geo:type_name -> gohassh.session.Geo
+	17, // 4: gohassh.session.Session.first_seen:type_name -> google.protobuf.Timestamp
+	17, // 5: gohassh.session.Session.banner_time:type_name -> google.protobuf.Timestamp
+	17, // 6: gohassh.session.Session.kexinit_time:type_name -> google.protobuf.Timestamp
+	4,  // 7: gohassh.session.Session.client:type_name -> gohassh.session.Record
+	4,  // 8: gohassh.session.Session.server:type_name -> gohassh.session.Record
+	14, // 9: gohassh.session.Session.anomalies:type_name -> gohassh.session.Anomalies
+	3,  // 10: gohassh.session.Session.kex_gex_request:type_name -> gohassh.session.KexGexRequest
+	17, // 11: gohassh.session.Session.rekey_times:type_name -> google.protobuf.Timestamp
+	1,  // 12: gohassh.session.Session.negotiated:type_name -> gohassh.session.Negotiated
+	2,  // 13: gohassh.session.Negotiated.client_to_server:type_name -> gohassh.session.Direction
+	2,  // 14: gohassh.session.Negotiated.server_to_client:type_name -> gohassh.session.Direction
+	8,  // 15: gohassh.session.Record.software_info:type_name -> gohassh.session.SoftwareInfo
+	9,  // 16: gohassh.session.Record.hassh_input:type_name -> gohassh.session.NameLists
+	12, // 17: gohassh.session.Record.kexinit_header:type_name -> gohassh.session.RecordHeader
+	13, // 18: gohassh.session.Record.rekeys:type_name -> gohassh.session.Rekey
+	10, // 19: gohassh.session.Record.host_key:type_name -> gohassh.session.HostKey
+	7,  // 20: gohassh.session.Record.policy:type_name -> gohassh.session.PolicyResult
+	5,  // 21: gohassh.session.Record.ssh1_public_key:type_name -> gohassh.session.SSH1PublicKey
+	6,  // 22: gohassh.session.Record.ssh1_session_key:type_name -> gohassh.session.SSH1SessionKey
+	11, // 23: gohassh.session.HostKey.certificate:type_name -> gohassh.session.Certificate
+	17, // 24: gohassh.session.Rekey.timestamp:type_name -> google.protobuf.Timestamp
+	25, // [25:25] is the sub-list for method output_type
+	25, // [25:25] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
+}
+
+func init() { file_session_proto_init() }
+func file_session_proto_init() {
+	if File_session_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_session_proto_rawDesc), len(file_session_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_session_proto_goTypes,
+		DependencyIndexes: file_session_proto_depIdxs,
+		MessageInfos:      file_session_proto_msgTypes,
+	}.Build()
+	File_session_proto = out.File
+	file_session_proto_goTypes = nil
+	file_session_proto_depIdxs = nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import "net"
+
+// checkSSH1 flags, with -ssh1-alert, the session if its server advertises
+// SSH protocol 1, and warns about it. Protocol 1 is long broken
+// and should no longer be offered.
+func checkSSH1(s *SSHSession) {
+	if !opts.SSH1Alert || s.Server.ESSHBannerRecord == nil || !s.Server.SSH1() {
+		return
+	}
+	s.SSH1Server = true
+	logger.Warn("Server advertises SSH protocol 1",
+		"server", net.JoinHostPort(s.ServerIP, s.ServerPort),
+		"proto_version", s.Server.ProtoVersion,
+		"software_version", s.Server.SoftwareVersion)
+}
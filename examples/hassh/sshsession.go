@@ -1,119 +1,53 @@
 package main
 
 import (
-	"encoding/json"
-	"time"
-
-	"github.com/kjelle/gohassh"
-	"github.com/kjelle/gohassh/essh"
+	"github.com/kjelle/gohassh/session"
 )
 
-type SSHRecord struct {
-	*essh.ESSHBannerRecord
-	*gohassh.HASSH
-	*gohassh.HASSHServer
-}
+// The sessions are those of the session package, under the names the tool
+// has always used.
+type (
+	SSHSession       = session.Session
+	SSHRecord        = session.Record
+	HASSHRecord      = session.HASSHRecord
+	HASSHInput       = session.HASSHInput
+	HASSHServerInput = session.HASSHServerInput
+	Anomalies        = session.Anomalies
+	State            = session.State
+)
 
-type SSHSession struct {
-	Timestamp  time.Time `json:"timestamp"`
-	InIface    string    `json:"in_iface"`
-	EventType  string    `json:"event_type"`
-	ClientIP   string    `json:"src_ip"`
-	ClientPort string    `json:"src_port"`
-	ServerIP   string    `json:"dest_ip"`
-	ServerPort string    `json:"dest_port"`
-	Protocol   string    `json:"proto"`
+const (
+	StateClientBanner     = session.StateClientBanner
+	StateServerBanner     = session.StateServerBanner
+	StateClientKexInit    = session.StateClientKexInit
+	StateServerKexInit    = session.StateServerKexInit
+	StateClientKexDHInit  = session.StateClientKexDHInit
+	StateServerKexDHReply = session.StateServerKexDHReply
+	StateClientNewKeys    = session.StateClientNewKeys
+	StateServerNewKeys    = session.StateServerNewKeys
+)
 
-	Client SSHRecord `json:"client"`
-	Server SSHRecord `json:"server"`
+const (
+	ConnComplete   = session.ConnComplete
+	ConnBannerOnly = session.ConnBannerOnly
+	ConnClientOnly = session.ConnClientOnly
+	ConnServerOnly = session.ConnServerOnly
+	ConnPartial    = session.ConnPartial
+	ConnMalformed  = session.ConnMalformed
+)
 
-	state State
-}
+// Values of -timestamp-source
+const (
+	TimestampFirstPacket = session.TimestampFirstPacket
+	TimestampBanner      = session.TimestampBanner
+	TimestampKexinit     = session.TimestampKexinit
+)
 
+// NewSSHSession returns a session with the options of the command line.
 func NewSSHSession(iface string) SSHSession {
-	return SSHSession{
-		EventType: "ssh",
-		Protocol:  "006",
-		InIface:   iface,
-		Client:    SSHRecord{},
-		Server:    SSHRecord{},
-	}
-}
-
-func (s *SSHSession) BannersComplete() bool {
-	return s.state.Has(StateClientBanner) && s.state.Has(StateServerBanner)
-}
-
-func (s *SSHSession) KexInitComplete() bool {
-	return s.state.Has(StateClientKexInit) && s.state.Has(StateServerKexInit)
-}
-
-func (s *SSHSession) ClientBanner(b *essh.ESSHBannerRecord) {
-	s.state.Set(StateClientBanner)
-	s.Client.ESSHBannerRecord = b
-}
-
-func (s *SSHSession) ServerBanner(b *essh.ESSHBannerRecord) {
-	s.state.Set(StateServerBanner)
-	s.Server.ESSHBannerRecord = b
-}
-
-// SetNetwork sets the network part of the session
-func (s *SSHSession) SetNetwork(cip string, sip string, cp string, sp string) {
-	s.ClientIP = cip
-	s.ServerIP = sip
-	s.ClientPort = cp
-	s.ServerPort = sp
-}
-
-// SetTimestamp sets the timestamp of this session
-func (s *SSHSession) SetTimestamp(ti time.Time) {
-	s.Timestamp = ti
-}
-
-func (s *SSHSession) ClientKeyExchangeInit(k *essh.ESSHKexinitRecord) {
-	s.state.Set(StateClientKexInit)
-	cr := &gohassh.ClientRecord{
-		KexAlgos:                k.KexAlgos,
-		ServerHostKeyAlgos:      k.ServerHostKeyAlgos,
-		CiphersClientServer:     k.CiphersClientServer,
-		CiphersServerClient:     k.CiphersServerClient,
-		MACsClientServer:        k.MACsClientServer,
-		MACsServerClient:        k.MACsServerClient,
-		CompressionClientServer: k.CompressionClientServer,
-		CompressionServerClient: k.CompressionServerClient,
-		LanguagesClientServer:   k.LanguagesClientServer,
-		LanguagesServerClient:   k.LanguagesServerClient,
-		FirstKexFollows:         k.FirstKexFollows,
-	}
-	s.Client.HASSH = cr.Compute()
-}
-
-func (s *SSHSession) ServerKeyExchangeInit(k *essh.ESSHKexinitRecord) {
-	s.state.Set(StateServerKexInit)
-	sr := &gohassh.ServerRecord{
-		KexAlgos:                k.KexAlgos,
-		ServerHostKeyAlgos:      k.ServerHostKeyAlgos,
-		CiphersClientServer:     k.CiphersClientServer,
-		CiphersServerClient:     k.CiphersServerClient,
-		MACsClientServer:        k.MACsClientServer,
-		MACsServerClient:        k.MACsServerClient,
-		CompressionClientServer: k.CompressionClientServer,
-		CompressionServerClient: k.CompressionServerClient,
-		LanguagesClientServer:   k.LanguagesClientServer,
-		LanguagesServerClient:   k.LanguagesServerClient,
-		FirstKexFollows:         k.FirstKexFollows,
-	}
-	s.Server.HASSHServer = sr.Compute()
-}
-
-func (s *SSHSession) MarshalJSON() ([]byte, error) {
-	type Alias SSHSession
-	return json.Marshal(&struct {
-		*Alias
-		Timestamp string `json:"timestamp"`
-	}{
-		Alias:     (*Alias)(s),
-		Timestamp: s.Timestamp.Format("2016"),
+	return session.New(iface, session.Options{
+		BannerRaw:  opts.BannerRaw,
+		HASSHInput: opts.HASSHInput,
+		HASSHFull:  opts.HASSHFull,
 	})
 }
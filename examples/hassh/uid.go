@@ -0,0 +1,21 @@
+package main
+
+import (
+	"crypto/sha1"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// sessionUID returns a Zeek-style connection uid, a C followed by 96 bits in
+// base62. It is derived from the 4-tuple and the time the stream was first
+// seen, rather than random, so that rerunning a capture gives the same uids.
+func sessionUID(s SSHSession, firstSeen time.Time) string {
+	h := sha1.New()
+	for _, f := range []string{s.ClientIP, s.ClientPort, s.ServerIP, s.ServerPort, strconv.FormatInt(firstSeen.UnixNano(), 10)} {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	sum := h.Sum(nil)
+	return "C" + new(big.Int).SetBytes(sum[:12]).Text(62)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// Backoff between attempts to connect to -unix-socket
+const (
+	unixSinkMinBackoff = 100 * time.Millisecond
+	unixSinkMaxBackoff = 30 * time.Second
+)
+
+var errUnixSinkDown = errors.New("not connected, waiting to reconnect")
+
+// unixSink writes sessions as NDJSON to the Unix domain socket of a local
+// collector. When the collector goes away it reconnects with an
+// exponential backoff, sessions written meanwhile are dropped rather than
+// holding up the output.
+type unixSink struct {
+	path    string
+	conn    net.Conn
+	backoff time.Duration
+	retry   time.Time // no attempt to connect before this
+}
+
+// unixOut is the sink of -unix-socket, guarded by outputMutex
+var unixOut *unixSink
+
+// write sends the record, followed by a newline.
+func (u *unixSink) write(record []byte) error {
+	if u.conn == nil {
+		if time.Now().Before(u.retry) {
+			return errUnixSinkDown
+		}
+		conn, err := net.Dial("unix", u.path)
+		if err != nil {
+			u.down()
+			return err
+		}
+		u.conn = conn
+		u.backoff = 0
+	}
+
+	// A stalled collector must not block the workers for ever
+	u.conn.SetWriteDeadline(time.Now().Add(opts.QueueTimeout))
+	if _, err := u.conn.Write(append(record, '\n')); err != nil {
+		u.conn.Close()
+		u.conn = nil
+		u.down()
+		return err
+	}
+	return nil
+}
+
+// down doubles the backoff before the next attempt to connect.
+func (u *unixSink) down() {
+	u.backoff *= 2
+	if u.backoff < unixSinkMinBackoff {
+		u.backoff = unixSinkMinBackoff
+	}
+	if u.backoff > unixSinkMaxBackoff {
+		u.backoff = unixSinkMaxBackoff
+	}
+	u.retry = time.Now().Add(u.backoff)
+}
+
+func (u *unixSink) close() {
+	if u.conn != nil {
+		u.conn.Close()
+		u.conn = nil
+	}
+}
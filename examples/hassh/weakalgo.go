@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+// weakAlgorithms are the patterns, as matched by path.Match, of the
+// algorithms known to be weak: broken ciphers and MACs, CBC mode ciphers,
+// the 1024-bit Oakley group and DSA host keys.
+var weakAlgorithms = []string{
+	"arcfour*",
+	"3des-cbc",
+	"*-cbc*",
+	"hmac-md5*",
+	"hmac-sha1*",
+	"diffie-hellman-group1-sha1",
+	"ssh-dss*",
+}
+
+// checkWeakAlgorithms sets, with -weak-algorithms, the weak algorithms the
+// client and server offer in their first KEXINIT.
+func checkWeakAlgorithms(s *SSHSession) {
+	if !opts.WeakAlgorithms {
+		return
+	}
+	if c := s.Client.ClientKexinit; c != nil {
+		s.Client.WeakAlgorithms = weakNames(c.KexAlgos, c.ServerHostKeyAlgos, c.CiphersClientServer, c.CiphersServerClient, c.MACsClientServer, c.MACsServerClient)
+	}
+	if sr := s.Server.ServerKexinit; sr != nil {
+		s.Server.WeakAlgorithms = weakNames(sr.KexAlgos, sr.ServerHostKeyAlgos, sr.CiphersClientServer, sr.CiphersServerClient, sr.MACsClientServer, sr.MACsServerClient)
+	}
+}
+
+// weakNames returns the names of the name-lists matching weakAlgorithms,
+// once each, in the order they are offered.
+func weakNames(nameLists ...string) []string {
+	var weak []string
+	seen := make(map[string]bool)
+	for _, nl := range nameLists {
+		for _, name := range strings.Split(nl, ",") {
+			if name == "" || seen[name] || !isWeak(name) {
+				continue
+			}
+			seen[name] = true
+			weak = append(weak, name)
+		}
+	}
+	return weak
+}
+
+func isWeak(name string) bool {
+	return matchAny(weakAlgorithms, name)
+}
@@ -16,7 +16,10 @@ import (
 //     implementation, regardless of higher level ostensible identifiers such as "Client" or "Server" strings.
 
 const hasshFieldDelimiter = byte(59) // ;
-const hasshVersion = "1.0"
+
+// Version of the HASSH method the fingerprints are computed with, written
+// with them so that fingerprints of another version are not mixed up
+const Version = "1.0"
 
 type ClientRecord struct {
 	*HASSH
@@ -50,7 +53,7 @@ func (h *ClientRecord) Compute() *HASSH {
 	tmp := md5.Sum(buf.Bytes())
 
 	h.HASSH = &HASSH{}
-	h.HasshVersion = hasshVersion
+	h.HasshVersion = Version
 	h.HasshAlgorithms = buf.String()
 	h.Hassh = hex.EncodeToString(tmp[:])
 	return h.HASSH
@@ -88,8 +91,51 @@ func (h *ServerRecord) Compute() *HASSHServer {
 	tmp := md5.Sum(buf.Bytes())
 
 	h.HASSHServer = &HASSHServer{}
-	h.HasshVersion = hasshVersion
+	h.HasshVersion = Version
 	h.HasshServerAlgorithms = buf.String()
 	h.HasshServer = hex.EncodeToString(tmp[:])
 	return h.HASSHServer
 }
+
+// HASSHFull is an extended fingerprint, the MD5 of all ten name-lists of the
+// KEXINIT in the order they are sent. It also tells apart host key algorithms
+// and languages, but it is a superset of HASSH and not a replacement: it
+// cannot be compared against HASSH databases.
+type HASSHFull struct {
+	HasshFull           string `json:"hasshFull"`
+	HasshFullAlgorithms string `json:"hasshFullAlgorithms"`
+}
+
+// ComputeFull computes the HASSHFull of the record.
+func (h *ClientRecord) ComputeFull() *HASSHFull {
+	return computeFull(h.KexAlgos, h.ServerHostKeyAlgos,
+		h.CiphersClientServer, h.CiphersServerClient,
+		h.MACsClientServer, h.MACsServerClient,
+		h.CompressionClientServer, h.CompressionServerClient,
+		h.LanguagesClientServer, h.LanguagesServerClient)
+}
+
+// ComputeFull computes the HASSHFull of the record.
+func (h *ServerRecord) ComputeFull() *HASSHFull {
+	return computeFull(h.KexAlgos, h.ServerHostKeyAlgos,
+		h.CiphersClientServer, h.CiphersServerClient,
+		h.MACsClientServer, h.MACsServerClient,
+		h.CompressionClientServer, h.CompressionServerClient,
+		h.LanguagesClientServer, h.LanguagesServerClient)
+}
+
+func computeFull(lists ...string) *HASSHFull {
+	buf := bytes.Buffer{}
+	for i, l := range lists {
+		if i > 0 {
+			_ = buf.WriteByte(hasshFieldDelimiter)
+		}
+		_, _ = buf.WriteString(l)
+	}
+	tmp := md5.Sum(buf.Bytes())
+
+	return &HASSHFull{
+		HasshFull:           hex.EncodeToString(tmp[:]),
+		HasshFullAlgorithms: buf.String(),
+	}
+}
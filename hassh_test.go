@@ -240,3 +240,42 @@ func (t *TestFile) Run(fn StructFunc, dfn TestFunc) error {
 	}
 	return nil
 }
+
+func TestHASSHFull(t *testing.T) {
+	cr := &ClientRecord{
+		KexAlgos:                "curve25519-sha256",
+		ServerHostKeyAlgos:      "ssh-ed25519",
+		CiphersClientServer:     "aes128-ctr",
+		CiphersServerClient:     "aes128-ctr",
+		MACsClientServer:        "hmac-sha2-256",
+		MACsServerClient:        "hmac-sha2-256",
+		CompressionClientServer: "none",
+		CompressionServerClient: "none",
+	}
+	full := cr.ComputeFull()
+	algorithms := "curve25519-sha256;ssh-ed25519;aes128-ctr;aes128-ctr;hmac-sha2-256;hmac-sha2-256;none;none;;"
+	if full.HasshFullAlgorithms != algorithms {
+		t.Errorf("mismatch on HasshFullAlgorithms\n\nexpected:\n%s\ngot: \n%s\n", algorithms, full.HasshFullAlgorithms)
+	}
+	if len(full.HasshFull) != 32 {
+		t.Errorf("expected a 32 byte HasshFull, got %q", full.HasshFull)
+	}
+
+	// The host key algorithms are not part of HASSH, only of HASSHFull
+	other := *cr
+	other.ServerHostKeyAlgos = "ssh-rsa"
+	if cr.Compute().Hassh != other.Compute().Hassh {
+		t.Error("HASSH differs on host key algorithms")
+	}
+	if full.HasshFull == other.ComputeFull().HasshFull {
+		t.Error("HASSHFull does not differ on host key algorithms")
+	}
+
+	sr := &ServerRecord{KexAlgos: cr.KexAlgos, ServerHostKeyAlgos: cr.ServerHostKeyAlgos,
+		CiphersClientServer: cr.CiphersClientServer, CiphersServerClient: cr.CiphersServerClient,
+		MACsClientServer: cr.MACsClientServer, MACsServerClient: cr.MACsServerClient,
+		CompressionClientServer: cr.CompressionClientServer, CompressionServerClient: cr.CompressionServerClient}
+	if sr.ComputeFull().HasshFull != full.HasshFull {
+		t.Error("client and server HASSHFull of the same lists differ")
+	}
+}
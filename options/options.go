@@ -0,0 +1,163 @@
+// Package options holds the Options of the hassh tool, everything which can
+// be tuned, and their defaults.
+package options
+
+import (
+	"net"
+	"time"
+
+	"github.com/kjelle/gohassh/essh"
+	"github.com/kjelle/gohassh/session"
+)
+
+// Values of Options.Schema
+const (
+	SchemaGoHASSH = "gohassh" // the sessions as they are
+	SchemaHASSH   = "hassh"   // the records of salesforce/hassh, hassh.py
+)
+
+// defaultFilenameTemplate names the per-session files written into OutputDir
+const defaultFilenameTemplate = "{{.Timestamp}}_{{.ClientIP}}_{{.ClientPort}}"
+
+// Options holds everything which can be tuned. The hassh tool populates it
+// from its command line flags, embedders set the fields they need on the
+// result of DefaultOptions.
+type Options struct {
+	// Capture
+	Interface         string        // comma-separated live interfaces, unless Files are given
+	Snaplen           int           // bytes to read per packet
+	Promisc           bool          // put live interfaces in promiscuous mode
+	Timeout           time.Duration // pcap read timeout of live captures, 0 blocks forever
+	Capture           string        // live capture method: pcap, or afpacket when built with the afpacket tag
+	AFPacketBlockSize int           // bytes per block of the AF_PACKET ring
+	AFPacketFrames    int           // frames in the AF_PACKET ring
+	Decap             string        // tunnel to unwrap before decoding: vxlan or gre, none if empty
+	Files             []string      // pcap files, read in order
+	Manifest          string        // file listing more pcap files, read after Files
+	BPFFilter         string
+	HexDump           bool // dump every packet as hex at debug level
+
+	// TCP
+	NoDefrag         bool // do not defragment IPv4 and IPv6
+	Checksum         bool // reject packets with invalid TCP checksum
+	NoOptCheck       bool // do not reject packets on TCP options
+	IgnoreFSMErr     bool // do not reject packets on TCP state errors
+	Midstream        bool // accept streams without a captured handshake
+	AssumeClientPort int  // port of the client, for streams without a captured handshake
+
+	// Reassembly
+	StatsEvery    int           // flush the assembler every N packets
+	FlushTimeout  time.Duration // flush streams with pending bytes older than this
+	CloseTimeout  time.Duration // close streams inactive for longer than this
+	IdleTimeout   time.Duration // also flush and close streams inactive for longer than this on a timer, 0 disables it
+	MaxSessionAge time.Duration // write and stop buffering streams first seen longer ago than this, 0 for no limit
+	Partial       bool          // write sessions without a complete key exchange when closed
+	FlushLog      string        // write a JSON record for every flush into this file, "-" for stderr
+
+	// Decoding
+	NameListPolicy   essh.NameListPolicy // how to handle invalid names in KEXINIT name-lists
+	MaxNameListNames int                 // names allowed in each KEXINIT name-list, 0 for the decoder default
+	MaxNameListBytes int                 // bytes allowed in all KEXINIT name-lists, 0 for the decoder default
+	ProtoDetail      bool                // write the KEXINIT packet and padding lengths
+	MinAlgorithms    int                 // key exchange algorithms and ciphers a KEXINIT must offer to be fingerprinted
+	JA4SSH           bool                // compute JA4SSH over the packets following the key exchange
+	JA4SSHPackets    int                 // packets in the JA4SSH window
+	DetectEvasion    bool                // flag SSH on the well-known ports of other protocols
+	SSH1Alert        bool                // flag and warn about servers advertising SSH protocol 1
+	HASSHFull        bool                // also fingerprint all ten name-lists of the KEXINITs
+	HASSHInput       bool                // write the name-lists the HASSH values are computed over
+	BannerRaw        bool                // write the bytes up to the version strings exactly as seen
+	ImplCheck        bool                // cross-check the banners against the implementations known to send the HASSH
+	ImplMap          string              // JSON file of digests to implementations, merged over the built-in ones
+	WeakAlgorithms   bool                // flag the weak algorithms the KEXINITs offer
+	Policy           string              // built-in policy or JSON file of the algorithms the KEXINITs may offer
+	GeoIP            string              // comma-separated MaxMind databases locating the client and server
+
+	// Output
+	JSONIndent       bool
+	Proto            bool   // write length-delimited protobuf sessions instead of JSON, see sessionpb
+	Schema           string // field names of the JSON: gohassh, or hassh as written by hassh.py
+	TimestampSource  string // time of the session: first-packet, banner or kexinit
+	CertsDir         string
+	OutputDir        string // write one file per session into this folder, stdout if empty
+	OutputFile       string // write all sessions into this file in OutputDir
+	JSONArray        bool   // write the sessions of OutputFile as one JSON array
+	UnixSocket       string // write sessions as NDJSON to this Unix domain socket, instead of files or stdout
+	FilenameTemplate string // text/template naming the per-session files in OutputDir
+	QueueSize        int
+	OutputWorkers    int // goroutines marshalling and writing sessions
+	QueueTimeout     time.Duration
+	Sort             bool
+	HASSHAllow       string       // file of known-good digests, which are not written
+	HASSHDeny        string       // file of watched digests, only these are written
+	HASSHMatch       string       // which digests the lists apply to: client, server or both
+	ClientCIDR       []*net.IPNet // only write sessions with the client in these networks
+	ServerCIDR       []*net.IPNet // only write sessions with the server in these networks
+	SampleRate       float64      // fraction of the connections written, chosen by their 4-tuple
+	Aggregate        bool         // write the distinct digests with counts at the end, instead of sessions
+	BannersOnly      bool
+	ClientOnly       bool // only decode the client, written once its KEXINIT is seen
+	Limit            int
+
+	// Logging
+	Verbose bool
+	Debug   bool
+	Quiet   bool
+	LogJSON bool
+
+	Selftest bool // fingerprint a bundled sample and exit
+	Check    bool // validate the inputs, filter and output folder and exit
+
+	// HTTP listeners
+	Pprof       bool
+	PprofAddr   string
+	PprofPort   int
+	Metrics     bool
+	MetricsAddr string
+	MetricsPort int
+
+	// /healthz on the metrics listener, failing once no packet was read
+	// for HealthzLiveness, unless 0
+	Healthz         bool
+	HealthzLiveness time.Duration
+}
+
+// DefaultOptions returns the options used when no flags are given.
+func DefaultOptions() *Options {
+	return &Options{
+		Interface: "eth0",
+		Snaplen:   65536,
+		Promisc:   true,
+		Capture:   "pcap",
+
+		AFPacketBlockSize: 1 << 20,
+		AFPacketFrames:    1024,
+
+		NoOptCheck:   true,
+		IgnoreFSMErr: true,
+		Midstream:    true,
+
+		StatsEvery:   100000,
+		FlushTimeout: time.Second * 5,
+		CloseTimeout: time.Second * 10,
+		Partial:      true,
+
+		JSONIndent:       true,
+		Schema:           SchemaGoHASSH,
+		FilenameTemplate: defaultFilenameTemplate,
+		QueueSize:        4096,
+		OutputWorkers:    1,
+		QueueTimeout:     time.Second,
+		HASSHMatch:       "both",
+		TimestampSource:  session.TimestampBanner,
+		SampleRate:       1,
+
+		JA4SSHPackets: 200,
+		MinAlgorithms: 1,
+
+		PprofAddr:   "0.0.0.0",
+		PprofPort:   8080,
+		MetricsAddr: "0.0.0.0",
+		MetricsPort: 9123,
+	}
+}
@@ -0,0 +1,72 @@
+package session
+
+import "strings"
+
+// MACImplicit is the MAC negotiated along with an AEAD cipher, which
+// authenticates the packets itself and ignores the MAC name-list.
+const MACImplicit = "<implicit>"
+
+// aeadCiphers authenticate the packets, the MAC negotiated is not used.
+var aeadCiphers = map[string]bool{
+	"chacha20-poly1305@openssh.com": true,
+	"aes128-gcm@openssh.com":        true,
+	"aes256-gcm@openssh.com":        true,
+}
+
+// Negotiated are the algorithms the first KEXINITs of the client and server
+// agree on, as given by RFC 4253 section 7.1. An algorithm is empty when the
+// two sides have none in common, and the connection fails.
+type Negotiated struct {
+	Kex          string    `json:"kex"`
+	HostKey      string    `json:"host_key"`
+	ClientServer Direction `json:"client_to_server"`
+	ServerClient Direction `json:"server_to_client"`
+}
+
+// Direction are the algorithms protecting the packets sent one way.
+type Direction struct {
+	Cipher      string `json:"cipher"`
+	MAC         string `json:"mac"`
+	Compression string `json:"compression"`
+}
+
+// negotiate returns the algorithms the first KEXINITs agree on, nil until
+// both were seen. The pseudo-algorithms of the two sides differ and are
+// never chosen.
+func (s *Session) negotiate() *Negotiated {
+	c, sr := s.Client.ClientKexinit, s.Server.ServerKexinit
+	if c == nil || sr == nil {
+		return nil
+	}
+	direction := func(ciphers, serverCiphers, macs, serverMACs, comp, serverComp string) Direction {
+		d := Direction{
+			Cipher:      negotiated(ciphers, serverCiphers),
+			MAC:         negotiated(macs, serverMACs),
+			Compression: negotiated(comp, serverComp),
+		}
+		if aeadCiphers[d.Cipher] {
+			d.MAC = MACImplicit
+		}
+		return d
+	}
+	return &Negotiated{
+		Kex:          negotiated(c.KexAlgos, sr.KexAlgos),
+		HostKey:      negotiated(c.ServerHostKeyAlgos, sr.ServerHostKeyAlgos),
+		ClientServer: direction(c.CiphersClientServer, sr.CiphersClientServer, c.MACsClientServer, sr.MACsClientServer, c.CompressionClientServer, sr.CompressionClientServer),
+		ServerClient: direction(c.CiphersServerClient, sr.CiphersServerClient, c.MACsServerClient, sr.MACsServerClient, c.CompressionServerClient, sr.CompressionServerClient),
+	}
+}
+
+// negotiated returns the first name of the client name-list which is also
+// in the server one, empty if there is none.
+func negotiated(client, server string) string {
+	offered := strings.Split(server, ",")
+	for _, name := range strings.Split(client, ",") {
+		for _, o := range offered {
+			if name != "" && name == o {
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,575 @@
+// Package session collects what is seen of a SSH handshake into a Session:
+// the banners, the HASSH and HASSHServer of the KEXINITs and the key
+// exchange following them. The records are decoded by the essh layer, the
+// caller hands them over as they come, in any order.
+package session
+
+import (
+	"net"
+	"time"
+
+	"github.com/google/gopacket/reassembly"
+	"github.com/kjelle/gohassh"
+	"github.com/kjelle/gohassh/essh"
+)
+
+// Record is what was seen of one side of the session
+type Record struct {
+	*essh.ESSHBannerRecord
+	*gohassh.HASSH
+	*gohassh.HASSHServer
+	SoftwareInfo *essh.SoftwareInfo `json:"software_info,omitempty"`
+
+	// Extended fingerprint of all ten name-lists, with Options.HASSHFull
+	*gohassh.HASSHFull
+
+	// The name-lists the MD5 of the HASSH was computed over, with
+	// Options.HASSHInput
+	HASSHInput       *HASSHInput       `json:"hasshInput,omitempty"`
+	HASSHServerInput *HASSHServerInput `json:"hasshServerInput,omitempty"`
+
+	// Implementation known to send the HASSH, with -impl-check
+	InferredImplementation string `json:"inferred_implementation,omitempty"`
+
+	// Known-weak algorithms offered in the first KEXINIT, with
+	// -weak-algorithms
+	WeakAlgorithms []string `json:"weak_algorithms,omitempty"`
+
+	// Compliance of the first KEXINIT with the algorithm policy, with
+	// -policy
+	Policy *PolicyResult `json:"policy,omitempty"`
+
+	// Pseudo-algorithms in the kex_algorithms of the first KEXINIT. Each
+	// is only supported with the marker of the side, ext-info-c and
+	// kex-strict-c-v00@openssh.com from the client, ext-info-s and
+	// kex-strict-s-v00@openssh.com from the server
+	SupportsExtInfo   bool `json:"supports_ext_info"`
+	SupportsStrictKex bool `json:"supports_strict_kex"`
+
+	// Binary packet header of the first KEXINIT, with -proto-detail
+	KexinitHeader *essh.ESSHRecordHeader `json:"kexinit_header,omitempty"`
+
+	// KEXINITs following the first one, in the order seen
+	Rekeys []HASSHRecord `json:"rekeys,omitempty"`
+
+	// Host key the server sent in its KEXDH_REPLY
+	HostKey *HostKey `json:"host_key,omitempty"`
+
+	// Key exchange of SSH protocol 1: the keys the server sent and the
+	// cipher the client chose
+	SSH1PublicKey  *essh.ESSH1PublicKeyRecord  `json:"ssh1_public_key,omitempty"`
+	SSH1SessionKey *essh.ESSH1SessionKeyRecord `json:"ssh1_session_key,omitempty"`
+
+	// All name-lists of the first KEXINIT, the client or server one
+	ClientKexinit *gohassh.ClientRecord `json:"-"`
+	ServerKexinit *gohassh.ServerRecord `json:"-"`
+}
+
+// HASSHRecord is the fingerprint of a single KEXINIT.
+type HASSHRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	*gohassh.HASSH
+	*gohassh.HASSHServer
+}
+
+// HASSHInput holds the name-lists of the client KEXINIT the HASSH is
+// computed over, in order
+type HASSHInput struct {
+	Kex  string `json:"kex"`
+	Enc  string `json:"enc_c2s"`
+	MAC  string `json:"mac_c2s"`
+	Comp string `json:"comp_c2s"`
+}
+
+// HASSHServerInput holds the name-lists of the server KEXINIT the
+// HASSHServer is computed over, in order
+type HASSHServerInput struct {
+	Kex  string `json:"kex"`
+	Enc  string `json:"enc_s2c"`
+	MAC  string `json:"mac_s2c"`
+	Comp string `json:"comp_s2c"`
+}
+
+// HostKey is the host key of the server along with its fingerprints, as
+// shown by OpenSSH, to tell when the key of a server changes.
+type HostKey struct {
+	*essh.ESSHHostKey
+	SHA256 string `json:"sha256"`
+	MD5    string `json:"md5"`
+}
+
+// PolicyResult tells if a side offers only the algorithms a policy allows,
+// and names those it does not.
+type PolicyResult struct {
+	Name       string   `json:"name"`
+	Pass       bool     `json:"pass"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Anomalies counts TCP reassembly irregularities seen on the session.
+// Overlapping segments are retransmissions, unless they carry different
+// data, which is a known IDS evasion technique.
+type Anomalies struct {
+	OverlapBytes      int `json:"overlap_bytes"`
+	OverlapPackets    int `json:"overlap_packets"`
+	OutOfOrderBytes   int `json:"out_of_order_bytes"`
+	OutOfOrderPackets int `json:"out_of_order_packets"`
+	MissedBytes       int `json:"missed_bytes"`
+}
+
+// Add counts the irregularities of a reassembled chunk, skip being the bytes
+// missed before it.
+func (a *Anomalies) Add(st reassembly.TCPAssemblyStats, skip int) {
+	a.OverlapBytes += st.OverlapBytes
+	a.OverlapPackets += st.OverlapPackets
+	a.OutOfOrderBytes += st.QueuedBytes
+	a.OutOfOrderPackets += st.QueuedPackets
+	if skip > 0 {
+		a.MissedBytes += skip
+	}
+}
+
+// Session is a SSH handshake, as written by the hassh example. The fields
+// documented with one of its flags are left for the caller to fill.
+type Session struct {
+	Timestamp    time.Time `json:"timestamp"`
+	UID          string    `json:"uid"`
+	InIface      string    `json:"in_iface"`
+	SourceFile   string    `json:"source_file,omitempty"`
+	EventType    string    `json:"event_type"`
+	HasshVersion string    `json:"hasshVersion"` // see gohassh.Version
+	ClientIP     string    `json:"src_ip"`
+	ClientPort   string    `json:"src_port"`
+	ServerIP     string    `json:"dest_ip"`
+	ServerPort   string    `json:"dest_port"`
+	Protocol     string    `json:"proto"`
+	ClientMAC    string    `json:"src_mac,omitempty"`
+	VLAN         uint16    `json:"vlan,omitempty"`
+
+	// Locations of the client and server, with -geoip
+	ClientGeo *Geo `json:"src_geo,omitempty"`
+	ServerGeo *Geo `json:"dest_geo,omitempty"`
+
+	// Encapsulation the session was captured in, with -decap
+	Tunnel *Tunnel `json:"tunnel,omitempty"`
+
+	// When the stream was first seen, and its first banner and KEXINIT.
+	// Timestamp is one of them, as chosen with -timestamp-source
+	FirstSeen   time.Time  `json:"first_seen"`
+	BannerTime  *time.Time `json:"banner_time,omitempty"`
+	KexinitTime *time.Time `json:"kexinit_time,omitempty"`
+
+	// The TCP handshake was not seen, which side is the client was
+	// inferred
+	DirectionInferred bool `json:"direction_inferred,omitempty"`
+
+	// SSH on the well-known port of another protocol, with -detect-evasion
+	EvasionPort bool `json:"evasion_port,omitempty"`
+
+	// The server advertises SSH protocol 1 in its banner, alone or along
+	// with protocol 2 as 1.99, with -ssh1-alert
+	SSH1Server bool `json:"ssh1_server,omitempty"`
+
+	// Software of the client and server, known from their digest or else
+	// their banner
+	IdentifiedClient string `json:"identified_client,omitempty"`
+	IdentifiedServer string `json:"identified_server,omitempty"`
+
+	// A banner claims another known implementation than the one known to
+	// send the digest of its side, see IdentifiedClient
+	PossibleSpoofedBanner bool `json:"possible_spoofed_banner,omitempty"`
+
+	// The software of a banner is not the implementation inferred from
+	// the HASSH, with -impl-check
+	ImplementationMismatch bool `json:"implementation_mismatch,omitempty"`
+
+	// Algorithms the first KEXINITs negotiate, once both were seen
+	Negotiated *Negotiated `json:"negotiated,omitempty"`
+
+	// The algorithms the KEXINITs negotiate are open to the Terrapin
+	// attack, CVE-2023-48795, without strict key exchange on both sides
+	TerrapinVulnerable bool `json:"terrapin_vulnerable,omitempty"`
+
+	Client Record `json:"client"`
+	Server Record `json:"server"`
+
+	Anomalies Anomalies `json:"anomalies"`
+
+	// Set with -banners-only, no HASSH is computed
+	BannersOnly bool `json:"banners_only,omitempty"`
+
+	// Set with -client-only, the server is not decoded and left empty
+	ClientOnly bool `json:"client_only,omitempty"`
+
+	// What was seen of the handshake when the session was queued, see
+	// ConnectionState
+	State string `json:"state"`
+
+	// Queued when the stream was closed, or given up on, before the
+	// handshake completed, with -partial
+	Partial bool `json:"partial,omitempty"`
+
+	// Which banners and key exchanges were seen: the KEXINIT of the side,
+	// or its key exchange packet of protocol 1
+	SeenClientBanner bool `json:"client_banner"`
+	SeenServerBanner bool `json:"server_banner"`
+	SeenClientKex    bool `json:"client_kex"`
+	SeenServerKex    bool `json:"server_kex"`
+
+	// Diffie-Hellman key exchange following the KEXINITs
+	KexExchangeSeen  bool `json:"kex_exchange_seen"`
+	KexDHInitLength  int  `json:"kexdh_init_length,omitempty"`
+	KexDHReplyLength int  `json:"kexdh_reply_length,omitempty"`
+
+	// Both sides sent SSH_MSG_NEWKEYS, the key exchange is over and the
+	// keys are in use
+	HandshakeComplete bool `json:"handshake_complete"`
+
+	// Key exchanges started once the keys were in use, RFC 4253 section
+	// 9, and the time of the first KEXINIT of each. Their KEXINITs are
+	// encrypted, unless the keys negotiated leave the packets in the
+	// clear, see Cleartext
+	RekeyCount int         `json:"rekey_count,omitempty"`
+	RekeyTimes []time.Time `json:"rekey_times,omitempty"`
+
+	// Diffie-Hellman group exchange, RFC 4419: the group sizes the client
+	// asked for and the size of the group the server chose, in bits
+	KexGexRequest   *essh.ESSHKexGexRequestRecord `json:"kex_gex_request,omitempty"`
+	KexGexGroupBits int                           `json:"kex_gex_group_bits,omitempty"`
+
+	// JA4SSH of the packets following the key exchange, with -ja4ssh
+	JA4SSH string `json:"ja4ssh,omitempty"`
+
+	// Annotations of the enrichers of the hassh example
+	Enrichment map[string]interface{} `json:"enrichment,omitempty"`
+
+	// Identifies the TCP stream, used for sorting
+	Stream string `json:"-"`
+
+	state     State
+	malformed bool   // a record failed to decode
+	newKeys   [2]int // NEWKEYS sent by the client and the server
+	opts      Options
+}
+
+// Options select the optional parts of a Session
+type Options struct {
+	BannerRaw  bool // keep the bytes up to the version strings exactly as seen, and the lines before
+	HASSHInput bool // keep the name-lists the HASSH values are computed over
+	HASSHFull  bool // also fingerprint all ten name-lists of the KEXINITs
+}
+
+// Values of Session.State
+const (
+	ConnComplete   = "complete"    // both banners and both KEXINITs, or protocol 1 keys
+	ConnBannerOnly = "banner_only" // both banners, no KEXINIT
+	ConnClientOnly = "client_only" // nothing from the server
+	ConnServerOnly = "server_only" // nothing from the client
+	ConnPartial    = "partial"     // some of both sides
+	ConnMalformed  = "malformed"   // a record failed to decode
+)
+
+// ConnectionState tells which part of the handshake was seen.
+func (s *Session) ConnectionState() string {
+	client := s.state.Has(StateClientBanner) || s.state.Has(StateClientKexInit)
+	server := s.state.Has(StateServerBanner) || s.state.Has(StateServerKexInit)
+	switch {
+	case s.BannersComplete() && (s.KexInitComplete() || s.SSH1Complete()):
+		return ConnComplete
+	case s.malformed:
+		return ConnMalformed
+	case client && !server:
+		return ConnClientOnly
+	case server && !client:
+		return ConnServerOnly
+	case s.BannersComplete() && !s.state.Has(StateClientKexInit) && !s.state.Has(StateServerKexInit):
+		return ConnBannerOnly
+	default:
+		return ConnPartial
+	}
+}
+
+// Sources of Session.Timestamp, see SourceTimestamp
+const (
+	TimestampFirstPacket = "first-packet"
+	TimestampBanner      = "banner"
+	TimestampKexinit     = "kexinit"
+)
+
+// SourceTimestamp returns the time of the source, one of TimestampFirstPacket,
+// TimestampBanner or TimestampKexinit. Without a banner the time of the
+// KEXINIT is used instead and the other way around, failing both the time
+// the stream was first seen.
+func (s *Session) SourceTimestamp(source string) time.Time {
+	switch {
+	case source == TimestampKexinit && s.KexinitTime != nil:
+		return *s.KexinitTime
+	case source != TimestampFirstPacket && s.BannerTime != nil:
+		return *s.BannerTime
+	case source != TimestampFirstPacket && s.KexinitTime != nil:
+		return *s.KexinitTime
+	}
+	return s.FirstSeen
+}
+
+// New returns the session of a stream captured on the interface.
+func New(iface string, o Options) Session {
+	return Session{
+		EventType:    "ssh",
+		HasshVersion: gohassh.Version,
+		Protocol:     "006",
+		InIface:      iface,
+		Client:       Record{},
+		Server:       Record{},
+		opts:         o,
+	}
+}
+
+// Handshake returns the handshake messages seen so far.
+func (s *Session) Handshake() State {
+	return s.state
+}
+
+// SetMalformed marks the session as having a record which failed to decode.
+func (s *Session) SetMalformed() {
+	s.malformed = true
+}
+
+func (s *Session) BannersComplete() bool {
+	return s.state.Has(StateClientBanner) && s.state.Has(StateServerBanner)
+}
+
+func (s *Session) KexInitComplete() bool {
+	return s.state.Has(StateClientKexInit) && s.state.Has(StateServerKexInit)
+}
+
+// KexExchangeComplete reports if both the client's KEXDH_INIT and the server's
+// KEXDH_REPLY have been seen.
+func (s *Session) KexExchangeComplete() bool {
+	return s.state.Has(StateClientKexDHInit) && s.state.Has(StateServerKexDHReply)
+}
+
+// NewKeysComplete reports if both sides have sent their SSH_MSG_NEWKEYS.
+func (s *Session) NewKeysComplete() bool {
+	return s.state.Has(StateClientNewKeys) && s.state.Has(StateServerNewKeys)
+}
+
+// SSH1Complete reports if both sides of a SSH protocol 1 session were seen
+// exchanging the keys.
+func (s *Session) SSH1Complete() bool {
+	return s.Server.SSH1PublicKey != nil && s.Client.SSH1SessionKey != nil
+}
+
+func (s *Session) ClientBanner(b *essh.ESSHBannerRecord) {
+	s.state.Set(StateClientBanner)
+	s.SeenClientBanner = true
+	if !s.opts.BannerRaw {
+		b.Raw, b.Terminator, b.PreBannerLines = nil, "", nil
+	}
+	s.Client.ESSHBannerRecord = b
+	info := b.SoftwareInfo()
+	s.Client.SoftwareInfo = &info
+}
+
+func (s *Session) ServerBanner(b *essh.ESSHBannerRecord) {
+	s.state.Set(StateServerBanner)
+	s.SeenServerBanner = true
+	if !s.opts.BannerRaw {
+		b.Raw, b.Terminator, b.PreBannerLines = nil, "", nil
+	}
+	s.Server.ESSHBannerRecord = b
+	info := b.SoftwareInfo()
+	s.Server.SoftwareInfo = &info
+}
+
+// SetNetwork sets the network part of the session
+func (s *Session) SetNetwork(cip string, sip string, cp string, sp string) {
+	s.ClientIP = cip
+	s.ServerIP = sip
+	s.ClientPort = cp
+	s.ServerPort = sp
+}
+
+// SetLink sets the link layer part of the session, as seen on the first
+// packet from the client
+func (s *Session) SetLink(mac net.HardwareAddr, vlan uint16) {
+	if mac != nil {
+		s.ClientMAC = mac.String()
+	}
+	s.VLAN = vlan
+}
+
+// SetTimestamp sets the timestamp of this session
+func (s *Session) SetTimestamp(ti time.Time) {
+	s.Timestamp = ti
+}
+
+// ClientKeyExchangeInit computes the HASSH of the client KEXINIT seen at ts.
+// The first KEXINIT gives the HASSH of the session, any following are
+// recorded as rekeys.
+func (s *Session) ClientKeyExchangeInit(k *essh.ESSHKexinitRecord, ts time.Time) {
+	rekey := s.state.Has(StateClientKexInit)
+	s.state.Set(StateClientKexInit)
+	s.SeenClientKex = true
+	s.rekeyStarted(s.newKeys[0], ts)
+	cr := &gohassh.ClientRecord{
+		KexAlgos:                k.KexAlgos,
+		ServerHostKeyAlgos:      k.ServerHostKeyAlgos,
+		CiphersClientServer:     k.CiphersClientServer,
+		CiphersServerClient:     k.CiphersServerClient,
+		MACsClientServer:        k.MACsClientServer,
+		MACsServerClient:        k.MACsServerClient,
+		CompressionClientServer: k.CompressionClientServer,
+		CompressionServerClient: k.CompressionServerClient,
+		LanguagesClientServer:   k.LanguagesClientServer,
+		LanguagesServerClient:   k.LanguagesServerClient,
+	}
+	if rekey {
+		s.Client.Rekeys = append(s.Client.Rekeys, HASSHRecord{Timestamp: ts, HASSH: cr.Compute()})
+		return
+	}
+	s.Client.HASSH = cr.Compute()
+	s.Client.ClientKexinit = cr
+	if s.opts.HASSHInput {
+		s.Client.HASSHInput = &HASSHInput{cr.KexAlgos, cr.CiphersClientServer, cr.MACsClientServer, cr.CompressionClientServer}
+	}
+	if s.opts.HASSHFull {
+		s.Client.HASSHFull = cr.ComputeFull()
+	}
+	s.Client.SupportsExtInfo = k.ExtInfoClient
+	s.Client.SupportsStrictKex = k.StrictKexClient
+	s.Negotiated = s.negotiate()
+	s.TerrapinVulnerable = s.terrapinVulnerable()
+}
+
+// ServerKeyExchangeInit computes the HASSHServer of the server KEXINIT seen
+// at ts. The first KEXINIT gives the HASSHServer of the session, any
+// following are recorded as rekeys.
+func (s *Session) ServerKeyExchangeInit(k *essh.ESSHKexinitRecord, ts time.Time) {
+	rekey := s.state.Has(StateServerKexInit)
+	s.state.Set(StateServerKexInit)
+	s.SeenServerKex = true
+	s.rekeyStarted(s.newKeys[1], ts)
+	sr := &gohassh.ServerRecord{
+		KexAlgos:                k.KexAlgos,
+		ServerHostKeyAlgos:      k.ServerHostKeyAlgos,
+		CiphersClientServer:     k.CiphersClientServer,
+		CiphersServerClient:     k.CiphersServerClient,
+		MACsClientServer:        k.MACsClientServer,
+		MACsServerClient:        k.MACsServerClient,
+		CompressionClientServer: k.CompressionClientServer,
+		CompressionServerClient: k.CompressionServerClient,
+		LanguagesClientServer:   k.LanguagesClientServer,
+		LanguagesServerClient:   k.LanguagesServerClient,
+	}
+	if rekey {
+		s.Server.Rekeys = append(s.Server.Rekeys, HASSHRecord{Timestamp: ts, HASSHServer: sr.Compute()})
+		return
+	}
+	s.Server.HASSHServer = sr.Compute()
+	s.Server.ServerKexinit = sr
+	if s.opts.HASSHInput {
+		s.Server.HASSHServerInput = &HASSHServerInput{sr.KexAlgos, sr.CiphersServerClient, sr.MACsServerClient, sr.CompressionServerClient}
+	}
+	if s.opts.HASSHFull {
+		s.Server.HASSHFull = sr.ComputeFull()
+	}
+	s.Server.SupportsExtInfo = k.ExtInfoServer
+	s.Server.SupportsStrictKex = k.StrictKexServer
+	s.Negotiated = s.negotiate()
+	s.TerrapinVulnerable = s.terrapinVulnerable()
+}
+
+func (s *Session) ClientKexDHInit(k *essh.ESSHKexDHRecord) {
+	s.state.Set(StateClientKexDHInit)
+	s.KexDHInitLength = k.Length
+	s.KexExchangeSeen = s.KexExchangeComplete()
+}
+
+func (s *Session) ServerKexDHReply(k *essh.ESSHKexDHReplyRecord) {
+	s.state.Set(StateServerKexDHReply)
+	s.KexDHReplyLength = k.Length
+	if s.Server.HostKey == nil && k.HostKey != nil {
+		s.Server.HostKey = &HostKey{
+			ESSHHostKey: k.HostKey,
+			SHA256:      k.HostKey.FingerprintSHA256(),
+			MD5:         k.HostKey.FingerprintMD5(),
+		}
+	}
+	s.KexExchangeSeen = s.KexExchangeComplete()
+}
+
+func (s *Session) ClientKexGexRequest(k *essh.ESSHKexGexRequestRecord) {
+	s.KexGexRequest = k
+}
+
+func (s *Session) ServerKexGexGroup(k *essh.ESSHKexGexGroupRecord) {
+	s.KexGexGroupBits = k.GroupBits
+}
+
+func (s *Session) ServerSSH1PublicKey(k *essh.ESSH1PublicKeyRecord) {
+	s.Server.SSH1PublicKey = k
+	s.SeenServerKex = true
+	s.HandshakeComplete = s.SSH1Complete()
+}
+
+func (s *Session) ClientSSH1SessionKey(k *essh.ESSH1SessionKeyRecord) {
+	s.Client.SSH1SessionKey = k
+	s.SeenClientKex = true
+	s.HandshakeComplete = s.SSH1Complete()
+}
+
+func (s *Session) ClientNewKeys() {
+	s.state.Set(StateClientNewKeys)
+	s.newKeys[0]++
+	s.HandshakeComplete = s.NewKeysComplete()
+}
+
+func (s *Session) ServerNewKeys() {
+	s.state.Set(StateServerNewKeys)
+	s.newKeys[1]++
+	s.HandshakeComplete = s.NewKeysComplete()
+}
+
+// rekeyStarted counts the KEXINIT seen at ts, of a side which sent newKeys
+// NEWKEYS before it, as the start of a rekey, unless the other side already
+// started the same one.
+func (s *Session) rekeyStarted(newKeys int, ts time.Time) {
+	if newKeys > s.RekeyCount {
+		s.RekeyCount = newKeys
+		s.RekeyTimes = append(s.RekeyTimes, ts)
+	}
+}
+
+// Cleartext reports if the packets the client, or the server, sends once
+// the keys are in use stay in the clear and can still be decoded: the
+// cipher, MAC and compression negotiated for the direction, as given by
+// RFC 4253 section 7.1, are all none.
+func (s *Session) Cleartext(client bool) bool {
+	n := s.Negotiated
+	if n == nil {
+		return false
+	}
+	d := n.ServerClient
+	if client {
+		d = n.ClientServer
+	}
+	return d == Direction{"none", "none", "none"}
+}
+
+// Tunnel is the encapsulation a session was captured in. The addresses are
+// those of the tunnel endpoints, the session holds those of the client and
+// server.
+type Tunnel struct {
+	Type   string `json:"type"`
+	SrcIP  string `json:"src_ip"`
+	DestIP string `json:"dest_ip"`
+	ID     uint32 `json:"id,omitempty"` // VXLAN network identifier or GRE key
+}
+
+// Geo locates an address
+type Geo struct {
+	Country string `json:"country,omitempty"` // ISO 3166-1 code
+	ASN     uint   `json:"asn,omitempty"`
+	Org     string `json:"org,omitempty"` // of the autonomous system
+}
@@ -0,0 +1,283 @@
+package session
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kjelle/gohassh/essh"
+)
+
+func TestSourceTimestamp(t *testing.T) {
+	first := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	banner, kexinit := first.Add(time.Second), first.Add(2*time.Second)
+	for _, test := range []struct {
+		source          string
+		banner, kexinit *time.Time
+		expected        time.Time
+	}{
+		{TimestampFirstPacket, &banner, &kexinit, first},
+		{TimestampBanner, &banner, &kexinit, banner},
+		{TimestampKexinit, &banner, &kexinit, kexinit},
+		{TimestampBanner, nil, &kexinit, kexinit},
+		{TimestampKexinit, &banner, nil, banner},
+		{TimestampKexinit, nil, nil, first},
+	} {
+		s := Session{FirstSeen: first, BannerTime: test.banner, KexinitTime: test.kexinit}
+		if ts := s.SourceTimestamp(test.source); !ts.Equal(test.expected) {
+			t.Errorf("mismatch on %s timestamp (banner:%t,kexinit:%t)\n\nexpected:\n%s\ngot: \n%s\n", test.source, test.banner != nil, test.kexinit != nil, test.expected, ts)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	s := New("eth0", Options{})
+	s.SetTimestamp(time.Date(2019, 1, 1, 0, 0, 0, 500, time.UTC))
+	value, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pointer, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value) != string(pointer) {
+		t.Errorf("mismatch between a session and a pointer to it\n\nvalue:\n%s\npointer: \n%s\n", value, pointer)
+	}
+	if expected := `"timestamp":"2019-01-01T00:00:00.0000005Z"`; !strings.Contains(string(pointer), expected) {
+		t.Errorf("expected %s, got %s", expected, pointer)
+	}
+}
+
+func TestConnectionState(t *testing.T) {
+	banner := func() *essh.ESSHBannerRecord {
+		return &essh.ESSHBannerRecord{ProtoVersion: "2.0", SoftwareVersion: "OpenSSH_7.4"}
+	}
+	kexinit := &essh.ESSHKexinitRecord{KexAlgos: "curve25519-sha256", CiphersClientServer: "aes128-ctr", CiphersServerClient: "aes128-ctr"}
+	for k, test := range map[string]struct {
+		feed     func(s *Session)
+		expected string
+	}{
+		"complete": {func(s *Session) {
+			s.ClientBanner(banner())
+			s.ServerBanner(banner())
+			s.ClientKeyExchangeInit(kexinit, time.Time{})
+			s.ServerKeyExchangeInit(kexinit, time.Time{})
+		}, ConnComplete},
+		"banners only": {func(s *Session) {
+			s.ClientBanner(banner())
+			s.ServerBanner(banner())
+		}, ConnBannerOnly},
+		"client only": {func(s *Session) {
+			s.ClientBanner(banner())
+			s.ClientKeyExchangeInit(kexinit, time.Time{})
+		}, ConnClientOnly},
+		"malformed": {func(s *Session) {
+			s.ClientBanner(banner())
+			s.ServerBanner(banner())
+			s.SetMalformed()
+		}, ConnMalformed},
+	} {
+		s := New("eth0", Options{})
+		test.feed(&s)
+		if state := s.ConnectionState(); state != test.expected {
+			t.Errorf("failed testcase '%s', mismatch on ConnectionState\n\nexpected:\n%s\ngot: \n%s\n", k, test.expected, state)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	kexinit := &essh.ESSHKexinitRecord{KexAlgos: "curve25519-sha256", CiphersClientServer: "aes128-ctr", MACsClientServer: "hmac-sha2-256", CompressionClientServer: "none"}
+	for _, o := range []Options{{}, {HASSHInput: true, HASSHFull: true, BannerRaw: true}} {
+		s := New("eth0", o)
+		s.ClientBanner(&essh.ESSHBannerRecord{Raw: []byte("SSH-2.0-OpenSSH_7.4\r\n"), Terminator: "\r\n"})
+		s.ClientKeyExchangeInit(kexinit, time.Time{})
+		if (s.Client.HASSHInput != nil) != o.HASSHInput {
+			t.Errorf("mismatch on hasshInput with %+v", o)
+		}
+		if (s.Client.HASSHFull != nil) != o.HASSHFull {
+			t.Errorf("mismatch on hasshFull with %+v", o)
+		}
+		if (s.Client.Raw != nil) != o.BannerRaw {
+			t.Errorf("mismatch on raw banner with %+v", o)
+		}
+	}
+}
+
+func TestRekey(t *testing.T) {
+	kexinit := &essh.ESSHKexinitRecord{KexAlgos: "curve25519-sha256", CiphersClientServer: "aes128-ctr", CiphersServerClient: "aes128-ctr"}
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+
+	s := New("eth0", Options{})
+	s.ClientKeyExchangeInit(kexinit, at(0))
+	s.ServerKeyExchangeInit(kexinit, at(0))
+	s.ServerNewKeys()
+	s.ClientNewKeys()
+	if !s.HandshakeComplete || s.RekeyCount != 0 {
+		t.Fatalf("mismatch after the handshake, complete %t, rekeys %d", s.HandshakeComplete, s.RekeyCount)
+	}
+	// Started by the server, then by the client
+	s.ServerKeyExchangeInit(kexinit, at(10))
+	s.ClientKeyExchangeInit(kexinit, at(11))
+	s.ClientNewKeys()
+	s.ServerNewKeys()
+	s.ClientKeyExchangeInit(kexinit, at(20))
+	s.ServerKeyExchangeInit(kexinit, at(21))
+
+	if s.RekeyCount != 2 {
+		t.Errorf("mismatch on RekeyCount\n\nexpected:\n%d\ngot: \n%d\n", 2, s.RekeyCount)
+	}
+	if expected := []time.Time{at(10), at(20)}; !reflect.DeepEqual(s.RekeyTimes, expected) {
+		t.Errorf("mismatch on RekeyTimes\n\nexpected:\n%v\ngot: \n%v\n", expected, s.RekeyTimes)
+	}
+}
+
+func TestCleartext(t *testing.T) {
+	for k, test := range map[string]struct {
+		client, server string // ciphers from the client to the server
+		mac            string // of both sides
+		expected       bool
+	}{
+		"none":             {"none", "none", "none", true},
+		"none not offered": {"aes128-ctr,none", "aes128-ctr", "none", false},
+		"client preferred": {"aes128-ctr,none", "none,aes128-ctr", "none", false},
+		"server preferred": {"none,aes128-ctr", "aes128-ctr,none", "none", true},
+		"MAC":              {"none", "none", "hmac-sha2-256", false},
+	} {
+		s := New("eth0", Options{})
+		s.ClientKeyExchangeInit(&essh.ESSHKexinitRecord{
+			CiphersClientServer: test.client, MACsClientServer: test.mac, CompressionClientServer: "none",
+			CiphersServerClient: "aes128-ctr", MACsServerClient: test.mac, CompressionServerClient: "none",
+		}, time.Time{})
+		s.ServerKeyExchangeInit(&essh.ESSHKexinitRecord{
+			CiphersClientServer: test.server, MACsClientServer: test.mac, CompressionClientServer: "none",
+			CiphersServerClient: "aes128-ctr", MACsServerClient: test.mac, CompressionServerClient: "none",
+		}, time.Time{})
+		if c := s.Cleartext(true); c != test.expected {
+			t.Errorf("failed testcase '%s', mismatch on Cleartext\n\nexpected:\n%t\ngot: \n%t\n", k, test.expected, c)
+		}
+		if s.Cleartext(false) {
+			t.Errorf("failed testcase '%s', server to client is encrypted", k)
+		}
+	}
+}
+
+func TestStrictKex(t *testing.T) {
+	for kex, expected := range map[string][2]bool{
+		"curve25519-sha256": {false, false},
+		"curve25519-sha256,kex-strict-c-v00@openssh.com": {true, false},
+		"curve25519-sha256,kex-strict-s-v00@openssh.com": {false, true},
+	} {
+		// The marker is parsed by essh, as it would be from the same
+		// KEXINIT on both sides
+		k := &essh.ESSHKexinitRecord{
+			KexAlgos:        kex,
+			StrictKexClient: strings.Contains(kex, "-c-"),
+			StrictKexServer: strings.Contains(kex, "-s-"),
+		}
+		s := New("eth0", Options{})
+		s.ClientKeyExchangeInit(k, time.Time{})
+		s.ServerKeyExchangeInit(k, time.Time{})
+		if s.Client.SupportsStrictKex != expected[0] || s.Server.SupportsStrictKex != expected[1] {
+			t.Errorf("mismatch for %q, expected client:%t server:%t, got %t %t", kex, expected[0], expected[1], s.Client.SupportsStrictKex, s.Server.SupportsStrictKex)
+		}
+	}
+}
+
+func TestExtInfo(t *testing.T) {
+	for kex, expected := range map[string][2]bool{
+		"curve25519-sha256":            {false, false},
+		"curve25519-sha256,ext-info-c": {true, false},
+		"curve25519-sha256,ext-info-s": {false, true},
+	} {
+		// The marker is parsed by essh, as it would be from the same
+		// KEXINIT on both sides
+		k := &essh.ESSHKexinitRecord{
+			KexAlgos:      kex,
+			ExtInfo:       strings.Contains(kex, "ext-info"),
+			ExtInfoClient: strings.HasSuffix(kex, "-c"),
+			ExtInfoServer: strings.HasSuffix(kex, "-s"),
+		}
+		s := New("eth0", Options{})
+		s.ClientKeyExchangeInit(k, time.Time{})
+		s.ServerKeyExchangeInit(k, time.Time{})
+		if s.Client.SupportsExtInfo != expected[0] || s.Server.SupportsExtInfo != expected[1] {
+			t.Errorf("mismatch for %q, expected client:%t server:%t, got %t %t", kex, expected[0], expected[1], s.Client.SupportsExtInfo, s.Server.SupportsExtInfo)
+		}
+	}
+}
+
+func TestTerrapinVulnerable(t *testing.T) {
+	for k, test := range map[string]struct {
+		cipher, mac string // of the client, the server offers all of them
+		strict      [2]bool
+		expected    bool
+	}{
+		"chacha20-poly1305":          {"chacha20-poly1305@openssh.com", "hmac-sha2-256", [2]bool{}, true},
+		"CBC with EtM":               {"aes128-cbc", "hmac-sha2-256-etm@openssh.com", [2]bool{}, true},
+		"CBC":                        {"aes128-cbc", "hmac-sha2-256", [2]bool{}, false},
+		"CTR with EtM":               {"aes128-ctr", "hmac-sha2-256-etm@openssh.com", [2]bool{}, false},
+		"GCM":                        {"aes256-gcm@openssh.com", "hmac-sha2-256-etm@openssh.com", [2]bool{}, false},
+		"strict key exchange":        {"chacha20-poly1305@openssh.com", "hmac-sha2-256", [2]bool{true, true}, false},
+		"strict key exchange client": {"chacha20-poly1305@openssh.com", "hmac-sha2-256", [2]bool{true, false}, true},
+	} {
+		ciphers := "aes256-gcm@openssh.com,chacha20-poly1305@openssh.com,aes128-ctr,aes128-cbc"
+		macs := "hmac-sha2-256-etm@openssh.com,hmac-sha2-256"
+		s := New("eth0", Options{})
+		s.ClientKeyExchangeInit(&essh.ESSHKexinitRecord{
+			CiphersClientServer: test.cipher, CiphersServerClient: test.cipher,
+			MACsClientServer: test.mac, MACsServerClient: test.mac,
+			StrictKexClient: test.strict[0],
+		}, time.Time{})
+		if s.TerrapinVulnerable {
+			t.Errorf("failed testcase '%s', vulnerable before the server KEXINIT", k)
+		}
+		s.ServerKeyExchangeInit(&essh.ESSHKexinitRecord{
+			CiphersClientServer: ciphers, CiphersServerClient: ciphers,
+			MACsClientServer: macs, MACsServerClient: macs,
+			StrictKexServer: test.strict[1],
+		}, time.Time{})
+		if s.TerrapinVulnerable != test.expected {
+			t.Errorf("failed testcase '%s', mismatch on TerrapinVulnerable\n\nexpected:\n%t\ngot: \n%t\n", k, test.expected, s.TerrapinVulnerable)
+		}
+	}
+}
+
+func TestNegotiated(t *testing.T) {
+	s := New("eth0", Options{})
+	s.ClientKeyExchangeInit(&essh.ESSHKexinitRecord{
+		KexAlgos:                "curve25519-sha256,ext-info-c,kex-strict-c-v00@openssh.com",
+		ServerHostKeyAlgos:      "ssh-ed25519,rsa-sha2-512",
+		CiphersClientServer:     "aes256-gcm@openssh.com,aes128-ctr",
+		CiphersServerClient:     "aes128-ctr",
+		MACsClientServer:        "hmac-sha2-256",
+		MACsServerClient:        "hmac-sha2-512,hmac-sha2-256",
+		CompressionClientServer: "none",
+		CompressionServerClient: "zlib@openssh.com,none",
+	}, time.Time{})
+	if s.Negotiated != nil {
+		t.Errorf("negotiated before the server KEXINIT: %+v", s.Negotiated)
+	}
+	s.ServerKeyExchangeInit(&essh.ESSHKexinitRecord{
+		KexAlgos:                "diffie-hellman-group14-sha256,curve25519-sha256,ext-info-s,kex-strict-s-v00@openssh.com",
+		ServerHostKeyAlgos:      "rsa-sha2-512",
+		CiphersClientServer:     "aes128-ctr,aes256-gcm@openssh.com",
+		CiphersServerClient:     "aes128-ctr",
+		MACsClientServer:        "hmac-sha2-256",
+		MACsServerClient:        "hmac-sha2-256,hmac-sha2-512",
+		CompressionClientServer: "none",
+		CompressionServerClient: "none",
+	}, time.Time{})
+	expected := &Negotiated{
+		Kex:          "curve25519-sha256",
+		HostKey:      "rsa-sha2-512",
+		ClientServer: Direction{"aes256-gcm@openssh.com", MACImplicit, "none"},
+		ServerClient: Direction{"aes128-ctr", "hmac-sha2-512", "none"},
+	}
+	if !reflect.DeepEqual(s.Negotiated, expected) {
+		t.Errorf("mismatch on Negotiated\n\nexpected:\n%+v\ngot: \n%+v\n", expected, s.Negotiated)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package session
 
 // State flag which keeps record of which handeshake message types
 // have been parsed.
@@ -9,6 +9,10 @@ const (
 	StateServerBanner
 	StateClientKexInit
 	StateServerKexInit
+	StateClientKexDHInit
+	StateServerKexDHReply
+	StateClientNewKeys
+	StateServerNewKeys
 )
 
 func (s *State) Set(flag State) {
@@ -0,0 +1,29 @@
+package session
+
+import "strings"
+
+// terrapinVulnerable reports if the connection is open to the Terrapin
+// prefix truncation attack (CVE-2023-48795): a direction negotiates
+// chacha20-poly1305@openssh.com, or a CBC cipher with an encrypt-then-MAC
+// MAC, and the two sides do not both support strict key exchange. Both
+// KEXINITs must have been seen.
+func (s *Session) terrapinVulnerable() bool {
+	n := s.Negotiated
+	if n == nil {
+		return false
+	}
+	if s.Client.SupportsStrictKex && s.Server.SupportsStrictKex {
+		return false
+	}
+	return terrapinCipher(n.ClientServer.Cipher, n.ClientServer.MAC) ||
+		terrapinCipher(n.ServerClient.Cipher, n.ServerClient.MAC)
+}
+
+// terrapinCipher reports if the cipher and MAC of a direction let an
+// attacker drop packets unnoticed by adjusting the sequence numbers.
+func terrapinCipher(cipher, mac string) bool {
+	if cipher == "chacha20-poly1305@openssh.com" {
+		return true
+	}
+	return strings.Contains(cipher, "-cbc") && strings.HasSuffix(mac, "-etm@openssh.com")
+}